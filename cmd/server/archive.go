@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"github.com/ride4Low/trip-service/internal/config"
+	"github.com/ride4Low/trip-service/internal/retention"
+)
+
+// runArchive moves finished trips older than the retention age out of the
+// trips collection. It runs once, e.g. from a cron job, or every -every
+// until stopped.
+func runArchive(serviceCfg *config.Config, args []string) {
+	cfg := serviceCfg.RetentionConfig()
+
+	fs := flag.NewFlagSet("archive", flag.ExitOnError)
+	fs.DurationVar(&cfg.MaxAge, "older-than", cfg.MaxAge, "archive trips created longer ago than this (RETENTION_MAX_AGE)")
+	fs.StringVar(&cfg.Target, "target", cfg.Target, "where archived trips go: collection or file (RETENTION_TARGET)")
+	fs.StringVar(&cfg.Dir, "dir", cfg.Dir, "directory of the gzipped NDJSON files of the file target (RETENTION_DIR)")
+	fs.StringVar(&cfg.Redaction, "redaction", cfg.Redaction, "none, pseudonymize or strip personal data (RETENTION_REDACTION)")
+	fs.IntVar(&cfg.BatchSize, "batch-size", cfg.BatchSize, "trips archived per batch (RETENTION_BATCH_SIZE)")
+	fs.BoolVar(&cfg.DryRun, "dry-run", false, "count the trips to archive without moving them")
+	every := fs.Duration("every", 0, "run again at this interval until stopped")
+	fs.Parse(args)
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	dbCfg := serviceCfg.MongoConfig()
+	mongoClient, err := mongo.NewMongoClient(dbCfg)
+	if err != nil {
+		log.Fatalf("failed to connect to MongoDB: %v", err)
+	}
+	defer mongoClient.Disconnect(context.Background())
+
+	db := mongo.GetDatabase(mongoClient, dbCfg.Database)
+
+	for {
+		sink := retention.NewCollectionSink(db)
+		if cfg.Target == retention.TargetFile {
+			if sink, err = retention.NewFileSink(cfg.Dir, time.Now()); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		report, err := retention.NewArchiver(db, sink, cfg).Run(ctx)
+		log.Printf("Archived %d trips to %s (dry run: %t)", report.Archived, cfg.Target, report.DryRun)
+		if err != nil && ctx.Err() == nil {
+			log.Fatalf("archive aborted: %v", err)
+		}
+
+		if *every <= 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(*every):
+		}
+	}
+}
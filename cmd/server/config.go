@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ride4Low/trip-service/internal/config"
+)
+
+// runConfig prints the effective configuration, after the file, environment
+// and flags were applied, with its secrets masked:
+//
+//	trip-service [-config file] config print
+//
+// Validation errors are reported after the configuration.
+func runConfig(cfg *config.Config, loadErr error, args []string) {
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: trip-service [-config file] config print")
+	}
+	fs.Parse(args)
+
+	if fs.Arg(0) != "print" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if cfg == nil {
+		log.Fatal(loadErr)
+	}
+
+	if err := cfg.Print(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+	if loadErr != nil {
+		fmt.Fprintln(os.Stderr, loadErr)
+		os.Exit(1)
+	}
+}
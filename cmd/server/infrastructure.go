@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/ride4Low/contracts/events"
+	"github.com/ride4Low/contracts/pkg/otel"
+	amqpClient "github.com/ride4Low/contracts/pkg/rabbitmq"
+	"github.com/ride4Low/trip-service/internal/adapter/haversine"
+	"github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"github.com/ride4Low/trip-service/internal/adapter/osrm"
+	"github.com/ride4Low/trip-service/internal/config"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/events/inprocess"
+	"github.com/ride4Low/trip-service/internal/events/rabbitmq"
+	"github.com/ride4Low/trip-service/internal/health"
+	"github.com/ride4Low/trip-service/internal/lifecycle"
+	"github.com/ride4Low/trip-service/internal/metrics"
+	"github.com/ride4Low/trip-service/internal/privacy"
+	"github.com/ride4Low/trip-service/internal/repository"
+	"github.com/ride4Low/trip-service/internal/retry"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// infrastructure is what the trip service runs on: MongoDB, RabbitMQ, OSRM
+// and Jaeger, or in-process stand-ins for all of them in local mode.
+type infrastructure struct {
+	repo          domain.Repository
+	routeProvider domain.RouteProvider
+	publisher     rabbitmq.MessagePublisher
+	consume       func(ctx context.Context, queue string, handler inprocess.MessageHandler) error
+	checks        map[string]health.Checker
+	// drain waits for the messages being published
+	drain lifecycle.StopFunc
+	// closers release the connections, in the order they must be closed
+	// once the work in progress drained
+	closers []closer
+}
+
+type closer struct {
+	name  string
+	close lifecycle.StopFunc
+}
+
+func newInfrastructure(ctx context.Context, cfg *config.Config, privacyCfg *privacy.Config, m *metrics.Metrics, logger *slog.Logger) *infrastructure {
+	infra := &infrastructure{checks: map[string]health.Checker{}}
+
+	otelCfg := otel.DefaultConfig("trip-service")
+	otelCfg.JaegerEndpoint = cfg.Tracing.JaegerEndpoint
+
+	otelProvider, err := otel.Setup(ctx, otelCfg)
+	if err != nil {
+		log.Fatalf("failed to setup otel: %v", err)
+	}
+	infra.closers = append(infra.closers, closer{name: "telemetry", close: otelProvider.Shutdown})
+
+	dbCfg := cfg.MongoConfig()
+	var mongoClient *mongoDriver.Client
+	err = retry.Do(ctx, cfg.RetryPolicy(), func(ctx context.Context) error {
+		mongoClient, err = mongo.NewMongoClient(dbCfg, options.Client().SetMonitor(m.CommandMonitor()))
+		return err
+	}, func(attempt int, err error, wait time.Duration) {
+		logger.WarnContext(ctx, "Failed to connect to MongoDB, retrying", "attempt", attempt, "retry_in", wait, "error", err)
+	})
+	if err != nil {
+		log.Fatalf("failed to connect to MongoDB: %v", err)
+	}
+	infra.checks["mongo"] = health.MongoChecker(mongoClient)
+	infra.closers = append(infra.closers, closer{name: "mongo", close: mongoClient.Disconnect})
+	db, repoOpts := openDatabase(mongoClient, dbCfg.Database, privacyCfg)
+
+	// Replicas booting together wait on the migration lock, so each
+	// migration runs once
+	if cfg.Mongo.MigrateOnStart {
+		if err := migrateUp(ctx, db); err != nil {
+			log.Fatal(err)
+		}
+	}
+	infra.repo = repository.NewRepository(db, repoOpts...)
+
+	osrmClient := osrm.NewClient(cfg.OSRM.URL, osrm.WithCoordinatePrecision(privacyCfg.CoordinatePrecision), osrm.WithLogger(logger))
+	infra.routeProvider = metrics.NewRouteProvider(osrmClient, m)
+	infra.checks["osrm"] = health.PingChecker(osrmClient)
+
+	rmq := rabbitmq.NewConnection(func() (*amqpClient.RabbitMQ, error) {
+		return amqpClient.NewRabbitMQ(cfg.RabbitMQ.URI)
+	}, cfg.RetryPolicy(), logger)
+	if err := rmq.Connect(ctx); err != nil {
+		log.Fatal(err)
+	}
+	// Reconnection stops with the signal, before the connection is closed
+	go rmq.Run(ctx)
+	infra.closers = append(infra.closers, closer{name: "rabbitmq", close: func(ctx context.Context) error {
+		rmq.Close()
+		return nil
+	}})
+	infra.checks["rabbitmq"] = health.RabbitMQChecker(rmq.Channel)
+
+	publisher := rabbitmq.NewPublisher(rmq, cfg.RabbitMQ.Exchange)
+	infra.publisher = publisher
+	infra.drain = publisher.Drain
+	infra.consume = rmq.Consume
+
+	return infra
+}
+
+// localBindings mirrors the RabbitMQ queue bindings for the in-process bus
+var localBindings = map[string][]string{
+	events.DriverTripResponseQueue:   {events.DriverCmdTripAccept, events.DriverCmdTripDecline},
+	events.NotifyPaymentSuccessQueue: {events.PaymentEventSuccess},
+}
+
+// newLocalInfrastructure runs the whole gRPC API on a laptop: trips live in
+// memory, routes are straight lines, events stay in the process and no
+// traces are exported.
+func newLocalInfrastructure(logger *slog.Logger) *infrastructure {
+	logger.Info("Running in local mode with in-memory stand-ins for MongoDB, RabbitMQ, OSRM and Jaeger")
+
+	bus := inprocess.NewBus(logger)
+
+	return &infrastructure{
+		repo:          repository.NewMemoryRepository(),
+		routeProvider: haversine.NewClient(),
+		publisher:     bus,
+		consume: func(ctx context.Context, queue string, handler inprocess.MessageHandler) error {
+			bus.Subscribe(handler, localBindings[queue]...)
+			return nil
+		},
+		drain: bus.Drain,
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/ride4Low/trip-service/internal/config"
+	"github.com/ride4Low/trip-service/internal/logging"
+)
+
+// newLogger builds the logger and makes it the default, so the standard log
+// package and libraries write through it too
+func newLogger(cfg *config.Config) *slog.Logger {
+	loggingCfg, err := cfg.LoggingConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logger := logging.New(os.Stderr, loggingCfg)
+	slog.SetDefault(logger)
+	return logger
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"github.com/ride4Low/trip-service/internal/config"
+	"github.com/ride4Low/trip-service/internal/migration"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+// runMigrate applies, reverts or lists the schema migrations:
+//
+//	trip-service migrate up
+//	trip-service migrate down [-steps n]
+//	trip-service migrate status
+func runMigrate(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	steps := fs.Int("steps", 1, "number of migrations to revert with down")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: trip-service migrate up | down [-steps n] | status")
+		fs.PrintDefaults()
+	}
+
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	command := args[0]
+	fs.Parse(args[1:])
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	dbCfg := cfg.MongoConfig()
+	mongoClient, err := mongo.NewMongoClient(dbCfg)
+	if err != nil {
+		log.Fatalf("failed to connect to MongoDB: %v", err)
+	}
+	defer mongoClient.Disconnect(context.Background())
+
+	db := mongo.GetDatabase(mongoClient, dbCfg.Database)
+
+	runner, err := migration.NewRunner(db, migration.Migrations())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch command {
+	case "up":
+		applied, err := runner.Up(ctx)
+		log.Printf("Applied %d migrations", len(applied))
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "down":
+		reverted, err := runner.Down(ctx, *steps)
+		log.Printf("Reverted %d migrations", len(reverted))
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printMigrationStatus(statuses)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+func printMigrationStatus(statuses []migration.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATUS\tAPPLIED AT\tDESCRIPTION")
+	for _, s := range statuses {
+		state, appliedAt := "pending", ""
+		if s.Applied {
+			state, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+		}
+		if s.Up == nil {
+			state += " (unknown to this build)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, state, appliedAt, s.Description)
+	}
+	w.Flush()
+}
+
+// migrateUp applies the pending migrations when the server starts
+func migrateUp(ctx context.Context, db *mongoDriver.Database) error {
+	runner, err := migration.NewRunner(db, migration.Migrations())
+	if err != nil {
+		return err
+	}
+
+	applied, err := runner.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to migrate the database: %w", err)
+	}
+	if len(applied) > 0 {
+		slog.InfoContext(ctx, "Applied migrations", "count", len(applied), "latest", applied[len(applied)-1].Version)
+	}
+	return nil
+}
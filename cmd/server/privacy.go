@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"github.com/ride4Low/trip-service/internal/config"
+	"github.com/ride4Low/trip-service/internal/privacy"
+	"github.com/ride4Low/trip-service/internal/repository"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func newPrivacyConfig(cfg *config.Config) *privacy.Config {
+	privacyCfg, err := cfg.PrivacyConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return privacyCfg
+}
+
+// openDatabase encrypts routes at rest when encryption keys are configured.
+// It returns the repository options that go with the database.
+func openDatabase(client *mongoDriver.Client, name string, cfg *privacy.Config) (*mongoDriver.Database, []repository.Option) {
+	keyring, err := privacy.NewKeyringFromConfig(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if keyring == nil {
+		slog.Warn("No route encryption keys configured, storing routes in plaintext", "database", name)
+		return mongo.GetDatabase(client, name), nil
+	}
+
+	blindIndex, err := privacy.NewBlindIndex(cfg.BlindIndexKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db := mongo.GetDatabase(client, name, options.Database().SetRegistry(privacy.NewRegistry(keyring)))
+	return db, []repository.Option{repository.WithRouteKeyHash(blindIndex.Blind)}
+}
+
+// runRotateKeys re-encrypts the stored routes with the active key, after a
+// new key was added in front of ROUTE_ENCRYPTION_KEYS. Routes stored before
+// encryption was enabled are encrypted too.
+func runRotateKeys(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("rotate-keys", flag.ExitOnError)
+	fs.Parse(args)
+
+	keyring, err := privacy.NewKeyringFromConfig(newPrivacyConfig(cfg))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if keyring == nil {
+		log.Fatal("ROUTE_ENCRYPTION_KEYS is required to rotate keys")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	dbCfg := cfg.MongoConfig()
+	mongoClient, err := mongo.NewMongoClient(dbCfg)
+	if err != nil {
+		log.Fatalf("failed to connect to MongoDB: %v", err)
+	}
+	defer mongoClient.Disconnect(context.Background())
+
+	counts, err := privacy.Reencrypt(ctx, mongo.GetDatabase(mongoClient, dbCfg.Database), keyring)
+	for collection, n := range counts {
+		log.Printf("Re-encrypted %d documents in %s with key %s", n, collection, keyring.ActiveKeyID())
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"github.com/ride4Low/trip-service/internal/config"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/repository"
+	"github.com/ride4Low/trip-service/internal/service"
+)
+
+// runReplay rebuilds trip projections from the trip_events stream, e.g. after
+// a schema change or to correct data written by a since-fixed bug.
+func runReplay(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	tripIDs := fs.String("trips", "", "comma separated trip ids to rebuild (default: all trips with events)")
+	dryRun := fs.Bool("dry-run", false, "rebuild projections without writing them")
+	reprice := fs.Bool("reprice", false, "recompute fares with the current pricing rules")
+	fs.Parse(args)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	dbCfg := cfg.MongoConfig()
+	mongoClient, err := mongo.NewMongoClient(dbCfg)
+	if err != nil {
+		log.Fatalf("failed to connect to MongoDB: %v", err)
+	}
+	defer mongoClient.Disconnect(context.Background())
+
+	// Replayed events carry encrypted routes that must be written back
+	// encrypted
+	db, repoOpts := openDatabase(mongoClient, dbCfg.Database, newPrivacyConfig(cfg))
+	repo := repository.NewRepository(db, repoOpts...)
+
+	var upcasters []domain.TripEventUpcaster
+	if *reprice {
+		upcasters = append(upcasters, service.RepriceFareUpcaster(cfg.PricingConfig()))
+	}
+
+	var ids []string
+	if *tripIDs != "" {
+		ids = strings.Split(*tripIDs, ",")
+	}
+
+	report, err := service.NewProjectionRebuilder(repo, upcasters...).Rebuild(ctx, ids, *dryRun)
+	if err != nil {
+		log.Fatalf("replay aborted: %v", err)
+	}
+
+	for tripID, err := range report.Failed {
+		log.Printf("failed to rebuild trip %s: %v", tripID, err)
+	}
+	log.Printf("Rebuilt %d trip projections (%d failed, dry run: %t)", report.Rebuilt, len(report.Failed), *dryRun)
+
+	if len(report.Failed) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,66 @@
+package haversine
+
+import (
+	"context"
+	"math"
+
+	"github.com/ride4Low/contracts/types"
+)
+
+const earthRadiusMeters = 6371000
+
+// Client is a RouteProvider that needs no routing engine: the route is the
+// great circle between pickup and dropoff, stretched by a detour factor to
+// approximate the road distance. It is used in local mode instead of OSRM.
+type Client struct {
+	detourFactor         float64
+	speedMetersPerSecond float64
+}
+
+func NewClient() *Client {
+	return &Client{
+		detourFactor:         1.3,
+		speedMetersPerSecond: 30 / 3.6, // 30 km/h average city speed
+	}
+}
+
+func (c *Client) GetRoute(ctx context.Context, pickup, dropoff types.Coordinate) (*types.OsrmApiResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	distance := Distance(pickup, dropoff) * c.detourFactor
+
+	var response types.OsrmApiResponse
+	response.Routes = make([]struct {
+		Distance float64 `json:"distance"`
+		Duration float64 `json:"duration"`
+		Geometry struct {
+			Coordinates [][]float64 `json:"coordinates"`
+		} `json:"geometry"`
+	}, 1)
+
+	route := &response.Routes[0]
+	route.Distance = distance
+	route.Duration = distance / c.speedMetersPerSecond
+	// Same [longitude, latitude] order as OSRM geojson geometries
+	route.Geometry.Coordinates = [][]float64{
+		{pickup.Longitude, pickup.Latitude},
+		{dropoff.Longitude, dropoff.Latitude},
+	}
+
+	return &response, nil
+}
+
+// Distance returns the great circle distance between two coordinates in meters
+func Distance(a, b types.Coordinate) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
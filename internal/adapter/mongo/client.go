@@ -3,34 +3,32 @@ package mongo
 import (
 	"context"
 	"fmt"
-	"log"
-	"strings"
+	"log/slog"
 	"time"
 
-	"github.com/ride4Low/contracts/env"
+	"github.com/ride4Low/trip-service/internal/domain"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const (
-	TripsCollection     = "trips"
-	RideFaresCollection = "ride_fares"
+	TripsCollection      = "trips"
+	RideFaresCollection  = "ride_fares"
+	TripEventsCollection = "trip_events"
+	RatingsCollection    = "ratings"
+	QuotesCollection     = "quotes"
 )
 
+// RideFareTTL is how long a ride fare can be booked after the preview
+const RideFareTTL = domain.RideFareTTL
+
 type MongoConfig struct {
 	URI      string
 	Database string
 }
 
-func NewMongoDefaultConfig() *MongoConfig {
-	return &MongoConfig{
-		URI:      env.GetString("MONGODB_URI", ""),
-		Database: env.GetString("MONGODB_DATABASE", ""),
-	}
-}
-
-func NewMongoClient(cfg *MongoConfig) (*mongo.Client, error) {
+func NewMongoClient(cfg *MongoConfig, opts ...*options.ClientOptions) (*mongo.Client, error) {
 	if cfg.URI == "" {
 		return nil, fmt.Errorf("mongodb URI is required")
 	}
@@ -41,51 +39,90 @@ func NewMongoClient(cfg *MongoConfig) (*mongo.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
 
-	clientOptions := options.Client().ApplyURI(cfg.URI)
-	client, err := mongo.Connect(ctx, clientOptions)
+	clientOptions := append([]*options.ClientOptions{options.Client().ApplyURI(cfg.URI)}, opts...)
+	client, err := mongo.Connect(ctx, clientOptions...)
 	if err != nil {
 		return nil, err
 	}
 
 	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
 		return nil, err
 	}
 
-	err = CreateTTLIndex(ctx, GetDatabase(client, cfg.Database))
-	if err != nil {
-		return nil, err
-	}
-	log.Println("Successfully connected to MongoDB")
+	slog.Info("Successfully connected to MongoDB")
 	return client, nil
 }
 
-func GetDatabase(client *mongo.Client, database string) *mongo.Database {
-	return client.Database(database)
+func GetDatabase(client *mongo.Client, database string, opts ...*options.DatabaseOptions) *mongo.Database {
+	return client.Database(database, opts...)
+}
+
+func CreateTripEventsIndex(ctx context.Context, db *mongo.Database) error {
+	indexModels := []mongo.IndexModel{
+		{
+			// Timeline reads fetch every event of a trip in chronological order
+			Keys: bson.D{
+				{Key: "trip_id", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+			Options: options.Index().SetName("trip_id_1_created_at_1"),
+		},
+		{
+			// Event-sourced writes carry a per-trip version; two concurrent
+			// commands on the same trip cannot both append the same version.
+			Keys: bson.D{
+				{Key: "trip_id", Value: 1},
+				{Key: "version", Value: 1},
+			},
+			Options: options.Index().
+				SetName("trip_id_1_version_1").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"version": bson.M{"$gt": 0}}),
+		},
+	}
+
+	_, err := db.Collection(TripEventsCollection).Indexes().CreateMany(ctx, indexModels)
+	return err
 }
 
-func CreateTTLIndex(ctx context.Context, db *mongo.Database) error {
-	// Create TTL index that expires documents after 24 hours (86400 seconds)
+func CreateRatingsIndex(ctx context.Context, db *mongo.Database) error {
+	// Each participant can rate a trip only once
 	indexModel := mongo.IndexModel{
-		Keys: bson.M{
-			"created_at": 1, // index on the created_at field
+		Keys: bson.D{
+			{Key: "trip_id", Value: 1},
+			{Key: "rater_role", Value: 1},
 		},
-		Options: options.Index().SetExpireAfterSeconds(86400).SetName("created_at_1"), // 24 hours TTL
+		Options: options.Index().SetName("trip_id_1_rater_role_1").SetUnique(true),
 	}
 
-	collection := db.Collection(RideFaresCollection)
-	_, err := collection.Indexes().CreateOne(ctx, indexModel)
-	if err != nil {
-		// Check for IndexOptionsConflict
-		if strings.Contains(err.Error(), "IndexOptionsConflict") {
-			log.Printf("Index conflict detected for 'created_at_1'. Dropping and recreating...")
-			if _, dropErr := collection.Indexes().DropOne(ctx, "created_at_1"); dropErr != nil {
-				return fmt.Errorf("failed to drop conflicting index: %w", dropErr)
-			}
-			// Retry creating the index
-			_, err = collection.Indexes().CreateOne(ctx, indexModel)
-			return err
-		}
-		return err
+	_, err := db.Collection(RatingsCollection).Indexes().CreateOne(ctx, indexModel)
+	return err
+}
+
+func CreateQuotesIndex(ctx context.Context, db *mongo.Database) error {
+	indexModels := []mongo.IndexModel{
+		{
+			// Quotes hold the route of their fares, so they live as long
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(RideFareTTL.Seconds())).SetName("created_at_1"),
+		},
+		{
+			// Repeated previews look up the latest quote of a user for a route
+			Keys: bson.D{
+				{Key: "userID", Value: 1},
+				{Key: "route_key", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("userID_1_route_key_1_created_at_-1"),
+		},
+		{
+			// Booking a fare loads it from its quote
+			Keys:    bson.D{{Key: "fares.fare_id", Value: 1}},
+			Options: options.Index().SetName("fares.fare_id_1"),
+		},
 	}
-	return nil
+
+	_, err := db.Collection(QuotesCollection).Indexes().CreateMany(ctx, indexModels)
+	return err
 }
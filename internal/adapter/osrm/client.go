@@ -2,34 +2,91 @@ package osrm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 
 	"github.com/bytedance/sonic"
 	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/privacy"
 )
 
 type Client struct {
-	baseURL string
+	baseURL             string
+	coordinatePrecision int
+	logger              *slog.Logger
 }
 
-func NewClient(baseURL string) *Client {
-	return &Client{
-		baseURL: baseURL,
+// Option configures the OSRM client
+type Option func(*Client)
+
+// WithCoordinatePrecision sets the decimals of the coordinates logged when a
+// request fails
+func WithCoordinatePrecision(precision int) Option {
+	return func(c *Client) {
+		c.coordinatePrecision = precision
+	}
+}
+
+// WithLogger sets the logger, slog.Default() otherwise
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:             baseURL,
+		coordinatePrecision: privacy.DefaultCoordinatePrecision,
+		logger:              slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Ping reports whether the OSRM server answers. Any response short of a
+// server error means it is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create OSRM request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("OSRM is unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("OSRM responded with status code: %d", resp.StatusCode)
 	}
+	return nil
 }
 
 func (c *Client) GetRoute(ctx context.Context, pickup, dropoff types.Coordinate) (*types.OsrmApiResponse, error) {
-	url := fmt.Sprintf(
+	routeURL := fmt.Sprintf(
 		"%s/route/v1/driving/%f,%f;%f,%f?overview=full&geometries=geojson",
 		c.baseURL,
 		pickup.Longitude, pickup.Latitude,
 		dropoff.Longitude, dropoff.Latitude,
 	)
 
-	resp, err := http.Get(url)
+	resp, err := http.Get(routeURL)
 	if err != nil {
+		// The error of the HTTP client carries the URL and with it the exact
+		// pickup and dropoff
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return nil, fmt.Errorf("OSRM request failed: %w", urlErr.Err)
+		}
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -40,8 +97,12 @@ func (c *Client) GetRoute(ctx context.Context, pickup, dropoff types.Coordinate)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		// print body
-		fmt.Println(string(body), url)
+		// The body may echo the requested coordinates, only its size is logged
+		c.logger.WarnContext(ctx, "OSRM route request failed",
+			"status", resp.StatusCode,
+			"pickup", privacy.FuzzCoordinate(&domain.Coordinate{Latitude: pickup.Latitude, Longitude: pickup.Longitude}, c.coordinatePrecision),
+			"dropoff", privacy.FuzzCoordinate(&domain.Coordinate{Latitude: dropoff.Latitude, Longitude: dropoff.Longitude}, c.coordinatePrecision),
+			"body_size", len(body))
 		return nil, fmt.Errorf("OSRM request failed with status code: %d", resp.StatusCode)
 	}
 
@@ -0,0 +1,90 @@
+// Package bsonpath edits fields of decoded BSON documents by path, for the
+// jobs that rewrite stored documents without their personal data
+package bsonpath
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// UpdateFunc returns the new value of a field, or false to remove the field
+type UpdateFunc func(v interface{}) (interface{}, bool)
+
+// Update replaces the value at path, or removes the field when fn does not
+// keep it. Keys are matched case-insensitively, as the protobuf types are
+// stored with lowercased keys. Missing fields are left alone. Embedded
+// documents on the path are copied, so documents shared with doc are not
+// changed.
+func Update(doc bson.D, path []string, fn UpdateFunc) bson.D {
+	for i, e := range doc {
+		if !strings.EqualFold(e.Key, path[0]) {
+			continue
+		}
+
+		if len(path) > 1 {
+			if sub, ok := e.Value.(bson.D); ok {
+				doc[i].Value = Update(Clone(sub), path[1:], fn)
+			}
+			return doc
+		}
+
+		value, keep := fn(e.Value)
+		if !keep {
+			return append(doc[:i:i], doc[i+1:]...)
+		}
+		doc[i].Value = value
+		return doc
+	}
+	return doc
+}
+
+// Split splits a dotted path such as "rideFare.route"
+func Split(path string) []string {
+	return strings.Split(path, ".")
+}
+
+// Clone returns a shallow copy of doc
+func Clone(doc bson.D) bson.D {
+	return append(bson.D{}, doc...)
+}
+
+// Remove removes the field it is applied to
+func Remove(interface{}) (interface{}, bool) {
+	return nil, false
+}
+
+// Embedded applies fn to an embedded document and leaves other values alone
+func Embedded(fn func(bson.D) bson.D) UpdateFunc {
+	return func(v interface{}) (interface{}, bool) {
+		doc, ok := v.(bson.D)
+		if !ok {
+			return v, true
+		}
+		return fn(doc), true
+	}
+}
+
+// UpdateRouteLegs returns a stored route with the encrypted geometry removed
+// and fn applied to each of its legs. The distance and duration of the legs
+// are what receipts show, so fn should keep them.
+func UpdateRouteLegs(fn func(leg bson.D) bson.D) UpdateFunc {
+	return Embedded(func(route bson.D) bson.D {
+		route = Update(Clone(route), []string{"geometry"}, Remove)
+		return Update(route, []string{"routes"}, func(v interface{}) (interface{}, bool) {
+			legs, ok := v.(bson.A)
+			if !ok {
+				return v, true
+			}
+
+			updated := make(bson.A, len(legs))
+			for i, item := range legs {
+				updated[i] = item
+				if leg, ok := item.(bson.D); ok {
+					updated[i] = fn(Clone(leg))
+				}
+			}
+			return updated, true
+		})
+	})
+}
@@ -0,0 +1,93 @@
+package bsonpath
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		fn   UpdateFunc
+		want bson.D
+	}{
+		{
+			name: "replaces a nested value",
+			path: "driver.id",
+			fn:   func(interface{}) (interface{}, bool) { return "anon", true },
+			want: bson.D{{Key: "driver", Value: bson.D{{Key: "id", Value: "anon"}, {Key: "name", Value: "Jane"}}}},
+		},
+		{
+			name: "matches keys case-insensitively",
+			path: "Driver.Name",
+			fn:   Remove,
+			want: bson.D{{Key: "driver", Value: bson.D{{Key: "id", Value: "driver-1"}}}},
+		},
+		{
+			name: "leaves missing fields alone",
+			path: "driver.carplate",
+			fn:   Remove,
+			want: bson.D{{Key: "driver", Value: bson.D{{Key: "id", Value: "driver-1"}, {Key: "name", Value: "Jane"}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			original := bson.D{{Key: "driver", Value: bson.D{{Key: "id", Value: "driver-1"}, {Key: "name", Value: "Jane"}}}}
+
+			// Execute
+			got := Update(Clone(original), Split(tt.path), tt.fn)
+
+			// Verify
+			if !equal(t, got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+			if driver := original[0].Value.(bson.D); len(driver) != 2 || driver[0].Value != "driver-1" {
+				t.Errorf("expected the original document to be left untouched, got %v", original)
+			}
+		})
+	}
+}
+
+func TestUpdateRouteLegs(t *testing.T) {
+	// Setup
+	route := bson.D{
+		{Key: "geometry", Value: bson.D{{Key: "key_id", Value: "k1"}}},
+		{Key: "routes", Value: bson.A{bson.D{
+			{Key: "distance", Value: 1000.0},
+			{Key: "geometry", Value: bson.D{{Key: "coordinates", Value: bson.A{}}}},
+		}}},
+	}
+	removeLegGeometry := UpdateRouteLegs(func(leg bson.D) bson.D {
+		return Update(leg, []string{"geometry"}, Remove)
+	})
+
+	// Execute
+	got, keep := removeLegGeometry(route)
+
+	// Verify
+	want := bson.D{{Key: "routes", Value: bson.A{bson.D{{Key: "distance", Value: 1000.0}}}}}
+	if !keep || !equal(t, got.(bson.D), want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if len(route) != 2 {
+		t.Errorf("expected the original route to be left untouched, got %v", route)
+	}
+}
+
+func equal(t *testing.T, a, b bson.D) bool {
+	t.Helper()
+
+	aData, err := bson.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bData, err := bson.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(aData) == string(bData)
+}
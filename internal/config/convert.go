@@ -0,0 +1,157 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+
+	"github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"github.com/ride4Low/trip-service/internal/domain"
+	grpcHandler "github.com/ride4Low/trip-service/internal/handler/grpc"
+	"github.com/ride4Low/trip-service/internal/logging"
+	"github.com/ride4Low/trip-service/internal/metrics"
+	"github.com/ride4Low/trip-service/internal/privacy"
+	"github.com/ride4Low/trip-service/internal/retention"
+	"github.com/ride4Low/trip-service/internal/retry"
+)
+
+// The methods below hand each package the part of the configuration it owns
+
+func (c *Config) MongoConfig() *mongo.MongoConfig {
+	return &mongo.MongoConfig{
+		URI:      c.Mongo.URI,
+		Database: c.Mongo.Database,
+	}
+}
+
+func (c *Config) LoggingConfig() (*logging.Config, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		return nil, fmt.Errorf("log.level (LOG_LEVEL): %w", err)
+	}
+	if c.Log.Format != logging.FormatJSON && c.Log.Format != logging.FormatText {
+		return nil, fmt.Errorf("log.format (LOG_FORMAT) must be %s or %s", logging.FormatJSON, logging.FormatText)
+	}
+
+	return &logging.Config{
+		Level:               level,
+		Format:              c.Log.Format,
+		CoordinatePrecision: c.Privacy.CoordinatePrecision,
+	}, nil
+}
+
+func (c *Config) PrivacyConfig() (*privacy.Config, error) {
+	keys, first, err := privacy.ParseKeys(c.Privacy.EncryptionKeys)
+	if err != nil {
+		return nil, fmt.Errorf("privacy.encryption_keys (ROUTE_ENCRYPTION_KEYS): %w", err)
+	}
+
+	active := c.Privacy.ActiveKey
+	if active == "" {
+		active = first
+	}
+
+	blindIndexKey, err := base64.StdEncoding.DecodeString(c.Privacy.BlindIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("privacy.blind_index_key (ROUTE_BLIND_INDEX_KEY) is not base64: %w", err)
+	}
+
+	return &privacy.Config{
+		CoordinatePrecision: c.Privacy.CoordinatePrecision,
+		Keys:                keys,
+		ActiveKeyID:         active,
+		BlindIndexKey:       blindIndexKey,
+	}, nil
+}
+
+func (c *Config) MetricsConfig() *metrics.Config {
+	return &metrics.Config{
+		Addr:          c.HTTP.Addr,
+		ZonePrecision: c.Metrics.ZonePrecision,
+	}
+}
+
+func (c *Config) AuthConfig() *grpcHandler.AuthConfig {
+	return &grpcHandler.AuthConfig{
+		JWTSecret:         c.Auth.JWTSecret,
+		JWTIssuer:         c.Auth.JWTIssuer,
+		JWTAudience:       c.Auth.JWTAudience,
+		GatewayIdentities: c.Auth.GatewayIdentities,
+	}
+}
+
+// RateLimitConfig starts from the default limits and applies the overrides
+func (c *Config) RateLimitConfig() (*grpcHandler.RateLimitConfig, error) {
+	cfg := &grpcHandler.RateLimitConfig{
+		Methods:           grpcHandler.DefaultMethodLimits(),
+		TrustForwardedFor: c.RateLimit.TrustForwardedFor,
+		RedisURL:          c.RateLimit.RedisURL,
+	}
+
+	overrides, err := grpcHandler.ParseMethodLimits(c.RateLimit.Limits)
+	if err != nil {
+		return nil, fmt.Errorf("rate_limit.limits (RATE_LIMITS): %w", err)
+	}
+	for method, limit := range overrides {
+		cfg.Methods[method] = limit
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) RetentionConfig() *retention.Config {
+	return &retention.Config{
+		MaxAge:        c.Retention.MaxAge,
+		Statuses:      append([]string{}, c.Retention.Statuses...),
+		Target:        c.Retention.Target,
+		Dir:           c.Retention.Dir,
+		Redaction:     c.Retention.Redaction,
+		RedactionSalt: c.Retention.RedactionSalt,
+		BatchSize:     c.Retention.BatchSize,
+	}
+}
+
+func (c *Config) PricingConfig() *domain.PricingConfig {
+	return &domain.PricingConfig{
+		PricePerUnitOfDistance: c.Pricing.PricePerUnitOfDistance,
+		PricingPerMinute:       c.Pricing.PricePerMinute,
+	}
+}
+
+func (c *Config) QuoteConfig() *domain.QuoteConfig {
+	return &domain.QuoteConfig{
+		ReuseWindow:    c.Quotes.ReuseWindow,
+		RoutePrecision: c.Quotes.RoutePrecision,
+	}
+}
+
+func (c *Config) ReceiptConfig() *domain.ReceiptConfig {
+	return &domain.ReceiptConfig{
+		Currency:       c.Receipts.Currency,
+		TaxRatePercent: c.Receipts.TaxRatePercent,
+	}
+}
+
+func (c *Config) TipConfig() *domain.TipConfig {
+	return &domain.TipConfig{
+		Window:           c.Tips.Window,
+		MaxFarePercent:   c.Tips.MaxFarePercent,
+		MaxAmountInCents: c.Tips.MaxAmountInCents,
+	}
+}
+
+// RatingConfig keeps the default star, tag and comment rules, which are part
+// of the API rather than a deployment setting
+func (c *Config) RatingConfig() *domain.RatingConfig {
+	cfg := domain.DefaultRatingConfig()
+	cfg.Window = c.Ratings.Window
+	return cfg
+}
+
+func (c *Config) RetryPolicy() retry.Policy {
+	return retry.Policy{
+		InitialInterval: c.Connect.InitialBackoff,
+		MaxInterval:     c.Connect.MaxBackoff,
+		MaxElapsed:      c.Connect.Timeout,
+	}
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Flags are the command line overrides of the configuration
+type Flags struct {
+	path      string
+	overrides []func(*Config)
+}
+
+// RegisterFlags defines the configuration flags on fs. They are applied by
+// Load once fs has been parsed.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{}
+
+	fs.StringVar(&f.path, "config", "", "YAML configuration file (TRIP_CONFIG)")
+	f.stringFlag(fs, "grpc-addr", "gRPC listen address (GRPC_ADDR)", func(c *Config) *string { return &c.GRPC.Addr })
+	f.stringFlag(fs, "http-addr", "HTTP listen address of /metrics, /healthz and /readyz (HTTP_ADDR)", func(c *Config) *string { return &c.HTTP.Addr })
+	f.stringFlag(fs, "log-level", "debug, info, warn or error (LOG_LEVEL)", func(c *Config) *string { return &c.Log.Level })
+	f.stringFlag(fs, "log-format", "json or text (LOG_FORMAT)", func(c *Config) *string { return &c.Log.Format })
+	f.stringFlag(fs, "osrm-url", "OSRM server URL (OSRM_URL)", func(c *Config) *string { return &c.OSRM.URL })
+	f.stringFlag(fs, "mongo-database", "MongoDB database (MONGODB_DATABASE)", func(c *Config) *string { return &c.Mongo.Database })
+	f.durationFlag(fs, "tip-window", "how long after a trip is paid the rider can tip (TIP_WINDOW)", func(c *Config) *time.Duration { return &c.Tips.Window })
+	f.durationFlag(fs, "rating-window", "how long after a trip is paid it can be rated (RATING_WINDOW)", func(c *Config) *time.Duration { return &c.Ratings.Window })
+	f.boolFlag(fs, "dev", "run without MongoDB, RabbitMQ, OSRM and Jaeger using in-process stand-ins (TRIP_MODE=local)", func(c *Config, v bool) {
+		if v {
+			c.Mode = ModeLocal
+		} else {
+			c.Mode = ""
+		}
+	})
+	f.boolFlag(fs, "event-sourced", "make trip_events the source of truth of trips (TRIP_EVENT_SOURCED)", func(c *Config, v bool) {
+		c.EventSourced = v
+	})
+
+	return f
+}
+
+func (f *Flags) stringFlag(fs *flag.FlagSet, name, usage string, field func(*Config) *string) {
+	fs.Func(name, usage, func(s string) error {
+		f.overrides = append(f.overrides, func(c *Config) { *field(c) = s })
+		return nil
+	})
+}
+
+func (f *Flags) durationFlag(fs *flag.FlagSet, name, usage string, field func(*Config) *time.Duration) {
+	fs.Func(name, usage, func(s string) error {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		f.overrides = append(f.overrides, func(c *Config) { *field(c) = d })
+		return nil
+	})
+}
+
+func (f *Flags) boolFlag(fs *flag.FlagSet, name, usage string, set func(*Config, bool)) {
+	fs.BoolFunc(name, usage, func(s string) error {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.overrides = append(f.overrides, func(c *Config) { set(c, v) })
+		return nil
+	})
+}
+
+// Load builds the configuration from the defaults, then the YAML file named
+// by -config or TRIP_CONFIG, then the environment, then the flags, each one
+// overriding the previous. Every invalid value is reported at once. The
+// configuration is returned along with validation errors so it can still be
+// printed; it is nil only when the file cannot be read.
+func Load(f *Flags, lookupEnv func(string) (string, bool)) (*Config, error) {
+	if f == nil {
+		f = &Flags{}
+	}
+
+	cfg := Default()
+
+	path := f.path
+	if path == "" {
+		path, _ = lookupEnv("TRIP_CONFIG")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	errs := applyEnv(reflect.ValueOf(cfg).Elem(), lookupEnv)
+
+	for _, override := range f.overrides {
+		override(cfg)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return cfg, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the config file: %w", err)
+	}
+
+	// Unknown keys are rejected, a typo must not silently fall back to the
+	// default
+	if err := yaml.UnmarshalWithOptions(data, c, yaml.Strict()); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv sets the fields tagged env from the environment. Empty variables
+// are treated as unset.
+func applyEnv(v reflect.Value, lookupEnv func(string) (string, bool)) []error {
+	var errs []error
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			errs = append(errs, applyEnv(value, lookupEnv)...)
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		s, ok := lookupEnv(name)
+		if !ok || s == "" {
+			continue
+		}
+
+		if err := setValue(value, s); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", name, err))
+		}
+	}
+
+	return errs
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		v.Set(reflect.ValueOf(splitList(s)))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
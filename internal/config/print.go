@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+
+	"github.com/goccy/go-yaml"
+)
+
+// masked replaces secrets when the configuration is printed
+const masked = "*****"
+
+// Print writes the configuration as YAML with its secrets masked
+func (c *Config) Print(w io.Writer) error {
+	data, err := yaml.Marshal(c.Masked())
+	if err != nil {
+		return fmt.Errorf("failed to marshal the config: %w", err)
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// Masked returns a copy of the configuration with the fields tagged secret
+// masked. The host of secret URLs stays visible, only their password is
+// redacted.
+func (c *Config) Masked() *Config {
+	copied := *c
+	mask(reflect.ValueOf(&copied).Elem())
+	return &copied
+}
+
+func mask(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			mask(value)
+			continue
+		}
+
+		if field.Tag.Get("secret") == "true" && value.Kind() == reflect.String && value.String() != "" {
+			value.SetString(maskSecret(value.String()))
+		}
+	}
+}
+
+func maskSecret(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return masked
+	}
+
+	// Redacted replaces the password with xxxxx
+	return u.Redacted()
+}
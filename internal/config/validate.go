@@ -0,0 +1,135 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// Validate reports every invalid setting, not only the first one
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Mode != "" && c.Mode != ModeLocal {
+		add("mode (TRIP_MODE) must be empty or %s, got %q", ModeLocal, c.Mode)
+	}
+	if c.GRPC.Addr == "" {
+		add("grpc.addr (GRPC_ADDR) is required")
+	}
+	if c.HTTP.Addr == "" {
+		add("http.addr (HTTP_ADDR) is required")
+	}
+	if (c.GRPC.TLS.CertFile == "") != (c.GRPC.TLS.KeyFile == "") {
+		add("grpc.tls.cert_file (TLS_CERT_FILE) and grpc.tls.key_file (TLS_KEY_FILE) must be set together")
+	}
+	if c.GRPC.TLS.ClientCAFile != "" && c.GRPC.TLS.CertFile == "" {
+		add("grpc.tls.client_ca_file (TLS_CLIENT_CA_FILE) requires a server certificate")
+	}
+	for _, file := range []struct{ name, path string }{
+		{"grpc.tls.cert_file (TLS_CERT_FILE)", c.GRPC.TLS.CertFile},
+		{"grpc.tls.key_file (TLS_KEY_FILE)", c.GRPC.TLS.KeyFile},
+		{"grpc.tls.client_ca_file (TLS_CLIENT_CA_FILE)", c.GRPC.TLS.ClientCAFile},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			add("%s: %w", file.name, err)
+		}
+	}
+
+	// Local mode trusts the x-user-id metadata when no authentication is set
+	if !c.Local() && c.Auth.JWTSecret == "" && len(c.Auth.GatewayIdentities) == 0 {
+		add("auth.jwt_secret (JWT_SECRET) or auth.gateway_identities (GATEWAY_IDENTITIES) is required")
+	}
+	if len(c.Auth.GatewayIdentities) > 0 && c.GRPC.TLS.ClientCAFile == "" {
+		add("auth.gateway_identities (GATEWAY_IDENTITIES) requires grpc.tls.client_ca_file (TLS_CLIENT_CA_FILE) to verify the gateways")
+	}
+
+	if _, err := c.LoggingConfig(); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Local mode runs on in-process stand-ins for these
+	if !c.Local() {
+		if c.Mongo.URI == "" {
+			add("mongo.uri (MONGODB_URI) is required")
+		}
+		if c.Mongo.Database == "" {
+			add("mongo.database (MONGODB_DATABASE) is required")
+		}
+		if c.RabbitMQ.URI == "" {
+			add("rabbitmq.uri (RABBITMQ_URI) is required")
+		}
+		if c.RabbitMQ.Exchange == "" {
+			add("rabbitmq.exchange (RABBITMQ_EXCHANGE) is required")
+		}
+		if u, err := url.Parse(c.OSRM.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			add("osrm.url (OSRM_URL) must be an absolute URL, got %q", c.OSRM.URL)
+		}
+	}
+
+	if privacyCfg, err := c.PrivacyConfig(); err != nil {
+		errs = append(errs, err)
+	} else if err := privacyCfg.Validate(); err != nil {
+		add("privacy: %w", err)
+	}
+
+	if c.Metrics.ZonePrecision < 0 || c.Metrics.ZonePrecision > 3 {
+		add("metrics.zone_precision (METRICS_ZONE_PRECISION) must be between 0 and 3 decimals")
+	}
+
+	if _, err := c.RateLimitConfig(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.Pricing.PricePerUnitOfDistance < 0 || c.Pricing.PricePerMinute < 0 {
+		add("pricing prices must not be negative")
+	}
+	if c.Quotes.ReuseWindow < 0 {
+		add("quotes.reuse_window (QUOTE_REUSE_WINDOW) must not be negative")
+	}
+	if c.Quotes.RoutePrecision < 0 || c.Quotes.RoutePrecision > 6 {
+		add("quotes.route_precision (QUOTE_ROUTE_PRECISION) must be between 0 and 6 decimals")
+	}
+	if c.Receipts.Currency == "" {
+		add("receipts.currency (RECEIPT_CURRENCY) is required")
+	}
+	if c.Receipts.TaxRatePercent < 0 {
+		add("receipts.tax_rate_percent (RECEIPT_TAX_RATE_PERCENT) must not be negative")
+	}
+
+	if c.Tips.Window <= 0 {
+		add("tips.window (TIP_WINDOW) must be positive")
+	}
+	if c.Tips.MaxFarePercent <= 0 || c.Tips.MaxAmountInCents <= 0 {
+		add("tips.max_fare_percent (TIP_MAX_FARE_PERCENT) and tips.max_amount_in_cents (TIP_MAX_AMOUNT_IN_CENTS) must be positive")
+	}
+	if c.Ratings.Window <= 0 {
+		add("ratings.window (RATING_WINDOW) must be positive")
+	}
+
+	if err := c.RetentionConfig().Validate(); err != nil {
+		add("retention: %w", err)
+	}
+
+	if c.Shutdown.DrainTimeout <= 0 {
+		add("shutdown.drain_timeout (SHUTDOWN_DRAIN_TIMEOUT) must be positive")
+	}
+	if c.Shutdown.Timeout <= 0 {
+		add("shutdown.timeout (SHUTDOWN_TIMEOUT) must be positive")
+	}
+
+	if c.Connect.Timeout <= 0 {
+		add("connect.timeout (CONNECT_TIMEOUT) must be positive")
+	}
+	if c.Connect.InitialBackoff <= 0 || c.Connect.MaxBackoff < c.Connect.InitialBackoff {
+		add("connect.initial_backoff (CONNECT_INITIAL_BACKOFF) must be positive and at most connect.max_backoff (CONNECT_MAX_BACKOFF)")
+	}
+
+	return errors.Join(errs...)
+}
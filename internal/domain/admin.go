@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/ride4Low/contracts/proto/trip"
+	"github.com/ride4Low/contracts/types"
+)
+
+// Search page sizes
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 500
+)
+
+var (
+	ErrReasonRequired  = NewError(KindInvalidArgument, "REASON_REQUIRED", "a reason is required")
+	ErrUnknownStatus   = NewError(KindInvalidArgument, "UNKNOWN_STATUS", "unknown trip status")
+	ErrNoQuoteSelected = NewError(KindInvalidArgument, "NO_QUOTE_SELECTED", "quotes must be selected by id or user")
+	ErrNotReemittable  = NewError(KindInvalidArgument, "NOT_REEMITTABLE", "event cannot be re-emitted")
+)
+
+// AdminService is what support uses to repair trips. Every operation
+// requires the admin role, and changes to trips are recorded as trip events
+// carrying the reason given.
+type AdminService interface {
+	// ForceTransition moves a trip to any known status, bypassing the
+	// transition rules
+	ForceTransition(ctx context.Context, tripID, status, reason string) (*types.Trip, error)
+	// AssignDriver replaces the driver of a trip, or unassigns it when
+	// driver is nil
+	AssignDriver(ctx context.Context, tripID string, driver *trip.TripDriver, reason string) (*types.Trip, error)
+	// GetTrip loads a trip, e.g. to publish its events again
+	GetTrip(ctx context.Context, tripID string) (*types.Trip, error)
+	// ExpireQuotes makes the selected quotes and their fares unusable and
+	// returns how many quotes were expired
+	ExpireQuotes(ctx context.Context, filter QuoteFilter) (int, error)
+	SearchTrips(ctx context.Context, filter TripFilter) ([]*types.Trip, error)
+}
+
+// TripFilter selects trips. Empty fields match every trip.
+type TripFilter struct {
+	Statuses    []string
+	UserID      string
+	DriverID    string
+	PackageSlug string
+	// CreatedAfter and CreatedBefore bound the creation time of the trip,
+	// which its ID holds
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// AfterID continues a search after the last trip of the previous page
+	AfterID string
+	Limit   int
+}
+
+// PageSize is the number of trips a search returns at most
+func (f TripFilter) PageSize() int {
+	if f.Limit <= 0 {
+		return DefaultSearchLimit
+	}
+	return min(f.Limit, MaxSearchLimit)
+}
+
+// QuoteFilter selects quotes by ID, by user, or both
+type QuoteFilter struct {
+	IDs    []string
+	UserID string
+}
+
+// KnownTripStatus reports whether status is a status a trip can have
+func KnownTripStatus(status string) bool {
+	switch status {
+	case TripStatusPending, TripStatusAccepted, TripStatusPaid:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/ride4Low/contracts/proto/trip"
+	"github.com/ride4Low/contracts/types"
+)
+
+// TripEventUpcaster rewrites a stored event before it is applied, so a replay
+// can correct historical data without touching the append-only stream.
+type TripEventUpcaster func(event *TripEvent) *TripEvent
+
+// ApplyTripEvent returns the state of the trip after event. The given trip is
+// never mutated, so it can still be used as the "before" snapshot.
+func ApplyTripEvent(t *types.Trip, event *TripEvent) (*types.Trip, error) {
+	switch event.Type {
+	case TripEventCreated:
+		if event.After == nil {
+			return nil, fmt.Errorf("trip %s: created event has no trip payload", event.TripID)
+		}
+		created := *event.After
+		return &created, nil
+	case TripEventStatusChanged:
+		if t == nil {
+			return nil, fmt.Errorf("trip %s: status change before the trip was created", event.TripID)
+		}
+		next := *t
+		next.Status = event.ToStatus
+		if event.Driver != nil {
+			next.Driver = event.Driver
+		}
+		return &next, nil
+	case TripEventDriverChanged:
+		if t == nil {
+			return nil, fmt.Errorf("trip %s: driver change before the trip was created", event.TripID)
+		}
+		next := *t
+		next.Driver = event.Driver
+		if next.Driver == nil {
+			next.Driver = &trip.TripDriver{}
+		}
+		return &next, nil
+	default:
+		return nil, fmt.Errorf("trip %s: unknown event type %s", event.TripID, event.Type)
+	}
+}
+
+// FoldTripEvents rebuilds a trip from its ordered event stream
+func FoldTripEvents(events []*TripEvent, upcasters ...TripEventUpcaster) (*types.Trip, error) {
+	var t *types.Trip
+	for _, event := range events {
+		for _, upcast := range upcasters {
+			event = upcast(event)
+		}
+
+		next, err := ApplyTripEvent(t, event)
+		if err != nil {
+			return nil, err
+		}
+		t = next
+	}
+
+	if t == nil {
+		return nil, fmt.Errorf("no events to rebuild the trip from")
+	}
+
+	return t, nil
+}
+
+// NextTripEventVersion returns the version the next event appended to the
+// stream must carry.
+func NextTripEventVersion(events []*TripEvent) int {
+	version := 0
+	for _, event := range events {
+		if event.Version > version {
+			version = event.Version
+		}
+	}
+	return version + 1
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "context"
+
+// Principal roles
+const (
+	RoleRider  = "rider"
+	RoleDriver = "driver"
+	RoleAdmin  = "admin"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	ID    string
+	Roles []string
+}
+
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal attaches the authenticated caller to the context
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated caller. Internal callers
+// such as event consumers and CLI commands have none.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
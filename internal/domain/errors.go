@@ -0,0 +1,50 @@
+package domain
+
+// ErrorKind classifies a domain error independently of the transport
+type ErrorKind int
+
+const (
+	KindInternal ErrorKind = iota
+	KindNotFound
+	KindInvalidArgument
+	KindFailedPrecondition
+	KindPermissionDenied
+	KindAlreadyExists
+	KindUnavailable
+	KindResourceExhausted
+	KindUnauthenticated
+)
+
+// Error is a domain error with a stable, machine readable reason. Its message
+// is safe to show to clients; details can be added by wrapping it with
+// fmt.Errorf("%w: ...").
+type Error struct {
+	Kind    ErrorKind
+	Reason  string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func NewError(kind ErrorKind, reason, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+var (
+	ErrTripNotFound        = NewError(KindNotFound, "TRIP_NOT_FOUND", "trip not found")
+	ErrFareNotFound        = NewError(KindNotFound, "FARE_NOT_FOUND", "fare does not exist")
+	ErrFareExpired         = NewError(KindFailedPrecondition, "FARE_EXPIRED", "fare has expired")
+	ErrForbidden           = NewError(KindPermissionDenied, "FORBIDDEN", "operation not allowed")
+	ErrUnauthenticated     = NewError(KindUnauthenticated, "UNAUTHENTICATED", "authentication required")
+	ErrInvalidTransition   = NewError(KindFailedPrecondition, "INVALID_TRANSITION", "invalid trip status transition")
+	ErrUpstreamUnavailable = NewError(KindUnavailable, "UPSTREAM_UNAVAILABLE", "upstream service unavailable")
+	ErrInvalidID           = NewError(KindInvalidArgument, "INVALID_ID", "invalid id")
+	ErrRateLimited         = NewError(KindResourceExhausted, "RATE_LIMITED", "too many requests")
+	ErrTripNotModified     = NewError(KindFailedPrecondition, "TRIP_NOT_MODIFIED", "trip already has the requested status and driver")
+)
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/ride4Low/contracts/proto/trip"
+	"github.com/ride4Low/contracts/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Actor types recorded on trip events
+const (
+	ActorRider  = "rider"
+	ActorDriver = "driver"
+	ActorSystem = "system"
+	ActorAdmin  = "admin"
+)
+
+// Trip event types
+const (
+	TripEventCreated       = "trip.created"
+	TripEventStatusChanged = "trip.status_changed"
+	// TripEventDriverChanged is recorded when an admin reassigns or
+	// unassigns the driver of a trip
+	TripEventDriverChanged = "trip.driver_changed"
+)
+
+// Actor is who triggered a change on a trip
+type Actor struct {
+	Type string `bson:"type" json:"type"`
+	ID   string `bson:"id,omitempty" json:"id,omitempty"`
+}
+
+// TripEvent is an append-only record of a single state transition of a trip
+type TripEvent struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TripID     string             `bson:"trip_id" json:"tripID"`
+	Type       string             `bson:"type" json:"type"`
+	Actor      Actor              `bson:"actor" json:"actor"`
+	Source     string             `bson:"source" json:"source"`
+	FromStatus string             `bson:"from_status,omitempty" json:"fromStatus,omitempty"`
+	ToStatus   string             `bson:"to_status" json:"toStatus"`
+	Reason     string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	Driver     *trip.TripDriver   `bson:"driver,omitempty" json:"driver,omitempty"`
+	Version    int                `bson:"version,omitempty" json:"version,omitempty"`
+	Before     *types.Trip        `bson:"before,omitempty" json:"before,omitempty"`
+	After      *types.Trip        `bson:"after,omitempty" json:"after,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+}
+
+func (e *TripEvent) ToProto() *trip.TripTimelineEvent {
+	pe := &trip.TripTimelineEvent{
+		Id:         e.ID.Hex(),
+		TripID:     e.TripID,
+		Type:       e.Type,
+		ActorType:  e.Actor.Type,
+		ActorID:    e.Actor.ID,
+		Source:     e.Source,
+		FromStatus: e.FromStatus,
+		ToStatus:   e.ToStatus,
+		Reason:     e.Reason,
+		CreatedAt:  timestamppb.New(e.CreatedAt),
+	}
+	if e.Before != nil {
+		pe.Before = e.Before.ToProto()
+	}
+	if e.After != nil {
+		pe.After = e.After.ToProto()
+	}
+	return pe
+}
+
+func ToTripEventsProto(events []*TripEvent) []*trip.TripTimelineEvent {
+	var protoEvents []*trip.TripTimelineEvent
+	for _, e := range events {
+		protoEvents = append(protoEvents, e.ToProto())
+	}
+	return protoEvents
+}
+
+// EventMeta describes who triggered a change and through which entry point
+// (gRPC method or AMQP routing key). It travels on the request context so the
+// service can attach it to the trip events it records.
+type EventMeta struct {
+	Actor  Actor
+	Source string
+}
+
+type eventMetaKey struct{}
+
+func WithEventMeta(ctx context.Context, meta EventMeta) context.Context {
+	return context.WithValue(ctx, eventMetaKey{}, meta)
+}
+
+// EventMetaFromContext returns the event metadata stored on ctx, falling back
+// to the system actor when none was set.
+func EventMetaFromContext(ctx context.Context) EventMeta {
+	meta, ok := ctx.Value(eventMetaKey{}).(EventMeta)
+	if !ok {
+		return EventMeta{Actor: Actor{Type: ActorSystem}, Source: "unknown"}
+	}
+	if meta.Actor.Type == "" {
+		meta.Actor.Type = ActorSystem
+	}
+	return meta
+}
@@ -2,8 +2,10 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"github.com/ride4Low/contracts/proto/driver"
+	"github.com/ride4Low/contracts/proto/trip"
 	"github.com/ride4Low/contracts/types"
 )
 
@@ -16,18 +18,64 @@ type Service interface {
 	GetAndValidateFare(ctx context.Context, fareID, userID string) (*types.RideFare, error)
 	GetTripByID(ctx context.Context, id string) (*types.Trip, error)
 	UpdateTrip(ctx context.Context, tripID string, status string, driver *driver.Driver) error
+	GetTripTimeline(ctx context.Context, tripID string) ([]*TripEvent, error)
+	RateTrip(ctx context.Context, rating *Rating) (*Rating, error)
+	AddTip(ctx context.Context, tripID, userID string, amountInCents float64) (*Tip, error)
+	GetReceipt(ctx context.Context, tripID string) (*Receipt, error)
+	ExportUserData(ctx context.Context, userID string) (*UserData, error)
+	EraseUserData(ctx context.Context, userID, mode string) (*ErasureReport, error)
 }
 
 // Repository interface
 type Repository interface {
 	SaveRideFare(ctx context.Context, rideFare *types.RideFare) error
 	GetRideFareByID(ctx context.Context, id string) (*types.RideFare, error)
+	// SaveQuote stores a quote with its fares in one document, so the fares of
+	// a preview are saved whole or not at all
+	SaveQuote(ctx context.Context, quote *Quote) error
+	FindRecentQuote(ctx context.Context, userID, routeKey string, since time.Time) (*Quote, error)
 	CreateTrip(ctx context.Context, trip *types.Trip) (*types.Trip, error)
 	GetTripByID(ctx context.Context, id string) (*types.Trip, error)
 	UpdateTrip(ctx context.Context, tripID string, status string, driver *driver.Driver) error
+	SaveTripEvent(ctx context.Context, event *TripEvent) error
+	GetTripEvents(ctx context.Context, tripID string) ([]*TripEvent, error)
+	GetEventTripIDs(ctx context.Context) ([]string, error)
+	// ReplaceTrip writes a trip projection, creating the trip when missing.
+	// Tips and ratings stored on the trip are kept.
+	ReplaceTrip(ctx context.Context, trip *types.Trip) error
+	SaveRating(ctx context.Context, rating *Rating) error
+	// GetRating returns the rating of a trip by the given role, or nil when
+	// it has not been rated by that role
+	GetRating(ctx context.Context, tripID, raterRole string) (*Rating, error)
+	SaveTip(ctx context.Context, tripID string, tip *Tip) error
+	GetTip(ctx context.Context, tripID string) (*Tip, error)
+	// GetUserData returns the data of a rider, including archived trips
+	GetUserData(ctx context.Context, userID string) (*UserData, error)
+	// EraseUserData replaces the user ID with the pseudonym, or removes it
+	// when the pseudonym is empty, in the live and archive collections
+	EraseUserData(ctx context.Context, userID, pseudonym string) (*ErasureReport, error)
+	// SetTripDriver replaces the driver of a trip, or clears it when driver
+	// is nil
+	SetTripDriver(ctx context.Context, tripID string, driver *trip.TripDriver) error
+	// SearchTrips returns the trips matching filter in creation order
+	SearchTrips(ctx context.Context, filter TripFilter) ([]*types.Trip, error)
+	// ExpireQuotes moves the creation time of the matching quotes and of
+	// their ride fares back to createdAt, past their TTL, so they are
+	// neither reused nor bookable and the TTL indexes purge them. It returns
+	// the number of quotes expired.
+	ExpireQuotes(ctx context.Context, filter QuoteFilter, createdAt time.Time) (int, error)
 }
 
 // RouteProvider interface
 type RouteProvider interface {
 	GetRoute(ctx context.Context, pickup, dropoff types.Coordinate) (*types.OsrmApiResponse, error)
 }
+
+// Metrics records what happens to quotes and trips, for monitoring
+type Metrics interface {
+	// QuoteCreated is called for every preview, with reused set when an
+	// earlier quote of the same route was returned
+	QuoteCreated(fares []*types.RideFare, reused bool)
+	// TripEventRecorded is called once a trip event has been stored
+	TripEventRecorded(event *TripEvent)
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ride4Low/contracts/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type QuoteConfig struct {
+	// ReuseWindow is how long a preview of the same route by the same user
+	// returns the earlier quote instead of pricing it again
+	ReuseWindow time.Duration
+	// RoutePrecision is the number of decimals the route endpoints are
+	// rounded to when comparing routes; 4 decimals is about 11 meters
+	RoutePrecision int
+}
+
+func DefaultQuoteConfig() *QuoteConfig {
+	return &QuoteConfig{
+		ReuseWindow:    2 * time.Minute,
+		RoutePrecision: 4,
+	}
+}
+
+// QuoteFare is the price of one package in a quote. FareID is the ride fare
+// the rider books with; fares of a quote have no document of their own.
+type QuoteFare struct {
+	FareID            primitive.ObjectID `bson:"fare_id" json:"fareID"`
+	PackageSlug       string             `bson:"packageSlug" json:"packageSlug"`
+	TotalPriceInCents float64            `bson:"totalPriceInCents" json:"totalPriceInCents"`
+}
+
+// Quote is the result of one preview: the route, stored once, and the fare
+// of every package priced for it
+type Quote struct {
+	ID        primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	UserID    string                 `bson:"userID" json:"userID"`
+	RouteKey  string                 `bson:"route_key" json:"-"`
+	Route     *types.OsrmApiResponse `bson:"route" json:"route"`
+	Fares     []QuoteFare            `bson:"fares" json:"fares"`
+	CreatedAt time.Time              `bson:"created_at" json:"createdAt"`
+}
+
+// RideFare returns the bookable fare of the quote with the given ID, or nil
+func (q *Quote) RideFare(fareID primitive.ObjectID) *types.RideFare {
+	for _, fare := range q.RideFares() {
+		if fare.ID == fareID {
+			return fare
+		}
+	}
+	return nil
+}
+
+// RideFares returns the bookable fares of the quote
+func (q *Quote) RideFares() []*types.RideFare {
+	fares := make([]*types.RideFare, len(q.Fares))
+	for i, f := range q.Fares {
+		fares[i] = &types.RideFare{
+			ID:                f.FareID,
+			UserID:            q.UserID,
+			PackageSlug:       f.PackageSlug,
+			TotalPriceInCents: f.TotalPriceInCents,
+			Route:             q.Route,
+			CreatedAt:         q.CreatedAt,
+		}
+	}
+	return fares
+}
+
+// QuoteRouteKey identifies equivalent routes by their rounded start and end
+// points. It is empty when the route has no geometry.
+func QuoteRouteKey(route *types.OsrmApiResponse, precision int) string {
+	if route == nil || len(route.Routes) == 0 {
+		return ""
+	}
+
+	coords := route.Routes[0].Geometry.Coordinates
+	if len(coords) == 0 || len(coords[0]) < 2 || len(coords[len(coords)-1]) < 2 {
+		return ""
+	}
+
+	start, end := coords[0], coords[len(coords)-1]
+	return fmt.Sprintf("%.*f,%.*f;%.*f,%.*f",
+		precision, start[0], precision, start[1],
+		precision, end[0], precision, end[1])
+}
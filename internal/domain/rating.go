@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Rater roles
+const (
+	RaterRider  = "rider"
+	RaterDriver = "driver"
+)
+
+var (
+	ErrInvalidRating      = NewError(KindInvalidArgument, "INVALID_RATING", "invalid rating")
+	ErrRatingWindowClosed = NewError(KindFailedPrecondition, "RATING_WINDOW_CLOSED", "rating window has closed")
+	ErrAlreadyRated       = NewError(KindAlreadyExists, "ALREADY_RATED", "trip already rated")
+)
+
+type RatingConfig struct {
+	Window           time.Duration
+	MinStars         int
+	MaxStars         int
+	MaxTags          int
+	MaxCommentLength int
+}
+
+func DefaultRatingConfig() *RatingConfig {
+	return &RatingConfig{
+		Window:           72 * time.Hour,
+		MinStars:         1,
+		MaxStars:         5,
+		MaxTags:          5,
+		MaxCommentLength: 500,
+	}
+}
+
+// Rating is the feedback one trip participant leaves about the other: the
+// rider rates the driver and the driver rates the rider.
+type Rating struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TripID    string             `bson:"trip_id" json:"tripID"`
+	RaterRole string             `bson:"rater_role" json:"raterRole"`
+	RaterID   string             `bson:"rater_id" json:"raterID"`
+	RateeID   string             `bson:"ratee_id" json:"rateeID"`
+	Stars     int                `bson:"stars" json:"stars"`
+	Tags      []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+	Comment   string             `bson:"comment,omitempty" json:"comment,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
@@ -0,0 +1,69 @@
+package domain
+
+import "time"
+
+// Receipt formats
+const (
+	ReceiptFormatJSON = "json"
+	ReceiptFormatHTML = "html"
+	ReceiptFormatPDF  = "pdf"
+)
+
+var ErrUnsupportedReceiptFormat = NewError(KindInvalidArgument, "UNSUPPORTED_RECEIPT_FORMAT", "unsupported receipt format")
+
+type ReceiptConfig struct {
+	Currency string
+	// TaxRatePercent is the tax included in the fare, shown as its own line
+	TaxRatePercent float64
+}
+
+func DefaultReceiptConfig() *ReceiptConfig {
+	return &ReceiptConfig{
+		Currency:       "USD",
+		TaxRatePercent: 0,
+	}
+}
+
+// ReceiptLineItem is one priced line of a receipt
+type ReceiptLineItem struct {
+	Label         string  `json:"label"`
+	AmountInCents float64 `json:"amountInCents"`
+}
+
+// ReceiptRoute summarises the route of a trip
+type ReceiptRoute struct {
+	Pickup          *Coordinate `json:"pickup,omitempty"`
+	Dropoff         *Coordinate `json:"dropoff,omitempty"`
+	DistanceMeters  float64     `json:"distanceMeters"`
+	DurationSeconds float64     `json:"durationSeconds"`
+}
+
+// Coordinate is a point on a receipt route
+type Coordinate struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// ReceiptDriver is the driver and vehicle shown on a receipt
+type ReceiptDriver struct {
+	Name     string `json:"name"`
+	CarPlate string `json:"carPlate"`
+}
+
+// Receipt is the rendering independent receipt of a completed trip
+type Receipt struct {
+	TripID          string            `json:"tripID"`
+	RiderID         string            `json:"riderID"`
+	PackageSlug     string            `json:"packageSlug"`
+	Currency        string            `json:"currency"`
+	Route           ReceiptRoute      `json:"route"`
+	Driver          *ReceiptDriver    `json:"driver,omitempty"`
+	Fare            []ReceiptLineItem `json:"fare"`
+	FareInCents     float64           `json:"fareInCents"`
+	DiscountInCents float64           `json:"discountInCents"`
+	TaxInCents      float64           `json:"taxInCents"`
+	TipInCents      float64           `json:"tipInCents"`
+	TotalInCents    float64           `json:"totalInCents"`
+	CompletedAt     time.Time         `json:"completedAt"`
+	IssuedAt        time.Time         `json:"issuedAt"`
+}
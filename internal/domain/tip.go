@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+)
+
+var (
+	ErrInvalidTip      = NewError(KindInvalidArgument, "INVALID_TIP", "invalid tip")
+	ErrTipWindowClosed = NewError(KindFailedPrecondition, "TIP_WINDOW_CLOSED", "tip window has closed")
+	ErrTipAlreadyAdded = NewError(KindAlreadyExists, "TIP_ALREADY_ADDED", "trip already has a tip")
+)
+
+type TipConfig struct {
+	Window time.Duration
+	// MaxFarePercent caps a tip relative to the trip fare
+	MaxFarePercent float64
+	// MaxAmountInCents caps a tip regardless of the fare
+	MaxAmountInCents float64
+}
+
+func DefaultTipConfig() *TipConfig {
+	return &TipConfig{
+		Window:           48 * time.Hour,
+		MaxFarePercent:   50,
+		MaxAmountInCents: 10000,
+	}
+}
+
+// MaxTipInCents is the largest tip allowed for a fare
+func (c *TipConfig) MaxTipInCents(fareInCents float64) float64 {
+	return min(fareInCents*c.MaxFarePercent/100, c.MaxAmountInCents)
+}
+
+// Tip is stored on the trip as a line separate from the ride fare
+type Tip struct {
+	AmountInCents float64   `bson:"amount_in_cents" json:"amountInCents"`
+	RiderID       string    `bson:"rider_id" json:"riderID"`
+	DriverID      string    `bson:"driver_id" json:"driverID"`
+	CreatedAt     time.Time `bson:"created_at" json:"createdAt"`
+}
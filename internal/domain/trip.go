@@ -1,10 +1,41 @@
 package domain
 
 import (
+	"time"
+
 	"github.com/ride4Low/contracts/proto/trip"
 	"github.com/ride4Low/contracts/types"
 )
 
+// Trip statuses
+const (
+	TripStatusPending  = "pending"
+	TripStatusAccepted = "accepted"
+	TripStatusPaid     = "paid"
+)
+
+// ErrTripNotCompleted is returned for operations only allowed once a trip is over
+var ErrTripNotCompleted = NewError(KindFailedPrecondition, "TRIP_NOT_COMPLETED", "trip is not completed")
+
+// RideFareTTL is how long a ride fare can be booked after the preview
+const RideFareTTL = 24 * time.Hour
+
+// tripTransitions lists the statuses a trip can move to from each status
+var tripTransitions = map[string][]string{
+	TripStatusPending:  {TripStatusAccepted},
+	TripStatusAccepted: {TripStatusPaid},
+}
+
+// CanTransition reports whether a trip may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, status := range tripTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
 type PricingConfig struct {
 	PricePerUnitOfDistance float64
 	PricingPerMinute       float64
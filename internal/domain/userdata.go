@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/ride4Low/contracts/types"
+)
+
+// Erasure modes
+const (
+	// ErasurePseudonymize replaces the user ID with a random pseudonym, so the
+	// erased trips of a user can still be told apart from other users' trips
+	ErasurePseudonymize = "pseudonymize"
+	// ErasureDelete removes the user ID
+	ErasureDelete = "delete"
+)
+
+var ErrInvalidErasureMode = NewError(KindInvalidArgument, "INVALID_ERASURE_MODE", "erasure mode must be pseudonymize or delete")
+
+// UserData is everything the trip service holds about a rider, as handed
+// out for a data access request
+type UserData struct {
+	UserID     string            `json:"userID"`
+	ExportedAt time.Time         `json:"exportedAt"`
+	Trips      []*UserTrip       `json:"trips"`
+	RideFares  []*types.RideFare `json:"rideFares"`
+	Quotes     []*Quote          `json:"quotes"`
+	Ratings    []*Rating         `json:"ratings"`
+}
+
+// UserTrip is a trip of the rider with the records attached to it
+type UserTrip struct {
+	Trip   *types.Trip  `json:"trip"`
+	Tip    *Tip         `json:"tip,omitempty"`
+	Events []*TripEvent `json:"events"`
+}
+
+// ErasureReport counts the documents changed by an erasure. Trips, their
+// events and ratings, live and archived, are kept for accounting with the
+// personal data removed; ride fares and quotes are deleted. Trips archived
+// to files cannot be erased, so the file archive must be pseudonymized or
+// stripped.
+type ErasureReport struct {
+	Trips      int `json:"trips"`
+	TripEvents int `json:"tripEvents"`
+	Ratings    int `json:"ratings"`
+	RideFares  int `json:"rideFares"`
+	Quotes     int `json:"quotes"`
+}
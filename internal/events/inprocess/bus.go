@@ -0,0 +1,97 @@
+package inprocess
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/ride4Low/contracts/events"
+	"github.com/ride4Low/trip-service/internal/events/tracing"
+	"github.com/ride4Low/trip-service/internal/lifecycle"
+)
+
+// MessageHandler handles a delivered message, like the RabbitMQ consumers do
+type MessageHandler interface {
+	Handle(ctx context.Context, msg amqp.Delivery) error
+}
+
+// Bus is an in-process stand-in for RabbitMQ used in local mode. Published
+// messages are delivered asynchronously to the handlers bound to their
+// routing key; messages nobody subscribed to are only logged.
+type Bus struct {
+	mu       sync.RWMutex
+	bindings map[string][]MessageHandler
+	inflight lifecycle.Inflight
+	logger   *slog.Logger
+}
+
+func NewBus(logger *slog.Logger) *Bus {
+	return &Bus{
+		bindings: map[string][]MessageHandler{},
+		logger:   logger,
+	}
+}
+
+// Subscribe binds handler to the given routing keys
+func (b *Bus) Subscribe(handler MessageHandler, routingKeys ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, key := range routingKeys {
+		b.bindings[key] = append(b.bindings[key], handler)
+	}
+}
+
+func (b *Bus) PublishMessage(ctx context.Context, routingKey string, message events.AmqpMessage) error {
+	ctx, span, headers := tracing.StartPublish(ctx, routingKey)
+	defer span.End()
+
+	body, err := sonic.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	b.mu.RLock()
+	handlers := b.bindings[routingKey]
+	b.mu.RUnlock()
+
+	if len(handlers) == 0 {
+		b.logger.DebugContext(ctx, "Published a message with no subscriber", "routing_key", routingKey, "owner_id", message.OwnerID)
+		return nil
+	}
+
+	// Deliveries outlive the publishing request, as they would with a broker
+	deliveryCtx := context.WithoutCancel(ctx)
+	published := time.Now()
+	for _, handler := range handlers {
+		if !b.inflight.Acquire() {
+			return fmt.Errorf("failed to publish %s: bus is closed", routingKey)
+		}
+		go func(handler MessageHandler) {
+			defer b.inflight.Release()
+
+			msg := amqp.Delivery{
+				RoutingKey:  routingKey,
+				ContentType: "application/json",
+				Timestamp:   published,
+				Headers:     headers,
+				Body:        body,
+			}
+			if err := handler.Handle(deliveryCtx, msg); err != nil {
+				b.logger.ErrorContext(deliveryCtx, "Failed to handle the message", "routing_key", routingKey, "error", err)
+			}
+		}(handler)
+	}
+
+	return nil
+}
+
+// Drain refuses new messages and waits until every delivered message has
+// been handled
+func (b *Bus) Drain(ctx context.Context) error {
+	return b.inflight.Wait(ctx)
+}
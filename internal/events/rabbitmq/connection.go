@@ -0,0 +1,176 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	amqpClient "github.com/ride4Low/contracts/pkg/rabbitmq"
+	"github.com/ride4Low/trip-service/internal/events/inprocess"
+	"github.com/ride4Low/trip-service/internal/retry"
+)
+
+// ErrNotConnected is returned for messages published while the connection
+// to RabbitMQ is being reestablished
+var ErrNotConnected = errors.New("not connected to RabbitMQ")
+
+type subscription struct {
+	ctx     context.Context
+	queue   string
+	handler inprocess.MessageHandler
+}
+
+// Connection keeps a RabbitMQ connection up. When the broker closes it, it
+// reconnects with backoff and subscribes the consumers again. It publishes
+// on whichever channel is current, so it can back a Publisher.
+type Connection struct {
+	dial   func() (*amqpClient.RabbitMQ, error)
+	policy retry.Policy
+	logger *slog.Logger
+
+	// notifyClose and consume are the broker operations, replaced in tests
+	notifyClose func(rmq *amqpClient.RabbitMQ) <-chan *amqp.Error
+	consume     func(ctx context.Context, rmq *amqpClient.RabbitMQ, queue string, handler inprocess.MessageHandler) error
+
+	mu            sync.RWMutex
+	rmq           *amqpClient.RabbitMQ
+	subscriptions []subscription
+}
+
+// NewConnection connects with dial, which declares the topology of the
+// service, retrying as policy allows
+func NewConnection(dial func() (*amqpClient.RabbitMQ, error), policy retry.Policy, logger *slog.Logger) *Connection {
+	return &Connection{
+		dial:   dial,
+		policy: policy,
+		logger: logger,
+		notifyClose: func(rmq *amqpClient.RabbitMQ) <-chan *amqp.Error {
+			return rmq.Channel.NotifyClose(make(chan *amqp.Error, 1))
+		},
+		consume: func(ctx context.Context, rmq *amqpClient.RabbitMQ, queue string, handler inprocess.MessageHandler) error {
+			return consumeQueue(ctx, rmq.Channel, queue, handler, logger)
+		},
+	}
+}
+
+// Connect establishes the first connection, retrying within the policy
+func (c *Connection) Connect(ctx context.Context) error {
+	return c.connect(ctx, c.policy)
+}
+
+func (c *Connection) connect(ctx context.Context, policy retry.Policy) error {
+	var rmq *amqpClient.RabbitMQ
+	err := retry.Do(ctx, policy, func(ctx context.Context) error {
+		var err error
+		rmq, err = c.dial()
+		return err
+	}, func(attempt int, err error, wait time.Duration) {
+		c.logger.WarnContext(ctx, "Failed to connect to RabbitMQ, retrying", "attempt", attempt, "retry_in", wait, "error", err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	c.mu.Lock()
+	c.rmq = rmq
+	subscriptions := append([]subscription(nil), c.subscriptions...)
+	c.mu.Unlock()
+
+	// Deliveries stopped with the previous channel
+	for _, s := range subscriptions {
+		if s.ctx.Err() != nil {
+			continue
+		}
+		if err := c.consume(s.ctx, rmq, s.queue, s.handler); err != nil {
+			c.logger.ErrorContext(ctx, "Failed to resubscribe", "queue", s.queue, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// Run reconnects whenever the connection closes, until ctx is done. At
+// runtime there is no time limit: readiness reports the outage meanwhile.
+func (c *Connection) Run(ctx context.Context) {
+	policy := c.policy
+	policy.MaxElapsed = 0
+
+	for {
+		c.mu.RLock()
+		rmq := c.rmq
+		c.mu.RUnlock()
+		if rmq == nil {
+			// Closed
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case amqpErr := <-c.notifyClose(rmq):
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.WarnContext(ctx, "RabbitMQ connection closed, reconnecting", "error", amqpErr)
+		}
+
+		c.mu.Lock()
+		c.rmq = nil
+		c.mu.Unlock()
+		rmq.Close()
+
+		if err := c.connect(ctx, policy); err != nil {
+			return
+		}
+		c.logger.InfoContext(ctx, "Reconnected to RabbitMQ")
+	}
+}
+
+// Consume subscribes handler to queue until ctx is done, again after each
+// reconnection. Handled deliveries are acked; see handleDeliveries for the
+// failed ones.
+func (c *Connection) Consume(ctx context.Context, queue string, handler inprocess.MessageHandler) error {
+	c.mu.Lock()
+	c.subscriptions = append(c.subscriptions, subscription{ctx: ctx, queue: queue, handler: handler})
+	rmq := c.rmq
+	c.mu.Unlock()
+
+	if rmq == nil {
+		return nil
+	}
+	return c.consume(ctx, rmq, queue, handler)
+}
+
+// Channel is the current channel, nil while reconnecting
+func (c *Connection) Channel() *amqp.Channel {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.rmq == nil {
+		return nil
+	}
+	return c.rmq.Channel
+}
+
+func (c *Connection) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	channel := c.Channel()
+	if channel == nil {
+		return ErrNotConnected
+	}
+	return channel.PublishWithContext(ctx, exchange, key, mandatory, immediate, msg)
+}
+
+// Close closes the current connection
+func (c *Connection) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rmq != nil {
+		c.rmq.Close()
+		c.rmq = nil
+	}
+}
@@ -0,0 +1,141 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	amqpClient "github.com/ride4Low/contracts/pkg/rabbitmq"
+	"github.com/ride4Low/trip-service/internal/events/inprocess"
+	"github.com/ride4Low/trip-service/internal/logging"
+	"github.com/ride4Low/trip-service/internal/retry"
+)
+
+type fakeBroker struct {
+	mu            sync.Mutex
+	down          int
+	dials         int
+	closed        map[*amqpClient.RabbitMQ]chan *amqp.Error
+	subscriptions map[*amqpClient.RabbitMQ][]string
+	subscribed    chan struct{}
+}
+
+func newFakeBroker(down int) *fakeBroker {
+	return &fakeBroker{
+		down:          down,
+		closed:        map[*amqpClient.RabbitMQ]chan *amqp.Error{},
+		subscriptions: map[*amqpClient.RabbitMQ][]string{},
+		subscribed:    make(chan struct{}, 10),
+	}
+}
+
+func (b *fakeBroker) dial() (*amqpClient.RabbitMQ, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.dials++
+	if b.dials <= b.down {
+		return nil, errors.New("connection refused")
+	}
+	rmq := &amqpClient.RabbitMQ{}
+	b.closed[rmq] = make(chan *amqp.Error, 1)
+	return rmq, nil
+}
+
+func (b *fakeBroker) connection() *Connection {
+	c := NewConnection(b.dial, retry.Policy{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, MaxElapsed: time.Second}, logging.Discard())
+	c.notifyClose = func(rmq *amqpClient.RabbitMQ) <-chan *amqp.Error {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return b.closed[rmq]
+	}
+	c.consume = func(ctx context.Context, rmq *amqpClient.RabbitMQ, queue string, handler inprocess.MessageHandler) error {
+		b.mu.Lock()
+		b.subscriptions[rmq] = append(b.subscriptions[rmq], queue)
+		b.mu.Unlock()
+		b.subscribed <- struct{}{}
+		return nil
+	}
+	return c
+}
+
+func (b *fakeBroker) drop(rmq *amqpClient.RabbitMQ) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed[rmq] <- &amqp.Error{Code: amqp.ConnectionForced, Reason: "broker restarted"}
+}
+
+func TestConnectionConnectRetries(t *testing.T) {
+	// Setup
+	broker := newFakeBroker(2)
+	conn := broker.connection()
+
+	// Execute
+	err := conn.Connect(context.Background())
+
+	// Verify
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if broker.dials != 3 {
+		t.Errorf("expected 3 dials, got %d", broker.dials)
+	}
+}
+
+func TestConnectionReconnects(t *testing.T) {
+	// Setup
+	broker := newFakeBroker(0)
+	conn := broker.connection()
+	if err := conn.Connect(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	first := conn.rmq
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := conn.Consume(ctx, "trip.driver_response", nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	<-broker.subscribed
+	go conn.Run(ctx)
+
+	// Execute
+	broker.drop(first)
+
+	// Verify
+	select {
+	case <-broker.subscribed:
+	case <-time.After(time.Second):
+		t.Fatal("expected the consumer to subscribe again")
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if broker.dials != 2 {
+		t.Errorf("expected a second dial, got %d", broker.dials)
+	}
+	for rmq, queues := range broker.subscriptions {
+		if len(queues) != 1 || queues[0] != "trip.driver_response" {
+			t.Errorf("expected one subscription per connection, got %v on %p", queues, rmq)
+		}
+	}
+	if len(broker.subscriptions) != 2 {
+		t.Errorf("expected subscriptions on both connections, got %d", len(broker.subscriptions))
+	}
+}
+
+func TestConnectionPublishWhileDisconnected(t *testing.T) {
+	// Setup
+	conn := newFakeBroker(0).connection()
+
+	// Execute
+	err := conn.PublishWithContext(context.Background(), DefaultExchange, "trip.event.created", false, false, amqp.Publishing{})
+
+	// Verify
+	if !errors.Is(err, ErrNotConnected) {
+		t.Errorf("expected ErrNotConnected, got %v", err)
+	}
+}
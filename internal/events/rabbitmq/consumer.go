@@ -0,0 +1,69 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/ride4Low/trip-service/internal/events/inprocess"
+)
+
+var consumerSeq atomic.Uint64
+
+// consumeQueue delivers the messages of queue to handler until ctx is done.
+// Consumption is then cancelled on the broker, and the deliveries it had
+// already prefetched are nacked with requeue for another replica.
+func consumeQueue(ctx context.Context, channel *amqp.Channel, queue string, handler inprocess.MessageHandler, logger *slog.Logger) error {
+	tag := fmt.Sprintf("trip-service.%s.%d", queue, consumerSeq.Add(1))
+	deliveries, err := channel.Consume(queue, tag, false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume %s: %w", queue, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		if err := channel.Cancel(tag, false); err != nil && !errors.Is(err, amqp.ErrClosed) {
+			logger.Error("Failed to cancel consumer", "queue", queue, "error", err)
+		}
+	}()
+	go handleDeliveries(ctx, deliveries, handler, logger)
+
+	return nil
+}
+
+// handleDeliveries acks the deliveries handler handled. A failed delivery is
+// requeued once, then rejected so it is dead-lettered rather than retried
+// forever.
+func handleDeliveries(ctx context.Context, deliveries <-chan amqp.Delivery, handler inprocess.MessageHandler, logger *slog.Logger) {
+	// Handlers in flight finish after consumption is cancelled
+	handlerCtx := context.WithoutCancel(ctx)
+
+	for msg := range deliveries {
+		if ctx.Err() != nil {
+			nack(handlerCtx, msg, true, logger)
+			continue
+		}
+
+		err := handler.Handle(handlerCtx, msg)
+		switch {
+		case err == nil:
+			if err := msg.Ack(false); err != nil {
+				logger.ErrorContext(handlerCtx, "Failed to ack the message", "routing_key", msg.RoutingKey, "error", err)
+			}
+		case errors.Is(err, ErrDraining):
+			// Nacked with requeue by the draining handler
+		default:
+			logger.ErrorContext(handlerCtx, "Failed to handle the message", "routing_key", msg.RoutingKey, "redelivered", msg.Redelivered, "error", err)
+			nack(handlerCtx, msg, !msg.Redelivered, logger)
+		}
+	}
+}
+
+func nack(ctx context.Context, msg amqp.Delivery, requeue bool, logger *slog.Logger) {
+	if err := msg.Nack(false, requeue); err != nil {
+		logger.ErrorContext(ctx, "Failed to nack the message", "routing_key", msg.RoutingKey, "error", err)
+	}
+}
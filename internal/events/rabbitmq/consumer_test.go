@@ -0,0 +1,166 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/ride4Low/trip-service/internal/lifecycle"
+	"github.com/ride4Low/trip-service/internal/logging"
+)
+
+type fakeAcknowledger struct {
+	mu      sync.Mutex
+	acked   int
+	nacked  int
+	requeue bool
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.acked++
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nacked++
+	a.requeue = requeue
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return a.Nack(tag, false, requeue)
+}
+
+type handlerFunc func(ctx context.Context, msg amqp.Delivery) error
+
+func (f handlerFunc) Handle(ctx context.Context, msg amqp.Delivery) error {
+	return f(ctx, msg)
+}
+
+func TestHandleDeliveries(t *testing.T) {
+	tests := []struct {
+		name        string
+		cancelled   bool
+		redelivered bool
+		handleErr   error
+		wantHandled bool
+		wantAcked   int
+		wantNacked  int
+		wantRequeue bool
+	}{
+		{
+			name:        "acks handled deliveries",
+			wantHandled: true,
+			wantAcked:   1,
+		},
+		{
+			name:        "requeues a first failure",
+			handleErr:   errors.New("boom"),
+			wantHandled: true,
+			wantNacked:  1,
+			wantRequeue: true,
+		},
+		{
+			name:        "rejects a failed redelivery",
+			redelivered: true,
+			handleErr:   errors.New("boom"),
+			wantHandled: true,
+			wantNacked:  1,
+		},
+		{
+			name:        "requeues deliveries prefetched before consumption was cancelled",
+			cancelled:   true,
+			wantNacked:  1,
+			wantRequeue: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			ack := &fakeAcknowledger{}
+			deliveries := make(chan amqp.Delivery, 1)
+			deliveries <- amqp.Delivery{Acknowledger: ack, Redelivered: tt.redelivered}
+			close(deliveries)
+
+			handled := false
+			handler := handlerFunc(func(ctx context.Context, msg amqp.Delivery) error {
+				handled = true
+				return tt.handleErr
+			})
+
+			// Execute
+			handleDeliveries(ctx, deliveries, handler, logging.Discard())
+
+			// Verify
+			if handled != tt.wantHandled {
+				t.Errorf("expected handled %v, got %v", tt.wantHandled, handled)
+			}
+			if ack.acked != tt.wantAcked || ack.nacked != tt.wantNacked {
+				t.Errorf("expected %d acks and %d nacks, got %d and %d", tt.wantAcked, tt.wantNacked, ack.acked, ack.nacked)
+			}
+			if ack.requeue != tt.wantRequeue {
+				t.Errorf("expected requeue %v, got %v", tt.wantRequeue, ack.requeue)
+			}
+		})
+	}
+}
+
+func TestDrainingHandler(t *testing.T) {
+	t.Run("requeues broker deliveries once draining", func(t *testing.T) {
+		// Setup
+		inflight := &lifecycle.Inflight{}
+		if err := inflight.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		handler := NewDrainingHandler(handlerFunc(func(ctx context.Context, msg amqp.Delivery) error {
+			t.Error("expected the delivery not to be handled")
+			return nil
+		}), inflight)
+		ack := &fakeAcknowledger{}
+		deliveries := make(chan amqp.Delivery, 1)
+		deliveries <- amqp.Delivery{Acknowledger: ack}
+		close(deliveries)
+
+		// Execute
+		handleDeliveries(context.Background(), deliveries, handler, logging.Discard())
+
+		// Verify
+		if ack.nacked != 1 || !ack.requeue || ack.acked != 0 {
+			t.Errorf("expected a single nack with requeue, got %+v", ack)
+		}
+	})
+
+	t.Run("handles in-process deliveries once draining", func(t *testing.T) {
+		// Setup
+		inflight := &lifecycle.Inflight{}
+		if err := inflight.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		handled := false
+		handler := NewDrainingHandler(handlerFunc(func(ctx context.Context, msg amqp.Delivery) error {
+			handled = true
+			return nil
+		}), inflight)
+
+		// Execute
+		err := handler.Handle(context.Background(), amqp.Delivery{})
+
+		// Verify
+		if err != nil || !handled {
+			t.Errorf("expected the delivery to be handled, got %v", err)
+		}
+	})
+}
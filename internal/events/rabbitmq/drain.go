@@ -0,0 +1,43 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/ride4Low/trip-service/internal/events/inprocess"
+	"github.com/ride4Low/trip-service/internal/lifecycle"
+)
+
+// ErrDraining is returned for deliveries received once the consumers are
+// draining. They are nacked with requeue, so the message is left to another
+// replica.
+var ErrDraining = errors.New("consumer is draining")
+
+type drainingHandler struct {
+	next     inprocess.MessageHandler
+	inflight *lifecycle.Inflight
+}
+
+// NewDrainingHandler counts the deliveries handled by next in inflight, so
+// shutdown can wait for them, and requeues deliveries once inflight is
+// waited on. Deliveries of the in-process bus cannot be requeued, so they
+// are still handled: the bus waits for them itself when it drains.
+func NewDrainingHandler(next inprocess.MessageHandler, inflight *lifecycle.Inflight) inprocess.MessageHandler {
+	return &drainingHandler{next: next, inflight: inflight}
+}
+
+func (h *drainingHandler) Handle(ctx context.Context, msg amqp.Delivery) error {
+	if !h.inflight.Acquire() {
+		if msg.Acknowledger == nil {
+			return h.next.Handle(ctx, msg)
+		}
+		if err := msg.Nack(false, true); err != nil {
+			return err
+		}
+		return ErrDraining
+	}
+	defer h.inflight.Release()
+
+	return h.next.Handle(ctx, msg)
+}
@@ -2,29 +2,44 @@ package rabbitmq
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/bytedance/sonic"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/ride4Low/contracts/events"
-	"github.com/ride4Low/contracts/pkg/rabbitmq"
 	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/events/tracing"
+	"github.com/ride4Low/trip-service/internal/logging"
 )
 
 type DriverEventHandler struct {
-	publisher *rabbitmq.Publisher
+	publisher MessagePublisher
 	service   domain.Service
+	logger    *slog.Logger
 }
 
-func NewDriverEventHandler(publisher *rabbitmq.Publisher, service domain.Service) *DriverEventHandler {
+func NewDriverEventHandler(publisher MessagePublisher, service domain.Service, logger *slog.Logger) *DriverEventHandler {
 	return &DriverEventHandler{
 		publisher: publisher,
 		service:   service,
+		logger:    logger,
 	}
 }
 
-func (h *DriverEventHandler) Handle(ctx context.Context, msg amqp.Delivery) error {
+// Handle processes a delivery in a consumer span continuing the trace of
+// the publisher
+func (h *DriverEventHandler) Handle(ctx context.Context, msg amqp.Delivery) (err error) {
+	ctx, span := tracing.StartConsume(ctx, msg)
+	defer func() { tracing.End(span, err) }()
+
+	return h.handle(ctx, msg)
+}
+
+func (h *DriverEventHandler) handle(ctx context.Context, msg amqp.Delivery) error {
+	ctx = logging.With(ctx, "routing_key", msg.RoutingKey)
+
 	var message events.AmqpMessage
 
 	if msg.Body == nil {
@@ -51,23 +66,29 @@ func (h *DriverEventHandler) handleTripAccept(ctx context.Context, message event
 		return fmt.Errorf("failed to unmarshal message: %v", err)
 	}
 
-	// 1. Fetch the first
-	trip, err := h.service.GetTripByID(ctx, payload.TripID)
-	if err != nil {
-		return err
-	}
+	ctx = tracing.WithTripID(ctx, payload.TripID)
+	ctx = logging.With(ctx, "trip_id", payload.TripID, "driver_id", payload.Driver.GetId())
+	ctx = domain.WithEventMeta(ctx, domain.EventMeta{
+		Actor:  domain.Actor{Type: domain.ActorDriver, ID: payload.Driver.GetId()},
+		Source: events.DriverCmdTripAccept,
+	})
 
-	if trip == nil {
-		return fmt.Errorf("trrip was not found %s", payload.TripID)
+	// 1. Fetch the first
+	if _, err := h.service.GetTripByID(ctx, payload.TripID); err != nil {
+		return fmt.Errorf("failed to get trip %s: %w", payload.TripID, err)
 	}
 
-	// 2. Update the trip
-	if err := h.service.UpdateTrip(ctx, payload.TripID, "accepted", payload.Driver); err != nil {
-		log.Printf("Failed to update the trip: %v", err)
-		return err
+	// 2. Update the trip. A redelivered accept finds it already accepted, and
+	// still publishes the event an earlier attempt may have failed to send.
+	if err := h.service.UpdateTrip(ctx, payload.TripID, domain.TripStatusAccepted, payload.Driver); err != nil {
+		if !errors.Is(err, domain.ErrTripNotModified) {
+			h.logger.ErrorContext(ctx, "Failed to update the trip", "error", err)
+			return err
+		}
+		h.logger.InfoContext(ctx, "Trip already accepted, publishing the driver assignment again")
 	}
 
-	trip, err = h.service.GetTripByID(ctx, payload.TripID)
+	trip, err := h.service.GetTripByID(ctx, payload.TripID)
 	if err != nil {
 		return err
 	}
@@ -118,6 +139,8 @@ func (h *DriverEventHandler) handleTripDecline(ctx context.Context, message even
 	if err := sonic.Unmarshal(message.Data, &payload); err != nil {
 		return fmt.Errorf("failed to unmarshal message: %v", err)
 	}
+	ctx = tracing.WithTripID(ctx, payload.TripID)
+	ctx = logging.With(ctx, "trip_id", payload.TripID, "driver_id", payload.Driver.GetId())
 
 	trip, err := h.service.GetTripByID(ctx, payload.TripID)
 	if err != nil {
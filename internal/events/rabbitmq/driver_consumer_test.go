@@ -0,0 +1,109 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/ride4Low/contracts/events"
+	"github.com/ride4Low/contracts/proto/driver"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// stubService implements the service calls of the consumers
+type stubService struct {
+	domain.Service
+	updateTripErr error
+	receipt       *domain.Receipt
+}
+
+func (s *stubService) GetTripByID(ctx context.Context, id string) (*types.Trip, error) {
+	return &types.Trip{ID: primitive.NewObjectID(), UserID: "user-123", Status: domain.TripStatusAccepted}, nil
+}
+
+func (s *stubService) UpdateTrip(ctx context.Context, tripID string, status string, driver *driver.Driver) error {
+	return s.updateTripErr
+}
+
+func (s *stubService) GetReceipt(ctx context.Context, tripID string) (*domain.Receipt, error) {
+	return s.receipt, nil
+}
+
+// messageRecorder records the routing keys of published messages
+type messageRecorder struct {
+	routingKeys []string
+}
+
+func (r *messageRecorder) PublishMessage(ctx context.Context, routingKey string, message events.AmqpMessage) error {
+	r.routingKeys = append(r.routingKeys, routingKey)
+	return nil
+}
+
+func delivery(t *testing.T, routingKey string, payload interface{}) amqp.Delivery {
+	t.Helper()
+
+	data, err := sonic.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := sonic.Marshal(events.AmqpMessage{OwnerID: "user-123", Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return amqp.Delivery{RoutingKey: routingKey, Body: body}
+}
+
+func TestDriverEventHandlerTripAccept(t *testing.T) {
+	tests := []struct {
+		name          string
+		updateTripErr error
+		wantErr       bool
+		wantPublished bool
+	}{
+		{
+			name:          "publishes the driver assignment",
+			wantPublished: true,
+		},
+		{
+			name:          "redelivered accept publishes the assignment again",
+			updateTripErr: fmt.Errorf("%w: trip-123", domain.ErrTripNotModified),
+			wantPublished: true,
+		},
+		{
+			name:          "failed update",
+			updateTripErr: errors.New("database connection failed"),
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			publisher := &messageRecorder{}
+			handler := NewDriverEventHandler(publisher, &stubService{updateTripErr: tt.updateTripErr}, logging.Discard())
+			msg := delivery(t, events.DriverCmdTripAccept, events.DriverTripResponseData{
+				TripID:  "trip-123",
+				RiderID: "user-123",
+				Driver:  &driver.Driver{Id: "driver-1"},
+			})
+
+			// Execute
+			err := handler.Handle(context.Background(), msg)
+
+			// Verify
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			published := len(publisher.routingKeys) == 1 && publisher.routingKeys[0] == events.TripEventDriverAssigned
+			if published != tt.wantPublished {
+				t.Errorf("expected published %v, got %v", tt.wantPublished, publisher.routingKeys)
+			}
+		})
+	}
+}
@@ -2,26 +2,49 @@ package rabbitmq
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/bytedance/sonic"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/ride4Low/contracts/events"
 	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/events/tracing"
+	"github.com/ride4Low/trip-service/internal/logging"
+	"github.com/ride4Low/trip-service/internal/privacy"
 )
 
 type PaymentEventHandler struct {
-	service domain.Service
+	publisher TripEventPublisher
+	service   domain.Service
+	// coordinatePrecision is the number of decimals of the route endpoints
+	// in published receipts
+	coordinatePrecision int
+	logger              *slog.Logger
 }
 
-func NewPaymentEventHandler(service domain.Service) *PaymentEventHandler {
+func NewPaymentEventHandler(publisher TripEventPublisher, service domain.Service, coordinatePrecision int, logger *slog.Logger) *PaymentEventHandler {
 	return &PaymentEventHandler{
-		service: service,
+		publisher:           publisher,
+		service:             service,
+		coordinatePrecision: coordinatePrecision,
+		logger:              logger,
 	}
 }
 
-func (h *PaymentEventHandler) Handle(ctx context.Context, msg amqp.Delivery) error {
+// Handle processes a delivery in a consumer span continuing the trace of
+// the publisher
+func (h *PaymentEventHandler) Handle(ctx context.Context, msg amqp.Delivery) (err error) {
+	ctx, span := tracing.StartConsume(ctx, msg)
+	defer func() { tracing.End(span, err) }()
+
+	return h.handle(ctx, msg)
+}
+
+func (h *PaymentEventHandler) handle(ctx context.Context, msg amqp.Delivery) error {
+	ctx = logging.With(ctx, "routing_key", msg.RoutingKey)
+
 	var message events.AmqpMessage
 	if msg.Body == nil {
 		return fmt.Errorf("message body is nil")
@@ -41,14 +64,51 @@ func (h *PaymentEventHandler) Handle(ctx context.Context, msg amqp.Delivery) err
 func (h *PaymentEventHandler) handlePaymentSuccess(ctx context.Context, message events.AmqpMessage) error {
 	var payload events.PaymentStatusUpdateData
 	if err := sonic.Unmarshal(message.Data, &payload); err != nil {
-		log.Printf("Failed to unmarshal payload: %v", err)
+		h.logger.ErrorContext(ctx, "Failed to unmarshal the payload", "error", err)
 		return err
 	}
 
-	return h.service.UpdateTrip(
+	ctx = tracing.WithTripID(ctx, payload.TripID)
+	ctx = logging.With(ctx, "trip_id", payload.TripID)
+	ctx = domain.WithEventMeta(ctx, domain.EventMeta{
+		Actor:  domain.Actor{Type: domain.ActorSystem, ID: "payment-service"},
+		Source: events.PaymentEventSuccess,
+	})
+
+	// A redelivered payment finds the trip already paid, and still issues the
+	// receipt an earlier attempt may have failed to send
+	if err := h.service.UpdateTrip(
 		ctx,
 		payload.TripID,
-		"paid",
+		domain.TripStatusPaid,
 		nil,
-	)
+	); err != nil {
+		if !errors.Is(err, domain.ErrTripNotModified) {
+			return err
+		}
+		h.logger.InfoContext(ctx, "Trip already paid, issuing the receipt again")
+	}
+
+	// The trip is paid at this point, a missing receipt must not cause the
+	// payment event to be redelivered.
+	if err := h.publishReceipt(ctx, payload.TripID); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to issue the receipt", "error", err)
+	}
+
+	return nil
+}
+
+func (h *PaymentEventHandler) publishReceipt(ctx context.Context, tripID string) error {
+	receipt, err := h.service.GetReceipt(ctx, tripID)
+	if err != nil {
+		return err
+	}
+
+	// Receipt events fan out to analytics, which have no use for the exact
+	// pickup and dropoff
+	published := *receipt
+	published.Route.Pickup = privacy.FuzzCoordinate(receipt.Route.Pickup, h.coordinatePrecision)
+	published.Route.Dropoff = privacy.FuzzCoordinate(receipt.Route.Dropoff, h.coordinatePrecision)
+
+	return h.publisher.PublishReceiptIssued(ctx, &published)
 }
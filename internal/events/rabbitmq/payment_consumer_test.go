@@ -0,0 +1,61 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ride4Low/contracts/events"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/logging"
+)
+
+func TestPaymentEventHandlerPaymentSuccess(t *testing.T) {
+	tests := []struct {
+		name          string
+		updateTripErr error
+		wantErr       bool
+		wantPublished bool
+	}{
+		{
+			name:          "issues the receipt",
+			wantPublished: true,
+		},
+		{
+			name:          "redelivered payment issues the receipt again",
+			updateTripErr: fmt.Errorf("%w: trip-123", domain.ErrTripNotModified),
+			wantPublished: true,
+		},
+		{
+			name:          "failed update",
+			updateTripErr: errors.New("database connection failed"),
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			publisher := &messageRecorder{}
+			svc := &stubService{
+				updateTripErr: tt.updateTripErr,
+				receipt:       &domain.Receipt{TripID: "trip-123", RiderID: "user-123"},
+			}
+			handler := NewPaymentEventHandler(NewTripEventPublisher(publisher), svc, 2, logging.Discard())
+			msg := delivery(t, events.PaymentEventSuccess, events.PaymentStatusUpdateData{TripID: "trip-123", UserID: "user-123"})
+
+			// Execute
+			err := handler.Handle(context.Background(), msg)
+
+			// Verify
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			published := len(publisher.routingKeys) == 1 && publisher.routingKeys[0] == TripEventReceiptIssued
+			if published != tt.wantPublished {
+				t.Errorf("expected published %v, got %v", tt.wantPublished, publisher.routingKeys)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bytedance/sonic"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/ride4Low/contracts/events"
+	"github.com/ride4Low/trip-service/internal/events/tracing"
+	"github.com/ride4Low/trip-service/internal/lifecycle"
+)
+
+// DefaultExchange is the topic exchange trip events are published to
+const DefaultExchange = "trip"
+
+// ErrPublisherClosed is returned for messages published once the publisher
+// is draining
+var ErrPublisherClosed = errors.New("publisher is closed")
+
+// Channel is the part of a RabbitMQ channel the publisher uses
+type Channel interface {
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+// Publisher publishes messages to RabbitMQ with the W3C trace context of the
+// publishing span in their headers, so consumers continue the same trace
+type Publisher struct {
+	channel  Channel
+	exchange string
+	inflight lifecycle.Inflight
+}
+
+func NewPublisher(channel Channel, exchange string) *Publisher {
+	return &Publisher{
+		channel:  channel,
+		exchange: exchange,
+	}
+}
+
+func (p *Publisher) PublishMessage(ctx context.Context, routingKey string, message events.AmqpMessage) (err error) {
+	if !p.inflight.Acquire() {
+		return fmt.Errorf("failed to publish %s: %w", routingKey, ErrPublisherClosed)
+	}
+	defer p.inflight.Release()
+
+	ctx, span, headers := tracing.StartPublish(ctx, routingKey)
+	defer func() { tracing.End(span, err) }()
+
+	body, err := sonic.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %v", err)
+	}
+
+	if err := p.channel.PublishWithContext(ctx, p.exchange, routingKey, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Headers:      headers,
+		Body:         body,
+	}); err != nil {
+		return fmt.Errorf("failed to publish %s: %w", routingKey, err)
+	}
+
+	return nil
+}
+
+// Drain refuses new messages and waits for those being published
+func (p *Publisher) Drain(ctx context.Context) error {
+	return p.inflight.Wait(ctx)
+}
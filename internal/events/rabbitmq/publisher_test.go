@@ -0,0 +1,52 @@
+package rabbitmq
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/ride4Low/contracts/events"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type publishingRecorder struct {
+	exchange   string
+	routingKey string
+	msg        amqp.Publishing
+}
+
+func (r *publishingRecorder) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	r.exchange, r.routingKey, r.msg = exchange, key, msg
+	return nil
+}
+
+func TestPublisher(t *testing.T) {
+	// Setup
+	channel := &publishingRecorder{}
+	publisher := NewPublisher(channel, DefaultExchange)
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	// Execute
+	err := publisher.PublishMessage(ctx, events.TripEventCreated, events.AmqpMessage{OwnerID: "user-1", Data: []byte(`{}`)})
+
+	// Verify
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if channel.exchange != DefaultExchange || channel.routingKey != events.TripEventCreated {
+		t.Errorf("expected %s on %s, got %s on %s", events.TripEventCreated, DefaultExchange, channel.routingKey, channel.exchange)
+	}
+	if channel.msg.DeliveryMode != amqp.Persistent {
+		t.Error("expected a persistent message")
+	}
+	if traceparent, _ := channel.msg.Headers["traceparent"].(string); !strings.Contains(traceparent, traceID.String()) {
+		t.Errorf("expected the trace context in the headers, got %v", channel.msg.Headers)
+	}
+}
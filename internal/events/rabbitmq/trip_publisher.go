@@ -6,15 +6,40 @@ import (
 
 	"github.com/bytedance/sonic"
 	"github.com/ride4Low/contracts/events"
-	"github.com/ride4Low/contracts/pkg/rabbitmq"
 	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/events/tracing"
 )
 
+const (
+	// TripEventRated is published when a trip participant rates the other one
+	TripEventRated = "trip.event.rated"
+	// TripEventTipAdded is published when a rider tips a completed trip
+	TripEventTipAdded = "trip.event.tip_added"
+	// TripEventReceiptIssued is published with the receipt of a paid trip
+	TripEventReceiptIssued = "trip.event.receipt_issued"
+)
+
+// TripTipData is the payload of TripEventTipAdded
+type TripTipData struct {
+	TripID        string  `json:"tripID"`
+	UserID        string  `json:"userID"`
+	DriverID      string  `json:"driverID"`
+	AmountInCents float64 `json:"amountInCents"`
+	Currency      string  `json:"currency"`
+}
+
+// MessagePublisher publishes AMQP messages. It is satisfied by the RabbitMQ
+// publisher and by the in-process bus used in local mode.
+type MessagePublisher interface {
+	PublishMessage(ctx context.Context, routingKey string, message events.AmqpMessage) error
+}
+
 type TripEventPublisher struct {
-	publisher *rabbitmq.Publisher
+	publisher MessagePublisher
 }
 
-func NewTripEventPublisher(publisher *rabbitmq.Publisher) TripEventPublisher {
+func NewTripEventPublisher(publisher MessagePublisher) TripEventPublisher {
 	return TripEventPublisher{
 		publisher: publisher,
 	}
@@ -22,6 +47,8 @@ func NewTripEventPublisher(publisher *rabbitmq.Publisher) TripEventPublisher {
 
 // will be consumed by driver service to find available drivers
 func (p *TripEventPublisher) PublishTripCreated(ctx context.Context, trip *types.Trip) error {
+	ctx = tracing.WithTripID(ctx, trip.ID.Hex())
+
 	payload := events.TripEventData{
 		Trip: trip.ToProto(),
 	}
@@ -38,3 +65,77 @@ func (p *TripEventPublisher) PublishTripCreated(ctx context.Context, trip *types
 
 	return p.publisher.PublishMessage(ctx, events.TripEventCreated, amqpMsg)
 }
+
+// will be consumed by driver service to maintain aggregate driver scores
+func (p *TripEventPublisher) PublishTripRated(ctx context.Context, rating *domain.Rating) error {
+	ctx = tracing.WithTripID(ctx, rating.TripID)
+
+	data, err := sonic.Marshal(rating)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %v", err)
+	}
+
+	amqpMsg := events.AmqpMessage{
+		OwnerID: rating.RateeID,
+		Data:    data,
+	}
+
+	return p.publisher.PublishMessage(ctx, TripEventRated, amqpMsg)
+}
+
+// will be consumed by payment service to charge the tip
+func (p *TripEventPublisher) PublishTipAdded(ctx context.Context, tripID string, tip *domain.Tip) error {
+	ctx = tracing.WithTripID(ctx, tripID)
+
+	data, err := sonic.Marshal(TripTipData{
+		TripID:        tripID,
+		UserID:        tip.RiderID,
+		DriverID:      tip.DriverID,
+		AmountInCents: tip.AmountInCents,
+		Currency:      "USD",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %v", err)
+	}
+
+	amqpMsg := events.AmqpMessage{
+		OwnerID: tip.RiderID,
+		Data:    data,
+	}
+
+	return p.publisher.PublishMessage(ctx, TripEventTipAdded, amqpMsg)
+}
+
+// will be consumed by notifier to tell the rider which driver is coming
+func (p *TripEventPublisher) PublishDriverAssigned(ctx context.Context, trip *types.Trip) error {
+	ctx = tracing.WithTripID(ctx, trip.ID.Hex())
+
+	data, err := sonic.Marshal(trip)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %v", err)
+	}
+
+	amqpMsg := events.AmqpMessage{
+		OwnerID: trip.UserID,
+		Data:    data,
+	}
+
+	return p.publisher.PublishMessage(ctx, events.TripEventDriverAssigned, amqpMsg)
+}
+
+// will be consumed by notifier to mail the receipt and by analytics
+func (p *TripEventPublisher) PublishReceiptIssued(ctx context.Context, receipt *domain.Receipt) error {
+	ctx = tracing.WithTripID(ctx, receipt.TripID)
+
+	data, err := sonic.Marshal(receipt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal receipt: %v", err)
+	}
+
+	amqpMsg := events.AmqpMessage{
+		OwnerID: receipt.RiderID,
+		Data:    data,
+	}
+
+	return p.publisher.PublishMessage(ctx, TripEventReceiptIssued, amqpMsg)
+}
@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/ride4Low/trip-service/internal/events"
+
+const (
+	AttrTripID     = attribute.Key("trip.id")
+	AttrRoutingKey = attribute.Key("messaging.rabbitmq.destination.routing_key")
+)
+
+// propagator writes and reads the W3C traceparent and tracestate headers,
+// whatever global propagator is installed
+var propagator = propagation.TraceContext{}
+
+// headerCarrier adapts AMQP headers to the OTel propagators
+type headerCarrier amqp.Table
+
+func (c headerCarrier) Get(key string) string {
+	value, ok := c[key]
+	if !ok {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+type tripIDKey struct{}
+
+// WithTripID records the trip on the current span and on the spans of the
+// messages published with the returned context
+func WithTripID(ctx context.Context, tripID string) context.Context {
+	trace.SpanFromContext(ctx).SetAttributes(AttrTripID.String(tripID))
+	return context.WithValue(ctx, tripIDKey{}, tripID)
+}
+
+// StartPublish starts the producer span of a message and returns the headers
+// carrying its trace context
+func StartPublish(ctx context.Context, routingKey string) (context.Context, trace.Span, amqp.Table) {
+	attrs := []attribute.KeyValue{
+		attribute.String("messaging.system", "rabbitmq"),
+		attribute.String("messaging.operation.type", "publish"),
+		AttrRoutingKey.String(routingKey),
+	}
+	if tripID, ok := ctx.Value(tripIDKey{}).(string); ok {
+		attrs = append(attrs, AttrTripID.String(tripID))
+	}
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, routingKey+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attrs...),
+	)
+
+	headers := amqp.Table{}
+	propagator.Inject(ctx, headerCarrier(headers))
+	return ctx, span, headers
+}
+
+// StartConsume starts the consumer span of a delivery. It continues the trace
+// of the producer and links to its span, so a trip's journey stays one trace
+// across services.
+func StartConsume(ctx context.Context, msg amqp.Delivery) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.operation.type", "process"),
+			AttrRoutingKey.String(msg.RoutingKey),
+		),
+	}
+
+	if msg.Headers != nil {
+		ctx = propagator.Extract(ctx, headerCarrier(msg.Headers))
+		if producer := trace.SpanContextFromContext(ctx); producer.IsValid() {
+			opts = append(opts, trace.WithLinks(trace.Link{SpanContext: producer}))
+		}
+	}
+
+	return otel.Tracer(tracerName).Start(ctx, msg.RoutingKey+" process", opts...)
+}
+
+// End records err on span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
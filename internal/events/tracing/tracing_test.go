@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
+
+func attributeValue(attrs []attribute.KeyValue, key attribute.Key) string {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestPropagation(t *testing.T) {
+	// Setup
+	recorder := setupRecorder(t)
+	ctx, request := otel.Tracer("test").Start(context.Background(), "CreateTrip")
+	ctx = WithTripID(ctx, "trip-1")
+
+	// Execute
+	_, publish, headers := StartPublish(ctx, "trip.event.created")
+	publish.End()
+	request.End()
+
+	_, consume := StartConsume(context.Background(), amqp.Delivery{RoutingKey: "trip.event.created", Headers: headers})
+	End(consume, errors.New("unknown trip"))
+
+	// Verify
+	if _, ok := headers["traceparent"].(string); !ok {
+		t.Fatalf("expected a traceparent header, got %v", headers)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	producer, consumer := spans[0], spans[2]
+
+	if producer.SpanKind() != trace.SpanKindProducer || consumer.SpanKind() != trace.SpanKindConsumer {
+		t.Errorf("expected producer and consumer spans, got %v and %v", producer.SpanKind(), consumer.SpanKind())
+	}
+	if consumer.SpanContext().TraceID() != producer.SpanContext().TraceID() {
+		t.Error("expected the consumer to continue the trace of the producer")
+	}
+	if consumer.Parent().SpanID() != producer.SpanContext().SpanID() {
+		t.Error("expected the consumer span to be a child of the producer span")
+	}
+	if links := consumer.Links(); len(links) != 1 || links[0].SpanContext.SpanID() != producer.SpanContext().SpanID() {
+		t.Errorf("expected the consumer span to link to the producer span, got %v", links)
+	}
+	if got := attributeValue(producer.Attributes(), AttrTripID); got != "trip-1" {
+		t.Errorf("expected the producer span to carry the trip ID, got %q", got)
+	}
+	if got := attributeValue(spans[1].Attributes(), AttrTripID); got != "trip-1" {
+		t.Errorf("expected the request span to carry the trip ID, got %q", got)
+	}
+	if got := attributeValue(consumer.Attributes(), AttrRoutingKey); got != "trip.event.created" {
+		t.Errorf("expected the consumer span to carry the routing key, got %q", got)
+	}
+	if consumer.Status().Code != codes.Error {
+		t.Errorf("expected the failed delivery to be recorded, got %v", consumer.Status())
+	}
+}
+
+func TestConsumeWithoutTraceContext(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	_, span := StartConsume(context.Background(), amqp.Delivery{RoutingKey: "payment.event.success"})
+	End(span, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Parent().IsValid() || len(spans[0].Links()) != 0 {
+		t.Error("expected a new trace for a message published without trace context")
+	}
+}
@@ -0,0 +1,178 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ride4Low/contracts/events"
+	"github.com/ride4Low/contracts/proto/trip"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/events/rabbitmq"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// adminHandler serves TripAdminService, the tooling support uses to repair
+// stuck trips
+type adminHandler struct {
+	trip.UnimplementedTripAdminServiceServer
+	svc       domain.AdminService
+	publisher rabbitmq.TripEventPublisher
+	logger    *slog.Logger
+}
+
+func NewAdminHandler(server *grpc.Server, svc domain.AdminService, publisher rabbitmq.TripEventPublisher, logger *slog.Logger) *adminHandler {
+	h := &adminHandler{
+		svc:       svc,
+		publisher: publisher,
+		logger:    logger,
+	}
+	trip.RegisterTripAdminServiceServer(server, h)
+	return h
+}
+
+// withAdminMeta records the admin calling method as the actor and source of
+// the trip events the call produces
+func withAdminMeta(ctx context.Context, method string) context.Context {
+	var adminID string
+	if principal, ok := domain.PrincipalFromContext(ctx); ok {
+		adminID = principal.ID
+	}
+
+	return domain.WithEventMeta(ctx, domain.EventMeta{
+		Actor:  domain.Actor{Type: domain.ActorAdmin, ID: adminID},
+		Source: method,
+	})
+}
+
+func (h *adminHandler) ForceTransition(ctx context.Context, req *trip.ForceTransitionRequest) (*trip.ForceTransitionResponse, error) {
+	if req.GetTripID() == "" {
+		return nil, status.Error(codes.InvalidArgument, "trip id is required")
+	}
+
+	ctx = withAdminMeta(ctx, trip.TripAdminService_ForceTransition_FullMethodName)
+
+	t, err := h.svc.ForceTransition(ctx, req.GetTripID(), req.GetStatus(), req.GetReason())
+	if err != nil {
+		return nil, fmt.Errorf("failed to force the trip transition: %w", err)
+	}
+
+	return &trip.ForceTransitionResponse{
+		Trip: t.ToProto(),
+	}, nil
+}
+
+func (h *adminHandler) AssignDriver(ctx context.Context, req *trip.AssignDriverRequest) (*trip.AssignDriverResponse, error) {
+	if req.GetTripID() == "" {
+		return nil, status.Error(codes.InvalidArgument, "trip id is required")
+	}
+
+	// A driver without an ID unassigns the trip
+	driver := req.GetDriver()
+	if driver.GetId() == "" {
+		driver = nil
+	}
+
+	ctx = withAdminMeta(ctx, trip.TripAdminService_AssignDriver_FullMethodName)
+
+	t, err := h.svc.AssignDriver(ctx, req.GetTripID(), driver, req.GetReason())
+	if err != nil {
+		return nil, fmt.Errorf("failed to assign the driver: %w", err)
+	}
+
+	return &trip.AssignDriverResponse{
+		Trip: t.ToProto(),
+	}, nil
+}
+
+func (h *adminHandler) ReemitTripEvent(ctx context.Context, req *trip.ReemitTripEventRequest) (*trip.ReemitTripEventResponse, error) {
+	if req.GetTripID() == "" {
+		return nil, status.Error(codes.InvalidArgument, "trip id is required")
+	}
+
+	ctx = withAdminMeta(ctx, trip.TripAdminService_ReemitTripEvent_FullMethodName)
+
+	t, err := h.svc.GetTrip(ctx, req.GetTripID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the trip: %w", err)
+	}
+
+	// The event is rebuilt from the current state of the trip
+	var publish func(ctx context.Context, trip *types.Trip) error
+	switch req.GetRoutingKey() {
+	case events.TripEventCreated:
+		publish = h.publisher.PublishTripCreated
+	case events.TripEventDriverAssigned:
+		publish = h.publisher.PublishDriverAssigned
+	default:
+		return nil, fmt.Errorf("%w: %q", domain.ErrNotReemittable, req.GetRoutingKey())
+	}
+
+	if err := publish(ctx, t); err != nil {
+		return nil, fmt.Errorf("failed to re-emit the trip event: %w", err)
+	}
+
+	meta := domain.EventMetaFromContext(ctx)
+	h.logger.InfoContext(ctx, "Re-emitted trip event", "trip_id", req.GetTripID(), "routing_key", req.GetRoutingKey(), "actor_id", meta.Actor.ID)
+
+	return &trip.ReemitTripEventResponse{}, nil
+}
+
+func (h *adminHandler) ExpireQuotes(ctx context.Context, req *trip.ExpireQuotesRequest) (*trip.ExpireQuotesResponse, error) {
+	ctx = withAdminMeta(ctx, trip.TripAdminService_ExpireQuotes_FullMethodName)
+
+	expired, err := h.svc.ExpireQuotes(ctx, domain.QuoteFilter{
+		IDs:    req.GetQuoteIDs(),
+		UserID: req.GetUserID(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire the quotes: %w", err)
+	}
+
+	return &trip.ExpireQuotesResponse{
+		Expired: int32(expired),
+	}, nil
+}
+
+func (h *adminHandler) SearchTrips(ctx context.Context, req *trip.SearchTripsRequest) (*trip.SearchTripsResponse, error) {
+	if req.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page size cannot be negative")
+	}
+
+	filter := domain.TripFilter{
+		Statuses:    req.GetStatuses(),
+		UserID:      req.GetUserID(),
+		DriverID:    req.GetDriverID(),
+		PackageSlug: req.GetPackageSlug(),
+		AfterID:     req.GetPageToken(),
+		Limit:       int(req.GetPageSize()),
+	}
+	if req.GetCreatedAfter() != nil {
+		filter.CreatedAfter = req.GetCreatedAfter().AsTime()
+	}
+	if req.GetCreatedBefore() != nil {
+		filter.CreatedBefore = req.GetCreatedBefore().AsTime()
+	}
+
+	trips, err := h.svc.SearchTrips(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the trips: %w", err)
+	}
+
+	resp := &trip.SearchTripsResponse{
+		Trips: make([]*trip.Trip, 0, len(trips)),
+	}
+	for _, t := range trips {
+		resp.Trips = append(resp.Trips, t.ToProto())
+	}
+
+	// A full page may be followed by another one
+	if len(trips) > 0 && len(trips) >= filter.PageSize() {
+		resp.NextPageToken = trips[len(trips)-1].ID.Hex()
+	}
+
+	return resp, nil
+}
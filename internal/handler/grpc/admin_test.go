@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ride4Low/contracts/events"
+	"github.com/ride4Low/contracts/proto/trip"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/events/rabbitmq"
+	"github.com/ride4Low/trip-service/internal/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// mockAdminService is a mock implementation of domain.AdminService for
+// testing
+type mockAdminService struct {
+	domain.AdminService
+	getTripFunc     func(ctx context.Context, tripID string) (*types.Trip, error)
+	searchTripsFunc func(ctx context.Context, filter domain.TripFilter) ([]*types.Trip, error)
+}
+
+func (m *mockAdminService) GetTrip(ctx context.Context, tripID string) (*types.Trip, error) {
+	return m.getTripFunc(ctx, tripID)
+}
+
+func (m *mockAdminService) SearchTrips(ctx context.Context, filter domain.TripFilter) ([]*types.Trip, error) {
+	return m.searchTripsFunc(ctx, filter)
+}
+
+type recordingPublisher struct {
+	routingKeys []string
+}
+
+func (p *recordingPublisher) PublishMessage(ctx context.Context, routingKey string, message events.AmqpMessage) error {
+	p.routingKeys = append(p.routingKeys, routingKey)
+	return nil
+}
+
+func TestReemitTripEvent(t *testing.T) {
+	tripID := primitive.NewObjectID()
+	mockSvc := &mockAdminService{
+		getTripFunc: func(ctx context.Context, id string) (*types.Trip, error) {
+			return &types.Trip{ID: tripID, UserID: "user-123", Status: domain.TripStatusAccepted, Driver: &trip.TripDriver{Id: "driver-1"}}, nil
+		},
+	}
+
+	tests := []struct {
+		name       string
+		routingKey string
+		wantErr    error
+	}{
+		{name: "created", routingKey: events.TripEventCreated},
+		{name: "driver assigned", routingKey: events.TripEventDriverAssigned},
+		{name: "not reemittable", routingKey: rabbitmq.TripEventTipAdded, wantErr: domain.ErrNotReemittable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			publisher := &recordingPublisher{}
+			h := &adminHandler{svc: mockSvc, publisher: rabbitmq.NewTripEventPublisher(publisher), logger: logging.Discard()}
+
+			// Execute
+			_, err := h.ReemitTripEvent(context.Background(), &trip.ReemitTripEventRequest{TripID: tripID.Hex(), RoutingKey: tt.routingKey})
+
+			// Verify
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && (len(publisher.routingKeys) != 1 || publisher.routingKeys[0] != tt.routingKey) {
+				t.Errorf("expected %s to be published, got %v", tt.routingKey, publisher.routingKeys)
+			}
+			if tt.wantErr != nil && len(publisher.routingKeys) != 0 {
+				t.Errorf("expected nothing published, got %v", publisher.routingKeys)
+			}
+		})
+	}
+}
+
+func TestSearchTripsPaging(t *testing.T) {
+	trips := []*types.Trip{
+		{ID: primitive.NewObjectID(), Status: domain.TripStatusPending},
+		{ID: primitive.NewObjectID(), Status: domain.TripStatusPending},
+	}
+	var gotFilter domain.TripFilter
+	mockSvc := &mockAdminService{
+		searchTripsFunc: func(ctx context.Context, filter domain.TripFilter) ([]*types.Trip, error) {
+			gotFilter = filter
+			return trips[:min(len(trips), filter.PageSize())], nil
+		},
+	}
+	h := &adminHandler{svc: mockSvc, logger: logging.Discard()}
+
+	tests := []struct {
+		name      string
+		pageSize  int32
+		wantToken string
+	}{
+		{name: "full page", pageSize: 2, wantToken: trips[1].ID.Hex()},
+		{name: "last page", pageSize: 3, wantToken: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			resp, err := h.SearchTrips(context.Background(), &trip.SearchTripsRequest{
+				Statuses:  []string{domain.TripStatusPending},
+				PageSize:  tt.pageSize,
+				PageToken: "previous",
+			})
+
+			// Verify
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(resp.Trips) != 2 {
+				t.Errorf("expected 2 trips, got %d", len(resp.Trips))
+			}
+			if resp.NextPageToken != tt.wantToken {
+				t.Errorf("expected next page token %q, got %q", tt.wantToken, resp.NextPageToken)
+			}
+			if gotFilter.AfterID != "previous" || len(gotFilter.Statuses) != 1 {
+				t.Errorf("unexpected filter: %+v", gotFilter)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Metadata keys carrying the caller identity
+const (
+	authorizationKey = "authorization"
+	userIDKey        = "x-user-id"
+	userRolesKey     = "x-user-roles"
+)
+
+// errNoCredentials is returned by an authenticator when the request carries
+// none of the credentials it understands, so the next one can be tried
+var errNoCredentials = errors.New("no credentials")
+
+// Authenticator resolves the caller of a request
+type Authenticator interface {
+	Authenticate(ctx context.Context) (*domain.Principal, error)
+}
+
+type AuthConfig struct {
+	// JWTSecret validates HS256 bearer tokens issued by the auth service
+	JWTSecret   string
+	JWTIssuer   string
+	JWTAudience string
+	// GatewayIdentities are the client certificate names of the API gateways
+	// allowed to forward an already authenticated identity over mTLS
+	GatewayIdentities []string
+}
+
+// NewAuthenticator builds an authenticator accepting every credential
+// configured in cfg
+func NewAuthenticator(cfg *AuthConfig) (Authenticator, error) {
+	var chain chainAuthenticator
+	if cfg.JWTSecret != "" {
+		chain = append(chain, NewJWTAuthenticator(cfg.JWTSecret, cfg.JWTIssuer, cfg.JWTAudience))
+	}
+	if len(cfg.GatewayIdentities) > 0 {
+		chain = append(chain, NewGatewayAuthenticator(cfg.GatewayIdentities...))
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no authentication configured: set JWT_SECRET or GATEWAY_IDENTITIES")
+	}
+	return chain, nil
+}
+
+// UnaryAuthInterceptor authenticates every call except the public methods
+// and attaches the principal to the context
+func UnaryAuthInterceptor(authenticator Authenticator, publicMethods ...string) grpc.UnaryServerInterceptor {
+	public := map[string]bool{}
+	for _, method := range publicMethods {
+		public[method] = true
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if public[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		principal, err := authenticator.Authenticate(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrUnauthenticated, err)
+		}
+
+		return handler(domain.WithPrincipal(ctx, principal), req)
+	}
+}
+
+type chainAuthenticator []Authenticator
+
+func (c chainAuthenticator) Authenticate(ctx context.Context) (*domain.Principal, error) {
+	for _, authenticator := range c {
+		principal, err := authenticator.Authenticate(ctx)
+		if errors.Is(err, errNoCredentials) {
+			continue
+		}
+		return principal, err
+	}
+	return nil, errNoCredentials
+}
+
+type jwtClaims struct {
+	Roles []string `json:"roles"`
+	jwt.RegisteredClaims
+}
+
+type jwtAuthenticator struct {
+	secret []byte
+	parser *jwt.Parser
+}
+
+// NewJWTAuthenticator validates HS256 bearer tokens from the authorization
+// metadata. The subject is the principal ID and the roles claim its roles.
+func NewJWTAuthenticator(secret, issuer, audience string) Authenticator {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+
+	return &jwtAuthenticator{
+		secret: []byte(secret),
+		parser: jwt.NewParser(opts...),
+	}
+}
+
+func (a *jwtAuthenticator) Authenticate(ctx context.Context) (*domain.Principal, error) {
+	header := firstMetadata(ctx, authorizationKey)
+	if header == "" {
+		return nil, errNoCredentials
+	}
+
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return nil, fmt.Errorf("authorization must be a bearer token")
+	}
+
+	var claims jwtClaims
+	if _, err := a.parser.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return a.secret, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("token has no subject")
+	}
+
+	return &domain.Principal{
+		ID:    claims.Subject,
+		Roles: claims.Roles,
+	}, nil
+}
+
+type gatewayAuthenticator struct {
+	identities map[string]bool
+	trustAll   bool
+}
+
+// NewGatewayAuthenticator trusts the x-user-id and x-user-roles metadata set
+// by an API gateway, but only on connections where the gateway presented a
+// verified client certificate with one of the given names.
+func NewGatewayAuthenticator(identities ...string) Authenticator {
+	a := &gatewayAuthenticator{identities: map[string]bool{}}
+	for _, identity := range identities {
+		a.identities[identity] = true
+	}
+	return a
+}
+
+// NewTrustedMetadataAuthenticator trusts the x-user-id and x-user-roles
+// metadata of every connection. It is only meant for local mode.
+func NewTrustedMetadataAuthenticator() Authenticator {
+	return &gatewayAuthenticator{trustAll: true}
+}
+
+func (a *gatewayAuthenticator) Authenticate(ctx context.Context) (*domain.Principal, error) {
+	userID := firstMetadata(ctx, userIDKey)
+	if userID == "" {
+		return nil, errNoCredentials
+	}
+
+	if !a.trustAll {
+		identity, err := peerIdentity(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !a.identities[identity] {
+			return nil, fmt.Errorf("peer %q is not a trusted gateway", identity)
+		}
+	}
+
+	return &domain.Principal{
+		ID:    userID,
+		Roles: splitList(firstMetadata(ctx, userRolesKey)),
+	}, nil
+}
+
+// peerIdentity returns the name on the verified client certificate of the
+// connection
+func peerIdentity(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("unknown peer")
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return "", fmt.Errorf("peer did not present a verified client certificate")
+	}
+
+	cert := tlsInfo.State.VerifiedChains[0][0]
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0], nil
+	}
+	return cert.Subject.CommonName, nil
+}
+
+// NewServerTLSConfig verifies client certificates against the given CA, which
+// is how API gateways authenticate to the service. Clients using bearer
+// tokens can connect without one.
+func NewServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	caPEM, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", clientCAFile)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,194 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const testSecret = "test-secret"
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func incomingContext(pairs ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+}
+
+func gatewayPeerContext(ctx context.Context, name string) context.Context {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: name}}
+	return peer.NewContext(ctx, &peer.Peer{
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+		},
+	})
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	authenticator := NewJWTAuthenticator(testSecret, "auth-service", "")
+	valid := jwtClaims{
+		Roles: []string{domain.RoleRider},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user-123",
+			Issuer:    "auth-service",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		// Setup
+		token := signToken(t, jwt.SigningMethodHS256, []byte(testSecret), valid)
+		ctx := incomingContext(authorizationKey, "Bearer "+token)
+
+		// Execute
+		principal, err := authenticator.Authenticate(ctx)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if principal.ID != "user-123" || !principal.HasRole(domain.RoleRider) {
+			t.Errorf("unexpected principal: %+v", principal)
+		}
+	})
+
+	expired := valid
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	otherIssuer := valid
+	otherIssuer.Issuer = "someone-else"
+	noExpiry := valid
+	noExpiry.ExpiresAt = nil
+
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{name: "expired token", header: "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), expired)},
+		{name: "wrong secret", header: "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte("other-secret"), valid)},
+		{name: "wrong issuer", header: "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), otherIssuer)},
+		{name: "missing expiry", header: "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), noExpiry)},
+		{name: "unsigned token", header: "Bearer " + signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid)},
+		{name: "not a bearer token", header: "Basic dXNlcjpwYXNz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			principal, err := authenticator.Authenticate(incomingContext(authorizationKey, tt.header))
+
+			// Verify
+			if err == nil || errors.Is(err, errNoCredentials) {
+				t.Errorf("expected the token to be rejected, got %+v, %v", principal, err)
+			}
+		})
+	}
+
+	t.Run("no token", func(t *testing.T) {
+		_, err := authenticator.Authenticate(context.Background())
+		if !errors.Is(err, errNoCredentials) {
+			t.Errorf("expected errNoCredentials, got %v", err)
+		}
+	})
+}
+
+func TestGatewayAuthenticator(t *testing.T) {
+	authenticator := NewGatewayAuthenticator("api-gateway")
+	ctx := incomingContext(userIDKey, "user-123", userRolesKey, "rider, admin")
+
+	t.Run("trusted gateway", func(t *testing.T) {
+		// Execute
+		principal, err := authenticator.Authenticate(gatewayPeerContext(ctx, "api-gateway"))
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if principal.ID != "user-123" || !principal.HasRole(domain.RoleAdmin) {
+			t.Errorf("unexpected principal: %+v", principal)
+		}
+	})
+
+	t.Run("unknown certificate", func(t *testing.T) {
+		if _, err := authenticator.Authenticate(gatewayPeerContext(ctx, "someone")); err == nil {
+			t.Error("expected an untrusted peer to be rejected")
+		}
+	})
+
+	t.Run("no client certificate", func(t *testing.T) {
+		if _, err := authenticator.Authenticate(ctx); err == nil {
+			t.Error("expected a peer without certificate to be rejected")
+		}
+	})
+}
+
+func TestUnaryAuthInterceptor(t *testing.T) {
+	authenticator, err := NewAuthenticator(&AuthConfig{JWTSecret: testSecret, GatewayIdentities: []string{"api-gateway"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	interceptor := UnaryAuthInterceptor(authenticator, "/grpc.health.v1.Health/Check")
+
+	var got *domain.Principal
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		got, _ = domain.PrincipalFromContext(ctx)
+		return "ok", nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/trip.TripService/PreviewTrip"}
+
+	t.Run("authenticated call", func(t *testing.T) {
+		// Setup
+		token := signToken(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.RegisteredClaims{
+			Subject:   "user-123",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		})
+
+		// Execute
+		_, err := interceptor(incomingContext(authorizationKey, "Bearer "+token), nil, info, handler)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got == nil || got.ID != "user-123" {
+			t.Errorf("expected the principal in the context, got %+v", got)
+		}
+	})
+
+	t.Run("missing credentials", func(t *testing.T) {
+		_, err := interceptor(context.Background(), nil, info, handler)
+		if !errors.Is(err, domain.ErrUnauthenticated) {
+			t.Errorf("expected ErrUnauthenticated, got %v", err)
+		}
+	})
+
+	t.Run("public method", func(t *testing.T) {
+		got = nil
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+		if err != nil || got != nil {
+			t.Errorf("expected an anonymous call, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("no authentication configured", func(t *testing.T) {
+		if _, err := NewAuthenticator(&AuthConfig{}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ride4Low/trip-service/internal/domain"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// errorDomain identifies this service in google.rpc.ErrorInfo details
+const errorDomain = "trip-service.ride4low"
+
+// unavailableRetryDelay is suggested to clients when a dependency is down
+const unavailableRetryDelay = time.Second
+
+// retryAfterError tells the client when it may retry the call
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+var kindCodes = map[domain.ErrorKind]codes.Code{
+	domain.KindInternal:           codes.Internal,
+	domain.KindNotFound:           codes.NotFound,
+	domain.KindInvalidArgument:    codes.InvalidArgument,
+	domain.KindFailedPrecondition: codes.FailedPrecondition,
+	domain.KindPermissionDenied:   codes.PermissionDenied,
+	domain.KindAlreadyExists:      codes.AlreadyExists,
+	domain.KindUnavailable:        codes.Unavailable,
+	domain.KindResourceExhausted:  codes.ResourceExhausted,
+	domain.KindUnauthenticated:    codes.Unauthenticated,
+}
+
+// UnaryErrorInterceptor converts the errors returned by handlers into gRPC
+// statuses, so handlers can return domain errors as they are. Internal errors
+// are logged and replaced by a generic message before reaching the client.
+func UnaryErrorInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+
+		st := status.Convert(toStatus(err))
+		if st.Code() == codes.Internal || st.Code() == codes.Unavailable {
+			logger.ErrorContext(ctx, "Call failed", "method", info.FullMethod, "error", err)
+		}
+
+		return nil, st.Err()
+	}
+}
+
+// toStatus maps an error to a gRPC status error carrying an ErrorInfo with
+// the domain reason, and a RetryInfo when retrying later can help.
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request canceled")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	}
+
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) {
+		return status.Error(codes.Internal, "internal error")
+	}
+
+	code, ok := kindCodes[domainErr.Kind]
+	if !ok {
+		code = codes.Internal
+	}
+
+	// Client errors carry details meant for the caller, anything else may
+	// leak infrastructure details and only keeps the domain message
+	message := err.Error()
+	if code == codes.Internal || code == codes.Unavailable {
+		message = domainErr.Message
+	}
+
+	st := status.New(code, message)
+	withDetails, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: domainErr.Reason,
+		Domain: errorDomain,
+	})
+	if detailsErr != nil {
+		return st.Err()
+	}
+
+	retryDelay := time.Duration(0)
+	var retryErr *retryAfterError
+	if errors.As(err, &retryErr) {
+		retryDelay = retryErr.delay
+	} else if code == codes.Unavailable {
+		retryDelay = unavailableRetryDelay
+	}
+
+	if retryDelay > 0 {
+		if withRetry, err := withDetails.WithDetails(&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(retryDelay),
+		}); err == nil {
+			withDetails = withRetry
+		}
+	}
+
+	return withDetails.Err()
+}
@@ -0,0 +1,139 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/logging"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryErrorInterceptor(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		expectedCode    codes.Code
+		expectedMessage string
+		expectedReason  string
+		expectRetry     bool
+	}{
+		{
+			name:            "trip not found",
+			err:             fmt.Errorf("failed to get trip: %w", domain.ErrTripNotFound),
+			expectedCode:    codes.NotFound,
+			expectedMessage: "failed to get trip: trip not found",
+			expectedReason:  "TRIP_NOT_FOUND",
+		},
+		{
+			name:           "fare expired",
+			err:            domain.ErrFareExpired,
+			expectedCode:   codes.FailedPrecondition,
+			expectedReason: "FARE_EXPIRED",
+		},
+		{
+			name:            "forbidden",
+			err:             fmt.Errorf("%w: fare does not belong to the user", domain.ErrForbidden),
+			expectedCode:    codes.PermissionDenied,
+			expectedMessage: "operation not allowed: fare does not belong to the user",
+			expectedReason:  "FORBIDDEN",
+		},
+		{
+			name:           "invalid transition",
+			err:            fmt.Errorf("%w: paid to pending", domain.ErrInvalidTransition),
+			expectedCode:   codes.FailedPrecondition,
+			expectedReason: "INVALID_TRANSITION",
+		},
+		{
+			name:            "upstream unavailable hides the cause",
+			err:             fmt.Errorf("%w: %w", domain.ErrUpstreamUnavailable, errors.New("dial tcp 10.0.0.7:5000: connection refused")),
+			expectedCode:    codes.Unavailable,
+			expectedMessage: "upstream service unavailable",
+			expectedReason:  "UPSTREAM_UNAVAILABLE",
+			expectRetry:     true,
+		},
+		{
+			name:            "unknown errors are scrubbed",
+			err:             errors.New("mongo: connection string mongodb://admin:secret@db"),
+			expectedCode:    codes.Internal,
+			expectedMessage: "internal error",
+		},
+		{
+			name:         "context canceled",
+			err:          fmt.Errorf("failed to get trip: %w", context.Canceled),
+			expectedCode: codes.Canceled,
+		},
+		{
+			name:            "status errors pass through",
+			err:             status.Error(codes.InvalidArgument, "trip id is required"),
+			expectedCode:    codes.InvalidArgument,
+			expectedMessage: "trip id is required",
+		},
+	}
+
+	interceptor := UnaryErrorInterceptor(logging.Discard())
+	info := &grpc.UnaryServerInfo{FullMethod: "/trip.TripService/GetTrip"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			resp, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "ignored", tt.err
+			})
+
+			// Verify
+			if resp != nil {
+				t.Errorf("expected nil response, got %v", resp)
+			}
+			st, ok := status.FromError(err)
+			if !ok {
+				t.Fatalf("expected gRPC status error, got %v", err)
+			}
+			if st.Code() != tt.expectedCode {
+				t.Errorf("expected code %v, got %v", tt.expectedCode, st.Code())
+			}
+			if tt.expectedMessage != "" && st.Message() != tt.expectedMessage {
+				t.Errorf("expected message %q, got %q", tt.expectedMessage, st.Message())
+			}
+
+			var info *errdetails.ErrorInfo
+			var retry *errdetails.RetryInfo
+			for _, detail := range st.Details() {
+				switch d := detail.(type) {
+				case *errdetails.ErrorInfo:
+					info = d
+				case *errdetails.RetryInfo:
+					retry = d
+				}
+			}
+			if tt.expectedReason != "" {
+				if info == nil {
+					t.Fatal("expected an ErrorInfo detail")
+				}
+				if info.Reason != tt.expectedReason || info.Domain != errorDomain {
+					t.Errorf("expected reason %s in %s, got %s in %s", tt.expectedReason, errorDomain, info.Reason, info.Domain)
+				}
+			}
+			if tt.expectRetry && (retry == nil || retry.RetryDelay.AsDuration() <= 0) {
+				t.Errorf("expected a RetryInfo detail, got %v", retry)
+			}
+			if !tt.expectRetry && retry != nil {
+				t.Errorf("expected no RetryInfo detail, got %v", retry)
+			}
+		})
+	}
+
+	t.Run("successful calls are untouched", func(t *testing.T) {
+		resp, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "ok", nil
+		})
+		if err != nil || resp != "ok" {
+			t.Errorf("expected ok, got %v, %v", resp, err)
+		}
+	})
+}
@@ -3,12 +3,14 @@ package grpc
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
+	"github.com/bytedance/sonic"
 	"github.com/ride4Low/contracts/proto/trip"
 	"github.com/ride4Low/contracts/types"
 	"github.com/ride4Low/trip-service/internal/domain"
 	"github.com/ride4Low/trip-service/internal/events/rabbitmq"
+	"github.com/ride4Low/trip-service/internal/receipt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -19,12 +21,14 @@ type handler struct {
 	trip.UnimplementedTripServiceServer
 	svc       domain.Service
 	publisher rabbitmq.TripEventPublisher
+	logger    *slog.Logger
 }
 
-func NewHandler(server *grpc.Server, svc domain.Service, publisher rabbitmq.TripEventPublisher) *handler {
+func NewHandler(server *grpc.Server, svc domain.Service, publisher rabbitmq.TripEventPublisher, logger *slog.Logger) *handler {
 	h := &handler{
 		svc:       svc,
 		publisher: publisher,
+		logger:    logger,
 	}
 	trip.RegisterTripServiceServer(server, h)
 	return h
@@ -34,18 +38,23 @@ func (h *handler) CreateTrip(ctx context.Context, req *trip.CreateTripRequest) (
 	fareID := req.GetRideFareID()
 	userID := req.GetUserID()
 
+	ctx = domain.WithEventMeta(ctx, domain.EventMeta{
+		Actor:  domain.Actor{Type: domain.ActorRider, ID: userID},
+		Source: trip.TripService_CreateTrip_FullMethodName,
+	})
+
 	rideFare, err := h.svc.GetAndValidateFare(ctx, fareID, userID)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "failed to get and validate the fare: %v", err)
+		return nil, fmt.Errorf("failed to get and validate the fare: %w", err)
 	}
 
 	t, err := h.svc.CreateTrip(ctx, rideFare)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create the trip: %v", err)
+		return nil, fmt.Errorf("failed to create the trip: %w", err)
 	}
 
 	if err := h.publisher.PublishTripCreated(ctx, t); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to publish the trip created event: %v", err)
+		return nil, fmt.Errorf("failed to publish the trip created event: %w", err)
 	}
 
 	return &trip.CreateTripResponse{
@@ -57,12 +66,12 @@ func (h *handler) PreviewTrip(ctx context.Context, req *trip.PreviewTripRequest)
 	pickup := req.GetPickupLocation()
 	dropoff := req.GetDropoffLocation()
 
-	log.Println("PreviewTrip")
-
 	if pickup == nil || dropoff == nil {
 		return nil, status.Error(codes.InvalidArgument, "pickup and dropoff locations are required")
 	}
 
+	h.logger.DebugContext(ctx, "Previewing trip", "pickup", pickup, "dropoff", dropoff)
+
 	pickupCoordinates := types.Coordinate{
 		Latitude:  pickup.GetLatitude(),
 		Longitude: pickup.GetLongitude(),
@@ -75,14 +84,14 @@ func (h *handler) PreviewTrip(ctx context.Context, req *trip.PreviewTripRequest)
 
 	osrmResponse, err := h.svc.GetRoute(ctx, pickupCoordinates, dropoffCoordinates)
 	if err != nil {
-		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to get route: %v", err))
+		return nil, fmt.Errorf("failed to get route: %w", err)
 	}
 
 	estimatedFares := h.svc.EstimatePackagesPriceWithRoute(osrmResponse)
 
 	fares, err := h.svc.CreateTripFares(ctx, estimatedFares, req.GetUserID(), osrmResponse)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to generate the ride fares: %v", err)
+		return nil, fmt.Errorf("failed to generate the ride fares: %w", err)
 	}
 
 	return &trip.PreviewTripResponse{
@@ -90,3 +99,122 @@ func (h *handler) PreviewTrip(ctx context.Context, req *trip.PreviewTripRequest)
 		RideFares: domain.ToRideFaresProto(fares),
 	}, nil
 }
+
+func (h *handler) GetTripTimeline(ctx context.Context, req *trip.GetTripTimelineRequest) (*trip.GetTripTimelineResponse, error) {
+	tripID := req.GetTripID()
+	if tripID == "" {
+		return nil, status.Error(codes.InvalidArgument, "trip id is required")
+	}
+
+	events, err := h.svc.GetTripTimeline(ctx, tripID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the trip timeline: %w", err)
+	}
+
+	return &trip.GetTripTimelineResponse{
+		Events: domain.ToTripEventsProto(events),
+	}, nil
+}
+
+func (h *handler) RateTrip(ctx context.Context, req *trip.RateTripRequest) (*trip.RateTripResponse, error) {
+	rating := &domain.Rating{
+		TripID:    req.GetTripID(),
+		RaterRole: req.GetRaterRole(),
+		RaterID:   req.GetUserID(),
+		Stars:     int(req.GetStars()),
+		Tags:      req.GetTags(),
+		Comment:   req.GetComment(),
+	}
+
+	rating, err := h.svc.RateTrip(ctx, rating)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rate the trip: %w", err)
+	}
+
+	// When the publish fails the client retries, and the same rating is
+	// returned again so the event is published on the retry
+	if err := h.publisher.PublishTripRated(ctx, rating); err != nil {
+		return nil, fmt.Errorf("failed to publish the trip rated event: %w", err)
+	}
+
+	return &trip.RateTripResponse{
+		RatingID: rating.ID.Hex(),
+	}, nil
+}
+
+func (h *handler) AddTip(ctx context.Context, req *trip.AddTipRequest) (*trip.AddTipResponse, error) {
+	tripID := req.GetTripID()
+
+	tip, err := h.svc.AddTip(ctx, tripID, req.GetUserID(), req.GetAmountInCents())
+	if err != nil {
+		return nil, fmt.Errorf("failed to add the tip: %w", err)
+	}
+
+	// When the publish fails the client retries, and the same tip is returned
+	// again so the event is published on the retry
+	if err := h.publisher.PublishTipAdded(ctx, tripID, tip); err != nil {
+		return nil, fmt.Errorf("failed to publish the tip added event: %w", err)
+	}
+
+	return &trip.AddTipResponse{
+		TripID:        tripID,
+		AmountInCents: tip.AmountInCents,
+	}, nil
+}
+
+func (h *handler) GetReceipt(ctx context.Context, req *trip.GetReceiptRequest) (*trip.GetReceiptResponse, error) {
+	r, err := h.svc.GetReceipt(ctx, req.GetTripID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the receipt: %w", err)
+	}
+
+	content, contentType, err := receipt.Render(r, req.GetFormat())
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the receipt: %w", err)
+	}
+
+	return &trip.GetReceiptResponse{
+		ContentType: contentType,
+		Content:     content,
+	}, nil
+}
+
+func (h *handler) ExportUserData(ctx context.Context, req *trip.ExportUserDataRequest) (*trip.ExportUserDataResponse, error) {
+	if req.GetUserID() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user ID is required")
+	}
+
+	data, err := h.svc.ExportUserData(ctx, req.GetUserID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to export the user data: %w", err)
+	}
+
+	content, err := sonic.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the user data: %w", err)
+	}
+
+	return &trip.ExportUserDataResponse{
+		ContentType: "application/json",
+		Content:     content,
+	}, nil
+}
+
+func (h *handler) EraseUserData(ctx context.Context, req *trip.EraseUserDataRequest) (*trip.EraseUserDataResponse, error) {
+	if req.GetUserID() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user ID is required")
+	}
+
+	report, err := h.svc.EraseUserData(ctx, req.GetUserID(), req.GetMode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase the user data: %w", err)
+	}
+
+	return &trip.EraseUserDataResponse{
+		Trips:      int32(report.Trips),
+		TripEvents: int32(report.TripEvents),
+		Ratings:    int32(report.Ratings),
+		RideFares:  int32(report.RideFares),
+		Quotes:     int32(report.Quotes),
+	}, nil
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/ride4Low/contracts/proto/driver"
 	"github.com/ride4Low/contracts/proto/trip"
 	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/logging"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -21,6 +23,12 @@ type mockService struct {
 	createTripFunc                     func(ctx context.Context, fare *types.RideFare) (*types.Trip, error)
 	getTripFunc                        func(ctx context.Context, id string) (*types.Trip, error)
 	updateTripFunc                     func(ctx context.Context, tripID string, status string, driver *driver.Driver) error
+	getTripTimelineFunc                func(ctx context.Context, tripID string) ([]*domain.TripEvent, error)
+	rateTripFunc                       func(ctx context.Context, rating *domain.Rating) (*domain.Rating, error)
+	addTipFunc                         func(ctx context.Context, tripID, userID string, amountInCents float64) (*domain.Tip, error)
+	getReceiptFunc                     func(ctx context.Context, tripID string) (*domain.Receipt, error)
+	exportUserDataFunc                 func(ctx context.Context, userID string) (*domain.UserData, error)
+	eraseUserDataFunc                  func(ctx context.Context, userID, mode string) (*domain.ErasureReport, error)
 }
 
 func (m *mockService) GetRoute(ctx context.Context, pickup, dropoff types.Coordinate) (*types.OsrmApiResponse, error) {
@@ -72,6 +80,48 @@ func (m *mockService) UpdateTrip(ctx context.Context, tripID string, status stri
 	return errors.New("not implemented")
 }
 
+func (m *mockService) GetTripTimeline(ctx context.Context, tripID string) ([]*domain.TripEvent, error) {
+	if m.getTripTimelineFunc != nil {
+		return m.getTripTimelineFunc(ctx, tripID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockService) RateTrip(ctx context.Context, rating *domain.Rating) (*domain.Rating, error) {
+	if m.rateTripFunc != nil {
+		return m.rateTripFunc(ctx, rating)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockService) AddTip(ctx context.Context, tripID, userID string, amountInCents float64) (*domain.Tip, error) {
+	if m.addTipFunc != nil {
+		return m.addTipFunc(ctx, tripID, userID, amountInCents)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockService) GetReceipt(ctx context.Context, tripID string) (*domain.Receipt, error) {
+	if m.getReceiptFunc != nil {
+		return m.getReceiptFunc(ctx, tripID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockService) ExportUserData(ctx context.Context, userID string) (*domain.UserData, error) {
+	if m.exportUserDataFunc != nil {
+		return m.exportUserDataFunc(ctx, userID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockService) EraseUserData(ctx context.Context, userID, mode string) (*domain.ErasureReport, error) {
+	if m.eraseUserDataFunc != nil {
+		return m.eraseUserDataFunc(ctx, userID, mode)
+	}
+	return nil, errors.New("not implemented")
+}
+
 func TestPreviewTrip(t *testing.T) {
 	t.Run("successful route retrieval", func(t *testing.T) {
 		// Create mock service that returns a successful response
@@ -119,7 +169,7 @@ func TestPreviewTrip(t *testing.T) {
 			},
 		}
 
-		h := &handler{svc: mockSvc}
+		h := &handler{svc: mockSvc, logger: logging.Discard()}
 
 		req := &trip.PreviewTripRequest{
 			UserID: "user123",
@@ -161,7 +211,7 @@ func TestPreviewTrip(t *testing.T) {
 
 	t.Run("missing pickup location", func(t *testing.T) {
 		mockSvc := &mockService{}
-		h := &handler{svc: mockSvc}
+		h := &handler{svc: mockSvc, logger: logging.Discard()}
 
 		req := &trip.PreviewTripRequest{
 			UserID: "user123",
@@ -191,7 +241,7 @@ func TestPreviewTrip(t *testing.T) {
 
 	t.Run("missing dropoff location", func(t *testing.T) {
 		mockSvc := &mockService{}
-		h := &handler{svc: mockSvc}
+		h := &handler{svc: mockSvc, logger: logging.Discard()}
 
 		req := &trip.PreviewTripRequest{
 			UserID: "user123",
@@ -227,7 +277,7 @@ func TestPreviewTrip(t *testing.T) {
 			},
 		}
 
-		h := &handler{svc: mockSvc}
+		h := &handler{svc: mockSvc, logger: logging.Discard()}
 
 		req := &trip.PreviewTripRequest{
 			UserID: "user123",
@@ -247,7 +297,7 @@ func TestPreviewTrip(t *testing.T) {
 		if err == nil {
 			t.Fatal("expected error, got nil")
 		}
-		st, ok := status.FromError(err)
+		st, ok := status.FromError(toStatus(err))
 		if !ok {
 			t.Fatal("expected gRPC status error")
 		}
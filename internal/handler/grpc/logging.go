@@ -0,0 +1,41 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// tripRequest is a request about a single trip
+type tripRequest interface {
+	GetTripID() string
+}
+
+// UnaryLoggingInterceptor attaches the method, the user ID and the trip ID
+// of the request to every line logged while serving the call, and logs the
+// call once done. It runs after authentication so the principal is known.
+func UnaryLoggingInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = logging.With(ctx, "method", info.FullMethod)
+		if principal, ok := domain.PrincipalFromContext(ctx); ok {
+			ctx = logging.With(ctx, "user_id", principal.ID)
+		}
+		if r, ok := req.(tripRequest); ok && r.GetTripID() != "" {
+			ctx = logging.With(ctx, "trip_id", r.GetTripID())
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		logger.DebugContext(ctx, "Handled call",
+			"code", status.Code(toStatus(err)).String(),
+			"duration", time.Since(start))
+
+		return resp, err
+	}
+}
@@ -0,0 +1,167 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+
+	"github.com/ride4Low/contracts/proto/trip"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+const forwardedForKey = "x-forwarded-for"
+
+// MethodLimit is the rate limit of one RPC, per authenticated user and per
+// client IP. A zero limit is not enforced.
+type MethodLimit struct {
+	PerUser ratelimit.Limit
+	PerIP   ratelimit.Limit
+}
+
+type RateLimitConfig struct {
+	// Methods maps full method names to their limits
+	Methods map[string]MethodLimit
+	// TrustForwardedFor takes the client IP from x-forwarded-for, for when the
+	// service is only reachable through the API gateway
+	TrustForwardedFor bool
+	// RedisURL shares the limits between replicas, otherwise they are kept
+	// in memory
+	RedisURL string
+}
+
+// DefaultMethodLimits protects the RPCs that call OSRM or write to Mongo
+func DefaultMethodLimits() map[string]MethodLimit {
+	return map[string]MethodLimit{
+		trip.TripService_PreviewTrip_FullMethodName: {
+			PerUser: ratelimit.Limit{Rate: 1, Burst: 5},
+			PerIP:   ratelimit.Limit{Rate: 5, Burst: 20},
+		},
+		trip.TripService_CreateTrip_FullMethodName: {
+			PerUser: ratelimit.Limit{Rate: 0.5, Burst: 3},
+			PerIP:   ratelimit.Limit{Rate: 2, Burst: 10},
+		},
+	}
+}
+
+// ParseMethodLimits parses "method=scope:rate:burst,...;method=..." where the
+// scope is user or ip. Methods without a leading slash belong to the trip
+// service.
+func ParseMethodLimits(s string) (map[string]MethodLimit, error) {
+	limits := map[string]MethodLimit{}
+
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		method, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q: expected method=scope:rate:burst", entry)
+		}
+		method = strings.TrimSpace(method)
+		if !strings.HasPrefix(method, "/") {
+			method = "/" + trip.TripService_ServiceDesc.ServiceName + "/" + method
+		}
+
+		var limit MethodLimit
+		for _, part := range strings.Split(spec, ",") {
+			scope, value, ok := strings.Cut(strings.TrimSpace(part), ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid rate limit %q for %s", part, method)
+			}
+
+			l, err := ratelimit.ParseLimit(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rate limit for %s: %w", method, err)
+			}
+
+			switch scope {
+			case "user":
+				limit.PerUser = l
+			case "ip":
+				limit.PerIP = l
+			default:
+				return nil, fmt.Errorf("unknown rate limit scope %q for %s", scope, method)
+			}
+		}
+		limits[method] = limit
+	}
+
+	return limits, nil
+}
+
+// UnaryRateLimitInterceptor rejects calls over their per-user or per-IP
+// limit with ResourceExhausted and a retry delay. It runs after
+// authentication so the principal is known. When the limiter itself fails,
+// calls are let through rather than taking the API down with it.
+func UnaryRateLimitInterceptor(limiter ratelimit.Limiter, cfg *RateLimitConfig, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		limit, ok := cfg.Methods[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		var buckets []ratelimit.Bucket
+		if principal, ok := domain.PrincipalFromContext(ctx); ok && !limit.PerUser.IsZero() {
+			buckets = append(buckets, ratelimit.Bucket{Key: bucketKey("user", info.FullMethod, principal.ID), Limit: limit.PerUser})
+		}
+		if ip := clientIP(ctx, cfg.TrustForwardedFor); ip != "" && !limit.PerIP.IsZero() {
+			buckets = append(buckets, ratelimit.Bucket{Key: bucketKey("ip", info.FullMethod, ip), Limit: limit.PerIP})
+		}
+
+		if len(buckets) > 0 {
+			if err := takeToken(ctx, limiter, logger, buckets); err != nil {
+				return nil, err
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// bucketKey names the bucket of one user or IP for a method. The method is
+// the Redis Cluster hash tag, so the buckets of a call share a slot.
+func bucketKey(kind, method, id string) string {
+	return kind + ":{" + method + "}:" + id
+}
+
+// takeToken spends a token from every bucket, or from none of them when
+// one is empty
+func takeToken(ctx context.Context, limiter ratelimit.Limiter, logger *slog.Logger, buckets []ratelimit.Bucket) error {
+	allowed, retryAfter, err := limiter.Allow(ctx, buckets...)
+	if err != nil {
+		logger.WarnContext(ctx, "Rate limiter failed, allowing the call", "error", err)
+		return nil
+	}
+
+	if !allowed {
+		return &retryAfterError{err: domain.ErrRateLimited, delay: retryAfter}
+	}
+	return nil
+}
+
+func clientIP(ctx context.Context, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded := firstMetadata(ctx, forwardedForKey); forwarded != "" {
+			ip, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(ip)
+		}
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,223 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ride4Low/contracts/proto/trip"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/logging"
+	"github.com/ride4Low/trip-service/internal/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// failingLimiter simulates an unreachable Redis
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, buckets ...ratelimit.Bucket) (bool, time.Duration, error) {
+	return false, 0, errors.New("redis: connection refused")
+}
+
+// recordingLimiter records the buckets of each call
+type recordingLimiter struct {
+	buckets []ratelimit.Bucket
+}
+
+func (l *recordingLimiter) Allow(ctx context.Context, buckets ...ratelimit.Bucket) (bool, time.Duration, error) {
+	l.buckets = append(l.buckets, buckets...)
+	return true, 0, nil
+}
+
+func peerContext(ip string) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 50000},
+	})
+}
+
+func TestUnaryRateLimitInterceptor(t *testing.T) {
+	cfg := &RateLimitConfig{
+		Methods: map[string]MethodLimit{
+			trip.TripService_PreviewTrip_FullMethodName: {
+				PerUser: ratelimit.Limit{Rate: 1, Burst: 1},
+				PerIP:   ratelimit.Limit{Rate: 1, Burst: 2},
+			},
+		},
+	}
+	preview := &grpc.UnaryServerInfo{FullMethod: trip.TripService_PreviewTrip_FullMethodName}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	t.Run("per user limit", func(t *testing.T) {
+		// Setup
+		interceptor := UnaryRateLimitInterceptor(ratelimit.NewMemoryLimiter(), cfg, logging.Discard())
+		ctx := domain.WithPrincipal(peerContext("10.0.0.1"), &domain.Principal{ID: "user-123"})
+
+		// Execute
+		if _, err := interceptor(ctx, nil, preview, handler); err != nil {
+			t.Fatalf("expected the first call to be allowed, got %v", err)
+		}
+		_, err := interceptor(ctx, nil, preview, handler)
+
+		// Verify
+		if !errors.Is(err, domain.ErrRateLimited) {
+			t.Fatalf("expected ErrRateLimited, got %v", err)
+		}
+
+		st := status.Convert(toStatus(err))
+		if st.Code() != codes.ResourceExhausted {
+			t.Errorf("expected ResourceExhausted, got %v", st.Code())
+		}
+		var retry *errdetails.RetryInfo
+		for _, detail := range st.Details() {
+			if d, ok := detail.(*errdetails.RetryInfo); ok {
+				retry = d
+			}
+		}
+		if retry == nil || retry.RetryDelay.AsDuration() <= 0 || retry.RetryDelay.AsDuration() > time.Second {
+			t.Errorf("expected a retry delay of at most a second, got %v", retry)
+		}
+	})
+
+	t.Run("per ip limit", func(t *testing.T) {
+		// Setup
+		interceptor := UnaryRateLimitInterceptor(ratelimit.NewMemoryLimiter(), cfg, logging.Discard())
+
+		// Execute
+		var err error
+		for i := 0; i < 3; i++ {
+			_, err = interceptor(peerContext("10.0.0.2"), nil, preview, handler)
+		}
+
+		// Verify
+		if !errors.Is(err, domain.ErrRateLimited) {
+			t.Errorf("expected ErrRateLimited on the third anonymous call, got %v", err)
+		}
+		if _, err := interceptor(peerContext("10.0.0.3"), nil, preview, handler); err != nil {
+			t.Errorf("expected another ip to be allowed, got %v", err)
+		}
+	})
+
+	t.Run("calls over the ip limit spend no user token", func(t *testing.T) {
+		// Setup
+		limits := &RateLimitConfig{
+			Methods: map[string]MethodLimit{
+				trip.TripService_PreviewTrip_FullMethodName: {
+					PerUser: ratelimit.Limit{Rate: 1, Burst: 2},
+					PerIP:   ratelimit.Limit{Rate: 1, Burst: 1},
+				},
+			},
+		}
+		interceptor := UnaryRateLimitInterceptor(ratelimit.NewMemoryLimiter(), limits, logging.Discard())
+		principal := &domain.Principal{ID: "user-456"}
+
+		// Execute
+		if _, err := interceptor(domain.WithPrincipal(peerContext("10.0.0.6"), principal), nil, preview, handler); err != nil {
+			t.Fatalf("expected the first call to be allowed, got %v", err)
+		}
+		if _, err := interceptor(domain.WithPrincipal(peerContext("10.0.0.6"), principal), nil, preview, handler); !errors.Is(err, domain.ErrRateLimited) {
+			t.Fatalf("expected the second call from the ip to be rejected, got %v", err)
+		}
+		_, err := interceptor(domain.WithPrincipal(peerContext("10.0.0.7"), principal), nil, preview, handler)
+
+		// Verify
+		if err != nil {
+			t.Errorf("expected the user to have a token left, got %v", err)
+		}
+	})
+
+	t.Run("forwarded for", func(t *testing.T) {
+		// Setup
+		trusted := *cfg
+		trusted.TrustForwardedFor = true
+		interceptor := UnaryRateLimitInterceptor(ratelimit.NewMemoryLimiter(), &trusted, logging.Discard())
+
+		// Execute
+		for i := 0; i < 2; i++ {
+			ctx := incomingContext(forwardedForKey, "203.0.113.7, 10.0.0.1")
+			if _, err := interceptor(ctx, nil, preview, handler); err != nil {
+				t.Fatalf("expected call %d to be allowed, got %v", i+1, err)
+			}
+		}
+		_, err := interceptor(incomingContext(forwardedForKey, "198.51.100.1"), nil, preview, handler)
+
+		// Verify
+		if err != nil {
+			t.Errorf("expected another forwarded ip to be allowed, got %v", err)
+		}
+	})
+
+	t.Run("methods without limits", func(t *testing.T) {
+		interceptor := UnaryRateLimitInterceptor(ratelimit.NewMemoryLimiter(), cfg, logging.Discard())
+		info := &grpc.UnaryServerInfo{FullMethod: "/trip.TripService/GetTripTimeline"}
+		for i := 0; i < 5; i++ {
+			if _, err := interceptor(peerContext("10.0.0.4"), nil, info, handler); err != nil {
+				t.Fatalf("expected no limit, got %v", err)
+			}
+		}
+	})
+
+	t.Run("bucket keys share the method hash tag", func(t *testing.T) {
+		// Setup
+		limiter := &recordingLimiter{}
+		interceptor := UnaryRateLimitInterceptor(limiter, cfg, logging.Discard())
+		ctx := domain.WithPrincipal(peerContext("10.0.0.8"), &domain.Principal{ID: "user-789"})
+
+		// Execute
+		if _, err := interceptor(ctx, nil, preview, handler); err != nil {
+			t.Fatalf("expected the call to be allowed, got %v", err)
+		}
+
+		// Verify
+		tag := "{" + trip.TripService_PreviewTrip_FullMethodName + "}"
+		want := []string{"user:" + tag + ":user-789", "ip:" + tag + ":10.0.0.8"}
+		if len(limiter.buckets) != len(want) {
+			t.Fatalf("expected %d buckets, got %+v", len(want), limiter.buckets)
+		}
+		for i, key := range want {
+			if limiter.buckets[i].Key != key {
+				t.Errorf("expected key %q, got %q", key, limiter.buckets[i].Key)
+			}
+		}
+	})
+
+	t.Run("limiter failures fail open", func(t *testing.T) {
+		interceptor := UnaryRateLimitInterceptor(failingLimiter{}, cfg, logging.Discard())
+		if _, err := interceptor(peerContext("10.0.0.5"), nil, preview, handler); err != nil {
+			t.Errorf("expected the call to be allowed, got %v", err)
+		}
+	})
+}
+
+func TestParseMethodLimits(t *testing.T) {
+	t.Run("valid limits", func(t *testing.T) {
+		limits, err := ParseMethodLimits("PreviewTrip=user:2:10,ip:5:20; /trip.TripService/CreateTrip=user:0.5:3")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		preview := limits[trip.TripService_PreviewTrip_FullMethodName]
+		if preview.PerUser != (ratelimit.Limit{Rate: 2, Burst: 10}) || preview.PerIP != (ratelimit.Limit{Rate: 5, Burst: 20}) {
+			t.Errorf("unexpected PreviewTrip limits: %+v", preview)
+		}
+		create := limits[trip.TripService_CreateTrip_FullMethodName]
+		if create.PerUser != (ratelimit.Limit{Rate: 0.5, Burst: 3}) || !create.PerIP.IsZero() {
+			t.Errorf("unexpected CreateTrip limits: %+v", create)
+		}
+	})
+
+	t.Run("invalid limits", func(t *testing.T) {
+		for _, s := range []string{"PreviewTrip", "PreviewTrip=user", "PreviewTrip=host:1:1", "PreviewTrip=user:x:1"} {
+			if _, err := ParseMethodLimits(s); err == nil {
+				t.Errorf("expected %q to be rejected", s)
+			}
+		}
+	})
+}
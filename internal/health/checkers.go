@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// MongoChecker pings the primary
+func MongoChecker(client *mongo.Client) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		if err := client.Ping(ctx, readpref.Primary()); err != nil {
+			return fmt.Errorf("failed to ping MongoDB: %w", err)
+		}
+		return nil
+	})
+}
+
+// RabbitMQChecker reports whether the channel returned by channel is open.
+// The channel is looked up on every check as it is replaced on reconnect.
+func RabbitMQChecker(channel func() *amqp.Channel) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		ch := channel()
+		if ch == nil || ch.IsClosed() {
+			return errors.New("RabbitMQ channel is closed")
+		}
+		return nil
+	})
+}
+
+// Pinger is a dependency that can tell whether it is reachable
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingChecker checks a dependency by pinging it
+func PingChecker(pinger Pinger) Checker {
+	return CheckerFunc(pinger.Ping)
+}
+
+// ErrStarting is reported until the dependencies are connected
+var ErrStarting = errors.New("connecting to dependencies")
+
+// Startup keeps the service unready until Done is called, while it connects
+// to its dependencies. Its zero value is ready to use.
+type Startup struct {
+	done atomic.Bool
+}
+
+// Done reports the dependencies connected
+func (s *Startup) Done() {
+	s.done.Store(true)
+}
+
+func (s *Startup) Check(ctx context.Context) error {
+	if !s.done.Load() {
+		return ErrStarting
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// DefaultTimeout bounds each readiness check
+const DefaultTimeout = 2 * time.Second
+
+// ErrShuttingDown is reported by readiness once shutdown has started
+var ErrShuttingDown = errors.New("shutting down")
+
+// Checker reports whether a dependency can be used
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to a Checker
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result is the outcome of one readiness check, empty when it passed
+type Result struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Health runs the readiness checks of the service and reports them on HTTP
+// and on the gRPC health-checking protocol. The process is live as long as it
+// answers; it is ready when every check passes and it is not shutting down.
+type Health struct {
+	mu       sync.RWMutex
+	checkers map[string]Checker
+
+	grpc         *health.Server
+	services     []string
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+// Option configures the health checks
+type Option func(*Health)
+
+// WithTimeout bounds each check
+func WithTimeout(timeout time.Duration) Option {
+	return func(h *Health) {
+		h.timeout = timeout
+	}
+}
+
+// New reports the readiness of the whole server and of the named gRPC
+// services. Until the first update every service is NOT_SERVING.
+func New(services []string, opts ...Option) *Health {
+	h := &Health{
+		checkers: map[string]Checker{},
+		grpc:     health.NewServer(),
+		services: append([]string{""}, services...),
+		timeout:  DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.setServing(healthpb.HealthCheckResponse_NOT_SERVING)
+	return h
+}
+
+// Register adds a readiness check
+func (h *Health) Register(name string, checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checkers[name] = checker
+}
+
+// GRPCServer is the grpc.health.v1 service to register on the gRPC server
+func (h *Health) GRPCServer() healthpb.HealthServer {
+	return h.grpc
+}
+
+// Check runs every check concurrently and returns their results by name
+func (h *Health) Check(ctx context.Context) (map[string]Result, bool) {
+	h.mu.RLock()
+	checkers := make(map[string]Checker, len(h.checkers))
+	for name, checker := range h.checkers {
+		checkers[name] = checker
+	}
+	h.mu.RUnlock()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]Result, len(checkers))
+		ready   = !h.shuttingDown.Load()
+	)
+
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func(name string, checker Checker) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+
+			result := Result{Status: "ok"}
+			if err := checker.Check(ctx); err != nil {
+				result = Result{Status: "failed", Error: err.Error()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = result
+			if result.Error != "" {
+				ready = false
+			}
+		}(name, checker)
+	}
+	wg.Wait()
+
+	return results, ready
+}
+
+// Update runs the checks and publishes the outcome on the gRPC health service
+func (h *Health) Update(ctx context.Context) bool {
+	_, ready := h.Check(ctx)
+
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if ready {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	h.setServing(status)
+
+	return ready
+}
+
+// Run updates the gRPC health status every interval until ctx is done
+func (h *Health) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		h.Update(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown reports NOT_SERVING from now on, so load balancers stop sending
+// new requests while in-flight ones drain
+func (h *Health) Shutdown() {
+	h.shuttingDown.Store(true)
+	h.grpc.Shutdown()
+}
+
+func (h *Health) setServing(status healthpb.HealthCheckResponse_ServingStatus) {
+	for _, service := range h.services {
+		h.grpc.SetServingStatus(service, status)
+	}
+}
+
+// LiveHandler answers /healthz: the process is up and serving HTTP
+func (h *Health) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+	})
+}
+
+// ReadyHandler answers /readyz with the result of every check
+func (h *Health) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		results, ready := h.Check(r.Context())
+
+		status, code := "ok", http.StatusOK
+		if !ready {
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+
+		body := map[string]interface{}{"status": status, "checks": results}
+		if h.shuttingDown.Load() {
+			body["error"] = ErrShuttingDown.Error()
+		}
+		writeJSON(w, code, body)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	data, err := sonic.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+}
@@ -0,0 +1,128 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func healthy(ctx context.Context) error { return nil }
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		checkers   map[string]CheckerFunc
+		shutdown   bool
+		wantCode   int
+		wantStatus healthpb.HealthCheckResponse_ServingStatus
+		wantBody   string
+	}{
+		{
+			name:       "all checks pass",
+			checkers:   map[string]CheckerFunc{"mongo": healthy, "rabbitmq": healthy},
+			wantCode:   http.StatusOK,
+			wantStatus: healthpb.HealthCheckResponse_SERVING,
+			wantBody:   `"mongo":{"status":"ok"}`,
+		},
+		{
+			name: "a check fails",
+			checkers: map[string]CheckerFunc{"mongo": healthy, "osrm": func(ctx context.Context) error {
+				return errors.New("OSRM is unreachable")
+			}},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: healthpb.HealthCheckResponse_NOT_SERVING,
+			wantBody:   `"osrm":{"status":"failed","error":"OSRM is unreachable"}`,
+		},
+		{
+			name: "a check times out",
+			checkers: map[string]CheckerFunc{"mongo": func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: healthpb.HealthCheckResponse_NOT_SERVING,
+			wantBody:   `context deadline exceeded`,
+		},
+		{
+			name:       "still connecting",
+			checkers:   map[string]CheckerFunc{"startup": (&Startup{}).Check},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: healthpb.HealthCheckResponse_NOT_SERVING,
+			wantBody:   `"startup":{"status":"failed","error":"connecting to dependencies"}`,
+		},
+		{
+			name:       "shutting down",
+			checkers:   map[string]CheckerFunc{"mongo": healthy},
+			shutdown:   true,
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: healthpb.HealthCheckResponse_NOT_SERVING,
+			wantBody:   `"error":"shutting down"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			h := New([]string{"trip.TripService"}, WithTimeout(10*time.Millisecond))
+			for name, checker := range tt.checkers {
+				h.Register(name, checker)
+			}
+			if tt.shutdown {
+				h.Shutdown()
+			}
+
+			// Execute
+			h.Update(context.Background())
+			rec := httptest.NewRecorder()
+			h.ReadyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			// Verify
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if body := rec.Body.String(); !strings.Contains(body, tt.wantBody) {
+				t.Errorf("expected body to contain %s, got %s", tt.wantBody, body)
+			}
+			for _, service := range []string{"", "trip.TripService"} {
+				resp, err := h.GRPCServer().Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if resp.Status != tt.wantStatus {
+					t.Errorf("expected %q to be %v, got %v", service, tt.wantStatus, resp.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	h := New(nil)
+	h.Register("mongo", CheckerFunc(func(ctx context.Context) error { return errors.New("down") }))
+	h.Shutdown()
+
+	rec := httptest.NewRecorder()
+	h.LiveHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected liveness to ignore dependencies, got %d", rec.Code)
+	}
+}
+
+func TestNotServingBeforeFirstUpdate(t *testing.T) {
+	h := New(nil)
+
+	resp, err := h.GRPCServer().Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected NOT_SERVING, got %v", resp.Status)
+	}
+}
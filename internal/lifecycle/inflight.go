@@ -0,0 +1,63 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+)
+
+// Inflight counts the work in progress, such as message handlers or
+// publishes, so shutdown can wait for it. Its zero value is ready to use.
+type Inflight struct {
+	mu      sync.Mutex
+	count   int
+	closing bool
+	idle    chan struct{}
+}
+
+// Acquire starts a unit of work. It returns false once Wait was called,
+// when no new work must start.
+func (i *Inflight) Acquire() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.closing {
+		return false
+	}
+	i.count++
+	return true
+}
+
+// Release ends a unit of work started by Acquire
+func (i *Inflight) Release() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.count--
+	if i.count == 0 && i.idle != nil {
+		close(i.idle)
+		i.idle = nil
+	}
+}
+
+// Wait refuses new work, then blocks until the work in progress is done or
+// ctx is done
+func (i *Inflight) Wait(ctx context.Context) error {
+	i.mu.Lock()
+	i.closing = true
+	if i.count == 0 {
+		i.mu.Unlock()
+		return nil
+	}
+	if i.idle == nil {
+		i.idle = make(chan struct{})
+	}
+	idle := i.idle
+	i.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,78 @@
+// Package lifecycle stops the components of the service in order when it
+// shuts down.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrTimeout is reported for a component that did not stop within its
+// timeout
+var ErrTimeout = errors.New("did not stop in time")
+
+// StopFunc stops a component. It should give up when ctx is done.
+type StopFunc func(ctx context.Context) error
+
+type component struct {
+	name    string
+	timeout time.Duration
+	stop    StopFunc
+}
+
+// Manager stops the registered components one after another, in the order
+// they were added, so each one can rely on those before it having stopped
+type Manager struct {
+	components []component
+	logger     *slog.Logger
+}
+
+func New(logger *slog.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// Add registers a component to stop on Shutdown within timeout
+func (m *Manager) Add(name string, timeout time.Duration, stop StopFunc) {
+	m.components = append(m.components, component{name: name, timeout: timeout, stop: stop})
+}
+
+// Shutdown stops every component, even when one before it failed or timed
+// out, and reports all those that did not stop cleanly. A component still
+// running past its timeout is left behind and wrapped in ErrTimeout.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, c := range m.components {
+		started := time.Now()
+		if err := m.stop(ctx, c); err != nil {
+			m.logger.ErrorContext(ctx, "Failed to stop component", "component", c.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+			continue
+		}
+		m.logger.InfoContext(ctx, "Stopped component", "component", c.name, "duration", time.Since(started))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *Manager) stop(ctx context.Context, c component) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("%w after %s", ErrTimeout, c.timeout)
+	}
+}
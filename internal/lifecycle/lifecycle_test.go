@@ -0,0 +1,105 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ride4Low/trip-service/internal/logging"
+)
+
+func TestManagerShutdown(t *testing.T) {
+	// Setup
+	var stopped []string
+	record := func(name string) StopFunc {
+		return func(ctx context.Context) error {
+			stopped = append(stopped, name)
+			return nil
+		}
+	}
+
+	m := New(logging.Discard())
+	m.Add("grpc", time.Second, record("grpc"))
+	m.Add("consumers", 10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	m.Add("publisher", 10*time.Millisecond, func(ctx context.Context) error {
+		// Ignores its context
+		time.Sleep(time.Second)
+		return nil
+	})
+	m.Add("mongo", time.Second, func(ctx context.Context) error {
+		stopped = append(stopped, "mongo")
+		return errors.New("connection reset")
+	})
+	m.Add("telemetry", time.Second, record("telemetry"))
+
+	// Execute
+	err := m.Shutdown(context.Background())
+
+	// Verify
+	if strings.Join(stopped, ",") != "grpc,mongo,telemetry" {
+		t.Errorf("expected every component to stop in order, got %v", stopped)
+	}
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+	for _, want := range []string{"consumers: did not stop in time", "publisher: did not stop in time", "mongo: connection reset"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in the error, got %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "grpc") || strings.Contains(err.Error(), "telemetry") {
+		t.Errorf("expected only the failed components to be reported, got %v", err)
+	}
+}
+
+func TestInflight(t *testing.T) {
+	t.Run("waits for the work in progress", func(t *testing.T) {
+		var inflight Inflight
+		if !inflight.Acquire() {
+			t.Fatal("expected work to start before Wait")
+		}
+
+		released := make(chan struct{})
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			close(released)
+			inflight.Release()
+		}()
+
+		if err := inflight.Wait(context.Background()); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		select {
+		case <-released:
+		default:
+			t.Error("expected Wait to return after the work was released")
+		}
+		if inflight.Acquire() {
+			t.Error("expected new work to be refused once waited on")
+		}
+	})
+
+	t.Run("gives up when the context is done", func(t *testing.T) {
+		var inflight Inflight
+		inflight.Acquire()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if err := inflight.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("nothing in progress", func(t *testing.T) {
+		var inflight Inflight
+
+		if err := inflight.Wait(context.Background()); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,16 @@
+package logging
+
+import "log/slog"
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type Config struct {
+	Level slog.Level
+	// Format is json in production, text is easier to read locally
+	Format string
+	// CoordinatePrecision is the number of decimals coordinates keep in logs
+	CoordinatePrecision int
+}
@@ -0,0 +1,133 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestLogger(level slog.Level) (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return New(&buf, &Config{Level: level, Format: FormatJSON, CoordinatePrecision: 3}), &buf
+}
+
+func decodeLine(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+
+	var line map[string]interface{}
+	if err := sonic.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("failed to decode %q: %v", buf.String(), err)
+	}
+	return line
+}
+
+func TestContextFields(t *testing.T) {
+	// Setup
+	logger, buf := newTestLogger(slog.LevelInfo)
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	ctx = With(ctx, "user_id", "user-1")
+	ctx = With(ctx, "trip_id", "trip-1")
+
+	// Execute
+	logger.InfoContext(ctx, "Trip updated")
+
+	// Verify
+	line := decodeLine(t, buf)
+	expected := map[string]string{
+		"msg":      "Trip updated",
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":  "00f067aa0ba902b7",
+		"user_id":  "user-1",
+		"trip_id":  "trip-1",
+	}
+	for key, value := range expected {
+		if line[key] != value {
+			t.Errorf("expected %s to be %q, got %v", key, value, line[key])
+		}
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []any
+		expected string
+		leaked   string
+	}{
+		{
+			name:     "tokens",
+			args:     []any{"access_token", "eyJhbGciOiJIUzI1NiJ9.payload.sig"},
+			expected: `"access_token":"[REDACTED]"`,
+			leaked:   "eyJhbGciOiJIUzI1NiJ9",
+		},
+		{
+			name:     "authorization header",
+			args:     []any{"Authorization", "Bearer abc"},
+			expected: `"Authorization":"[REDACTED]"`,
+			leaked:   "Bearer abc",
+		},
+		{
+			name:     "latitude",
+			args:     []any{"lat", 13.7563309},
+			expected: `"lat":13.756`,
+			leaked:   "13.7563309",
+		},
+		{
+			name:     "coordinate",
+			args:     []any{"pickup", types.Coordinate{Latitude: 13.7563309, Longitude: 100.5017651}},
+			expected: `"pickup":{"latitude":13.756,"longitude":100.501}`,
+			leaked:   "100.5017651",
+		},
+		{
+			name:     "receipt coordinate",
+			args:     []any{"dropoff", &domain.Coordinate{Latitude: 13.7563309, Longitude: 100.5017651}},
+			expected: `"dropoff":{"latitude":13.756,"longitude":100.501}`,
+			leaked:   "13.7563309",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			logger, buf := newTestLogger(slog.LevelInfo)
+
+			// Execute
+			logger.Info("Redacted", tt.args...)
+
+			// Verify
+			out := buf.String()
+			if !strings.Contains(out, tt.expected) {
+				t.Errorf("expected %s in %s", tt.expected, out)
+			}
+			if strings.Contains(out, tt.leaked) {
+				t.Errorf("expected %s to be redacted from %s", tt.leaked, out)
+			}
+		})
+	}
+}
+
+func TestLevel(t *testing.T) {
+	logger, buf := newTestLogger(slog.LevelWarn)
+
+	logger.Info("Dropped")
+	if buf.Len() != 0 {
+		t.Fatalf("expected info to be dropped at warn level, got %s", buf.String())
+	}
+
+	logger.Warn("Kept")
+	if line := decodeLine(t, buf); line["level"] != "WARN" {
+		t.Errorf("expected a WARN line, got %v", line["level"])
+	}
+}
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/privacy"
+)
+
+const redacted = "[REDACTED]"
+
+// secretKeys are parts of attribute names whose values are never logged
+var secretKeys = []string{"token", "authorization", "password", "secret", "jwt", "api_key"}
+
+// coordinateKeys are attribute names holding a single latitude or longitude
+var coordinateKeys = map[string]bool{
+	"lat":       true,
+	"latitude":  true,
+	"lng":       true,
+	"lon":       true,
+	"longitude": true,
+}
+
+// protoCoordinate is a coordinate of the gRPC API
+type protoCoordinate interface {
+	GetLatitude() float64
+	GetLongitude() float64
+}
+
+// redactor hides credentials and truncates coordinates to precision
+// decimals, whether they are logged as numbers or as coordinate values
+func redactor(precision int) func(groups []string, attr slog.Attr) slog.Attr {
+	return func(groups []string, attr slog.Attr) slog.Attr {
+		key := strings.ToLower(attr.Key)
+		for _, secret := range secretKeys {
+			if strings.Contains(key, secret) {
+				return slog.String(attr.Key, redacted)
+			}
+		}
+
+		if coordinateKeys[key] && attr.Value.Kind() == slog.KindFloat64 {
+			return slog.Float64(attr.Key, privacy.Fuzz(attr.Value.Float64(), precision))
+		}
+
+		if attr.Value.Kind() != slog.KindAny {
+			return attr
+		}
+
+		switch c := attr.Value.Any().(type) {
+		case types.Coordinate:
+			return coordinate(attr.Key, c.Latitude, c.Longitude, precision)
+		case *types.Coordinate:
+			if c != nil {
+				return coordinate(attr.Key, c.Latitude, c.Longitude, precision)
+			}
+		case domain.Coordinate:
+			return coordinate(attr.Key, c.Latitude, c.Longitude, precision)
+		case *domain.Coordinate:
+			if c != nil {
+				return coordinate(attr.Key, c.Latitude, c.Longitude, precision)
+			}
+		case protoCoordinate:
+			return coordinate(attr.Key, c.GetLatitude(), c.GetLongitude(), precision)
+		}
+
+		return attr
+	}
+}
+
+func coordinate(key string, latitude, longitude float64, precision int) slog.Attr {
+	return slog.Group(key,
+		slog.Float64("latitude", privacy.Fuzz(latitude, precision)),
+		slog.Float64("longitude", privacy.Fuzz(longitude, precision)),
+	)
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+type routeProvider struct {
+	next    domain.RouteProvider
+	metrics *Metrics
+}
+
+// NewRouteProvider measures the latency and errors of the routes of next
+func NewRouteProvider(next domain.RouteProvider, m *Metrics) domain.RouteProvider {
+	return &routeProvider{next: next, metrics: m}
+}
+
+func (p *routeProvider) GetRoute(ctx context.Context, pickup, dropoff types.Coordinate) (*types.OsrmApiResponse, error) {
+	start := time.Now()
+	route, err := p.next.GetRoute(ctx, pickup, dropoff)
+	p.metrics.osrmDuration.WithLabelValues(result(err)).Observe(since(start))
+	return route, err
+}
+
+// CommandMonitor measures the latency of MongoDB commands, to be set on the
+// client options
+func (m *Metrics) CommandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			m.mongoDuration.WithLabelValues(e.CommandName, "ok").Observe(e.Duration.Seconds())
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			m.mongoDuration.WithLabelValues(e.CommandName, "error").Observe(e.Duration.Seconds())
+		},
+	}
+}
+
+// MessageHandler handles a delivered AMQP message
+type MessageHandler interface {
+	Handle(ctx context.Context, msg amqp.Delivery) error
+}
+
+type messageHandler struct {
+	next    MessageHandler
+	metrics *Metrics
+}
+
+// NewMessageHandler counts the messages handled by next and how long they
+// waited in the queue
+func NewMessageHandler(next MessageHandler, m *Metrics) MessageHandler {
+	return &messageHandler{next: next, metrics: m}
+}
+
+func (h *messageHandler) Handle(ctx context.Context, msg amqp.Delivery) error {
+	if !msg.Timestamp.IsZero() {
+		h.metrics.consumerLag.WithLabelValues(msg.RoutingKey).Observe(since(msg.Timestamp))
+	}
+
+	err := h.next.Handle(ctx, msg)
+	h.metrics.messages.WithLabelValues(msg.RoutingKey, result(err)).Inc()
+	return err
+}
@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/privacy"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const namespace = "trip"
+
+// DefaultZonePrecision groups pickups in cells of 1 decimal, about 11 km
+const DefaultZonePrecision = 1
+
+type Config struct {
+	// Addr is the address of the HTTP server exposing /metrics
+	Addr          string
+	ZonePrecision int
+}
+
+// Metrics holds the Prometheus collectors of the trip service. The
+// preview-to-booking conversion is the rate of trips created over the rate
+// of previews.
+type Metrics struct {
+	trips         *prometheus.CounterVec
+	previews      *prometheus.CounterVec
+	quotePrice    *prometheus.HistogramVec
+	timeToAccept  prometheus.Histogram
+	osrmDuration  *prometheus.HistogramVec
+	mongoDuration *prometheus.HistogramVec
+	consumerLag   *prometheus.HistogramVec
+	messages      *prometheus.CounterVec
+
+	zonePrecision int
+}
+
+// Option configures the metrics
+type Option func(*Metrics)
+
+// WithZonePrecision sets the decimals pickups are truncated to for the zone
+// label. More decimals mean more, smaller zones and more time series.
+func WithZonePrecision(precision int) Option {
+	return func(m *Metrics) {
+		m.zonePrecision = precision
+	}
+}
+
+// New creates the collectors and registers them with reg
+func New(reg prometheus.Registerer, opts ...Option) *Metrics {
+	m := &Metrics{
+		trips: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "trips_total",
+			Help:      "Trips created and moved to a new status, by package and pickup zone.",
+		}, []string{"event", "package", "zone"}),
+		previews: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "previews_total",
+			Help:      "Trip previews, by whether an earlier quote was reused.",
+		}, []string{"reused"}),
+		quotePrice: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "quote_price_cents",
+			Help:      "Prices of newly quoted fares in cents, by package.",
+			Buckets:   prometheus.ExponentialBuckets(500, 1.5, 12),
+		}, []string{"package"}),
+		timeToAccept: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "dispatch_time_to_accept_seconds",
+			Help:      "Time from the creation of a trip to its acceptance by a driver.",
+			Buckets:   []float64{5, 10, 20, 30, 45, 60, 90, 120, 180, 300, 600},
+		}),
+		osrmDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "osrm_request_duration_seconds",
+			Help:      "Latency of route requests, by result.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"result"}),
+		mongoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "mongo_command_duration_seconds",
+			Help:      "Latency of MongoDB commands, by command and result.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"command", "result"}),
+		consumerLag: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "amqp_consumer_lag_seconds",
+			Help:      "Time from publishing a message to handling it, by routing key. Messages without a timestamp are not measured.",
+			Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300},
+		}, []string{"routing_key"}),
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "amqp_messages_total",
+			Help:      "Handled AMQP messages, by routing key and result.",
+		}, []string{"routing_key", "result"}),
+		zonePrecision: DefaultZonePrecision,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	reg.MustRegister(
+		m.trips, m.previews, m.quotePrice, m.timeToAccept,
+		m.osrmDuration, m.mongoDuration, m.consumerLag, m.messages,
+	)
+
+	return m
+}
+
+// Handler serves the metrics gathered by g in the Prometheus text format
+func Handler(g prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(g, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) QuoteCreated(fares []*types.RideFare, reused bool) {
+	m.previews.WithLabelValues(fmt.Sprint(reused)).Inc()
+	if reused {
+		return
+	}
+
+	for _, fare := range fares {
+		m.quotePrice.WithLabelValues(fare.PackageSlug).Observe(fare.TotalPriceInCents)
+	}
+}
+
+func (m *Metrics) TripEventRecorded(event *domain.TripEvent) {
+	label := tripEventLabel(event)
+	if label == "" {
+		return
+	}
+
+	packageSlug, zone := "unknown", "unknown"
+	if event.After != nil && event.After.RideFare != nil {
+		packageSlug = event.After.RideFare.PackageSlug
+		zone = m.zone(event.After.RideFare.Route)
+	}
+	m.trips.WithLabelValues(label, packageSlug, zone).Inc()
+
+	// Trip IDs are ObjectIDs, which carry the creation time of the trip
+	if event.ToStatus == domain.TripStatusAccepted {
+		if tripID, err := primitive.ObjectIDFromHex(event.TripID); err == nil && !event.CreatedAt.IsZero() {
+			m.timeToAccept.Observe(event.CreatedAt.Sub(tripID.Timestamp()).Seconds())
+		}
+	}
+}
+
+// tripEventLabel names a trip event for the trips counter: created, or the
+// status the trip moved to, with paid trips counted as completed
+func tripEventLabel(event *domain.TripEvent) string {
+	switch {
+	case event.Type == domain.TripEventCreated:
+		return "created"
+	case event.ToStatus == domain.TripStatusPaid:
+		return "completed"
+	default:
+		return event.ToStatus
+	}
+}
+
+// zone is the pickup of the route truncated to the zone precision
+func (m *Metrics) zone(route *types.OsrmApiResponse) string {
+	if route == nil || len(route.Routes) == 0 {
+		return "unknown"
+	}
+
+	coords := route.Routes[0].Geometry.Coordinates
+	if len(coords) == 0 || len(coords[0]) < 2 {
+		return "unknown"
+	}
+
+	return fmt.Sprintf("%.*f,%.*f",
+		m.zonePrecision, privacy.Fuzz(coords[0][1], m.zonePrecision),
+		m.zonePrecision, privacy.Fuzz(coords[0][0], m.zonePrecision))
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+func since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func testTrip() *types.Trip {
+	return &types.Trip{
+		RideFare: &types.RideFare{PackageSlug: "suv", Route: testRoute()},
+	}
+}
+
+func testRoute() *types.OsrmApiResponse {
+	var route types.OsrmApiResponse
+	route.Routes = make([]struct {
+		Distance float64 `json:"distance"`
+		Duration float64 `json:"duration"`
+		Geometry struct {
+			Coordinates [][]float64 `json:"coordinates"`
+		} `json:"geometry"`
+	}, 1)
+	route.Routes[0].Geometry.Coordinates = [][]float64{{100.56789, 13.98765}, {100.6, 13.9}}
+	return &route
+}
+
+func TestTripEventRecorded(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+	tripID := primitive.NewObjectIDFromTimestamp(time.Now().Add(-30 * time.Second))
+
+	m.TripEventRecorded(&domain.TripEvent{TripID: tripID.Hex(), Type: domain.TripEventCreated, ToStatus: domain.TripStatusPending, After: testTrip()})
+	m.TripEventRecorded(&domain.TripEvent{TripID: tripID.Hex(), Type: domain.TripEventStatusChanged, ToStatus: domain.TripStatusAccepted, After: testTrip(), CreatedAt: time.Now()})
+	m.TripEventRecorded(&domain.TripEvent{TripID: tripID.Hex(), Type: domain.TripEventStatusChanged, ToStatus: domain.TripStatusPaid})
+
+	if got := testutil.ToFloat64(m.trips.WithLabelValues("created", "suv", "13.9,100.5")); got != 1 {
+		t.Errorf("expected 1 created trip in the pickup zone, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.trips.WithLabelValues("completed", "unknown", "unknown")); got != 1 {
+		t.Errorf("expected the paid trip counted as completed, got %v", got)
+	}
+	if got := testutil.CollectAndCount(m.timeToAccept); got != 1 {
+		t.Fatalf("expected a time to accept, got %d series", got)
+	}
+}
+
+func TestQuoteCreated(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+	fares := []*types.RideFare{{PackageSlug: "suv", TotalPriceInCents: 1850}, {PackageSlug: "sedan", TotalPriceInCents: 1200}}
+
+	m.QuoteCreated(fares, false)
+	m.QuoteCreated(fares, true)
+
+	if got := testutil.ToFloat64(m.previews.WithLabelValues("false")); got != 1 {
+		t.Errorf("expected 1 new quote, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.previews.WithLabelValues("true")); got != 1 {
+		t.Errorf("expected 1 reused quote, got %v", got)
+	}
+	if got := testutil.CollectAndCount(m.quotePrice); got != 2 {
+		t.Errorf("expected a price histogram per package, got %d", got)
+	}
+}
+
+type routeProviderFunc func(ctx context.Context, pickup, dropoff types.Coordinate) (*types.OsrmApiResponse, error)
+
+func (f routeProviderFunc) GetRoute(ctx context.Context, pickup, dropoff types.Coordinate) (*types.OsrmApiResponse, error) {
+	return f(ctx, pickup, dropoff)
+}
+
+type handlerFunc func(ctx context.Context, msg amqp.Delivery) error
+
+func (f handlerFunc) Handle(ctx context.Context, msg amqp.Delivery) error {
+	return f(ctx, msg)
+}
+
+func TestInstrumentation(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := New(reg)
+
+	// Setup
+	failing := NewRouteProvider(routeProviderFunc(func(ctx context.Context, pickup, dropoff types.Coordinate) (*types.OsrmApiResponse, error) {
+		return nil, errors.New("timeout")
+	}), m)
+	handler := NewMessageHandler(handlerFunc(func(ctx context.Context, msg amqp.Delivery) error {
+		return errors.New("unknown trip")
+	}), m)
+	monitor := m.CommandMonitor()
+
+	// Execute
+	failing.GetRoute(context.Background(), types.Coordinate{}, types.Coordinate{})
+	handler.Handle(context.Background(), amqp.Delivery{RoutingKey: "payment.event.success", Timestamp: time.Now().Add(-time.Second)})
+	monitor.Succeeded(context.Background(), &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", Duration: 3 * time.Millisecond}})
+
+	// Verify
+	if got := testutil.CollectAndCount(m.osrmDuration, "trip_osrm_request_duration_seconds"); got != 1 {
+		t.Errorf("expected the failed route request to be measured, got %d", got)
+	}
+	if got := testutil.ToFloat64(m.messages.WithLabelValues("payment.event.success", "error")); got != 1 {
+		t.Errorf("expected 1 handler error, got %v", got)
+	}
+	if got := testutil.CollectAndCount(m.consumerLag); got != 1 {
+		t.Errorf("expected the consumer lag to be measured, got %d", got)
+	}
+	if got := testutil.CollectAndCount(m.mongoDuration); got != 1 {
+		t.Errorf("expected the Mongo command to be measured, got %d", got)
+	}
+
+	rec := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `trip_osrm_request_duration_seconds_count{result="error"} 1`) {
+		t.Errorf("expected the OSRM error on /metrics, got:\n%s", body)
+	}
+}
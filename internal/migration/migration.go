@@ -0,0 +1,338 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// MigrationsCollection records the applied migrations, one document per
+	// version
+	MigrationsCollection = "schema_migrations"
+	// LockCollection holds the lease of the replica running migrations
+	LockCollection = "schema_migrations_lock"
+
+	lockID = "schema_migrations"
+)
+
+// Migration is one versioned change to the database. Down may be nil for
+// migrations that cannot be reverted.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	Down        func(ctx context.Context, db *mongo.Database) error
+}
+
+// Status is a migration and whether it was applied. Migrations recorded in
+// the database but unknown to this build have no Up or Down.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type appliedMigration struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// Runner applies and reverts migrations. Every run holds a lock in the
+// database, so replicas starting together apply each migration once.
+type Runner interface {
+	// Up applies every pending migration in version order
+	Up(ctx context.Context) ([]Migration, error)
+	// Down reverts the last steps applied migrations
+	Down(ctx context.Context, steps int) ([]Migration, error)
+	Status(ctx context.Context) ([]Status, error)
+}
+
+type runner struct {
+	db         *mongo.Database
+	migrations []Migration
+	owner      string
+	lease      time.Duration
+	retry      time.Duration
+	now        func() time.Time
+	logger     *slog.Logger
+}
+
+// Option configures the runner
+type Option func(*runner)
+
+// WithLease sets how long the lock is held without being renewed, which is
+// how long a crashed runner blocks the others
+func WithLease(lease time.Duration) Option {
+	return func(r *runner) {
+		r.lease = lease
+	}
+}
+
+// WithLockRetry sets how often a runner waiting for the lock tries again
+func WithLockRetry(retry time.Duration) Option {
+	return func(r *runner) {
+		r.retry = retry
+	}
+}
+
+// WithClock replaces time.Now, e.g. to expire the lock in tests
+func WithClock(now func() time.Time) Option {
+	return func(r *runner) {
+		r.now = now
+	}
+}
+
+// WithLogger sets the logger, slog.Default() otherwise
+func WithLogger(logger *slog.Logger) Option {
+	return func(r *runner) {
+		r.logger = logger
+	}
+}
+
+func NewRunner(db *mongo.Database, migrations []Migration, opts ...Option) (Runner, error) {
+	if err := validate(migrations); err != nil {
+		return nil, err
+	}
+
+	sorted := append([]Migration{}, migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	hostname, _ := os.Hostname()
+	r := &runner{
+		db:         db,
+		migrations: sorted,
+		owner:      fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), primitive.NewObjectID().Hex()),
+		lease:      time.Minute,
+		retry:      time.Second,
+		now:        time.Now,
+		logger:     slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
+}
+
+func validate(migrations []Migration) error {
+	seen := map[int]bool{}
+	for _, m := range migrations {
+		if m.Version <= 0 {
+			return fmt.Errorf("migration %q: version must be positive", m.Description)
+		}
+		if seen[m.Version] {
+			return fmt.Errorf("duplicate migration version %d", m.Version)
+		}
+		if m.Up == nil {
+			return fmt.Errorf("migration %d has no Up", m.Version)
+		}
+		seen[m.Version] = true
+	}
+	return nil
+}
+
+func (r *runner) Up(ctx context.Context) ([]Migration, error) {
+	unlock, err := r.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range r.migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		r.logger.InfoContext(ctx, "Applying migration", "version", m.Version, "description", m.Description)
+		if err := m.Up(ctx, r.db); err != nil {
+			return done, fmt.Errorf("failed to apply migration %d: %w", m.Version, err)
+		}
+
+		record := appliedMigration{Version: m.Version, Description: m.Description, AppliedAt: r.now()}
+		if _, err := r.db.Collection(MigrationsCollection).InsertOne(ctx, record); err != nil {
+			return done, fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+func (r *runner) Down(ctx context.Context, steps int) ([]Migration, error) {
+	unlock, err := r.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(r.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := r.migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == nil {
+			return done, fmt.Errorf("migration %d cannot be reverted", m.Version)
+		}
+
+		r.logger.InfoContext(ctx, "Reverting migration", "version", m.Version, "description", m.Description)
+		if err := m.Down(ctx, r.db); err != nil {
+			return done, fmt.Errorf("failed to revert migration %d: %w", m.Version, err)
+		}
+
+		if _, err := r.db.Collection(MigrationsCollection).DeleteOne(ctx, bson.M{"_id": m.Version}); err != nil {
+			return done, fmt.Errorf("failed to unrecord migration %d: %w", m.Version, err)
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+func (r *runner) Status(ctx context.Context) ([]Status, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		s := Status{Migration: m}
+		if a, ok := applied[m.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = a.AppliedAt
+			delete(applied, m.Version)
+		}
+		statuses = append(statuses, s)
+	}
+
+	// Applied by a newer build
+	for _, a := range applied {
+		statuses = append(statuses, Status{
+			Migration: Migration{Version: a.Version, Description: a.Description},
+			Applied:   true,
+			AppliedAt: a.AppliedAt,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+func (r *runner) applied(ctx context.Context) (map[int]appliedMigration, error) {
+	cursor, err := r.db.Collection(MigrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []appliedMigration
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	applied := make(map[int]appliedMigration, len(records))
+	for _, a := range records {
+		applied[a.Version] = a
+	}
+	return applied, nil
+}
+
+// lock waits until it holds the migration lease and keeps renewing it until
+// unlock is called
+func (r *runner) lock(ctx context.Context) (unlock func(), err error) {
+	for {
+		acquired, err := r.tryLock(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			break
+		}
+
+		r.logger.InfoContext(ctx, "Waiting for another replica to finish migrating")
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(r.retry):
+		}
+	}
+
+	renewCtx, stopRenewing := context.WithCancel(context.WithoutCancel(ctx))
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		r.renew(renewCtx)
+	}()
+
+	return func() {
+		stopRenewing()
+		<-renewed
+
+		filter := bson.M{"_id": lockID, "owner": r.owner}
+		if _, err := r.db.Collection(LockCollection).DeleteOne(context.WithoutCancel(ctx), filter); err != nil {
+			r.logger.WarnContext(ctx, "Failed to release the migration lock", "error", err)
+		}
+	}, nil
+}
+
+// tryLock takes the lease when it is free or expired. When another runner
+// holds it, the upsert collides with the existing lock document.
+func (r *runner) tryLock(ctx context.Context) (bool, error) {
+	now := r.now()
+	filter := bson.M{
+		"_id": lockID,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$lt": now}},
+			bson.M{"owner": r.owner},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": r.owner, "expires_at": now.Add(r.lease)}}
+
+	_, err := r.db.Collection(LockCollection).UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire the migration lock: %w", err)
+	}
+	return true, nil
+}
+
+func (r *runner) renew(ctx context.Context) {
+	ticker := time.NewTicker(r.lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			filter := bson.M{"_id": lockID, "owner": r.owner}
+			update := bson.M{"$set": bson.M{"expires_at": r.now().Add(r.lease)}}
+			if _, err := r.db.Collection(LockCollection).UpdateOne(ctx, filter, update); err != nil && ctx.Err() == nil {
+				r.logger.WarnContext(ctx, "Failed to renew the migration lock", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func recordingMigrations(calls *[]string) []Migration {
+	migration := func(version int) Migration {
+		return Migration{
+			Version:     version,
+			Description: fmt.Sprintf("migration %d", version),
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				*calls = append(*calls, fmt.Sprintf("up %d", version))
+				return nil
+			},
+			Down: func(ctx context.Context, db *mongo.Database) error {
+				*calls = append(*calls, fmt.Sprintf("down %d", version))
+				return nil
+			},
+		}
+	}
+	// Registered out of order on purpose
+	return []Migration{migration(3), migration(1), migration(2)}
+}
+
+func TestNewRunnerValidation(t *testing.T) {
+	up := func(ctx context.Context, db *mongo.Database) error { return nil }
+
+	tests := []struct {
+		name       string
+		migrations []Migration
+		wantErr    bool
+	}{
+		{name: "valid", migrations: []Migration{{Version: 1, Up: up}, {Version: 2, Up: up}}},
+		{name: "duplicate version", migrations: []Migration{{Version: 1, Up: up}, {Version: 1, Up: up}}, wantErr: true},
+		{name: "zero version", migrations: []Migration{{Version: 0, Up: up}}, wantErr: true},
+		{name: "missing up", migrations: []Migration{{Version: 1}}, wantErr: true},
+		{name: "trip service migrations", migrations: Migrations()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRunner(nil, tt.migrations)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRunner(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ns := mtest.TestDb + "." + MigrationsCollection
+
+	mt.Run("up applies pending migrations in order", func(mt *mtest.T) {
+		// Setup
+		var calls []string
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "_id", Value: 1}}),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+		runner, err := NewRunner(mt.DB, recordingMigrations(&calls))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// Execute
+		applied, err := runner.Up(context.Background())
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(applied) != 2 {
+			t.Fatalf("expected 2 migrations applied, got %d", len(applied))
+		}
+		if fmt.Sprint(calls) != "[up 2 up 3]" {
+			t.Errorf("expected migrations 2 and 3 to be applied in order, got %v", calls)
+		}
+
+		commands := []string{}
+		for started := mt.GetStartedEvent(); started != nil; started = mt.GetStartedEvent() {
+			commands = append(commands, started.CommandName)
+		}
+		if fmt.Sprint(commands) != "[update find insert insert delete]" {
+			t.Errorf("expected to lock, record both migrations and unlock, got %v", commands)
+		}
+	})
+
+	mt.Run("up waits for the lock", func(mt *mtest.T) {
+		// Setup
+		var calls []string
+		mt.AddMockResponses(
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: "E11000 duplicate key error"}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: 1}}, bson.D{{Key: "_id", Value: 2}}, bson.D{{Key: "_id", Value: 3}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+		runner, err := NewRunner(mt.DB, recordingMigrations(&calls), WithLockRetry(time.Millisecond))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// Execute
+		applied, err := runner.Up(context.Background())
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(applied) != 0 || len(calls) != 0 {
+			t.Errorf("expected nothing to apply, got %v", calls)
+		}
+	})
+
+	mt.Run("down reverts the latest migrations", func(mt *mtest.T) {
+		// Setup
+		var calls []string
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: 1}}, bson.D{{Key: "_id", Value: 2}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+		runner, err := NewRunner(mt.DB, recordingMigrations(&calls))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// Execute
+		reverted, err := runner.Down(context.Background(), 5)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(reverted) != 2 || fmt.Sprint(calls) != "[down 2 down 1]" {
+			t.Errorf("expected migrations 2 and 1 to be reverted, got %v", calls)
+		}
+	})
+
+	mt.Run("status lists migrations unknown to the build", func(mt *mtest.T) {
+		// Setup
+		var calls []string
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: 1}, {Key: "applied_at", Value: time.Now()}},
+				bson.D{{Key: "_id", Value: 7}, {Key: "description", Value: "from a newer build"}}),
+		)
+		runner, err := NewRunner(mt.DB, recordingMigrations(&calls))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// Execute
+		statuses, err := runner.Status(context.Background())
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(statuses) != 4 {
+			t.Fatalf("expected 4 statuses, got %d", len(statuses))
+		}
+		for i, want := range []struct {
+			version int
+			applied bool
+		}{{1, true}, {2, false}, {3, false}, {7, true}} {
+			if statuses[i].Version != want.version || statuses[i].Applied != want.applied {
+				t.Errorf("status %d: expected version %d applied %t, got %+v", i, want.version, want.applied, statuses[i])
+			}
+		}
+		if statuses[3].Up != nil {
+			t.Error("expected the unknown migration to have no Up")
+		}
+	})
+}
+
+func TestRunnerIntegration(t *testing.T) {
+	uri := os.Getenv("TRIP_TEST_MONGODB_URI")
+	if uri == "" {
+		t.Skip("TRIP_TEST_MONGODB_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("failed to connect to MongoDB: %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect(ctx) })
+
+	db := client.Database(fmt.Sprintf("trip_service_test_%s", primitive.NewObjectID().Hex()))
+	t.Cleanup(func() { db.Drop(ctx) })
+
+	// Replicas racing to apply the same migrations
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			runner, err := NewRunner(db, Migrations(), WithLockRetry(10*time.Millisecond))
+			if err == nil {
+				_, err = runner.Up(ctx)
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("replica %d: expected no error, got %v", i, err)
+		}
+	}
+
+	runner, err := NewRunner(db, Migrations())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	statuses, err := runner.Status(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, s := range statuses {
+		if !s.Applied {
+			t.Errorf("expected migration %d to be applied", s.Version)
+		}
+	}
+
+	reverted, err := runner.Down(ctx, len(Migrations()))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(reverted) != len(Migrations()) {
+		t.Errorf("expected every migration to be reverted, got %d", len(reverted))
+	}
+
+	if applied, err := runner.Up(ctx); err != nil || len(applied) != len(Migrations()) {
+		t.Errorf("expected every migration to be applied again, got %d, %v", len(applied), err)
+	}
+}
@@ -0,0 +1,107 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tripMongo "github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migrations returns the schema of the trip service, oldest first. Released
+// migrations must never change: append a new one instead.
+func Migrations() []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "expire ride fares after their TTL",
+			Up:          expireRideFares,
+			Down:        dropIndexes(tripMongo.RideFaresCollection, "created_at_1"),
+		},
+		{
+			Version:     2,
+			Description: "index trip events by trip and version",
+			Up:          tripMongo.CreateTripEventsIndex,
+			Down:        dropIndexes(tripMongo.TripEventsCollection, "trip_id_1_created_at_1", "trip_id_1_version_1"),
+		},
+		{
+			Version:     3,
+			Description: "allow one rating per trip participant",
+			Up:          tripMongo.CreateRatingsIndex,
+			Down:        dropIndexes(tripMongo.RatingsCollection, "trip_id_1_rater_role_1"),
+		},
+		{
+			Version:     4,
+			Description: "index quotes by user and route, expire them with their fares",
+			Up:          tripMongo.CreateQuotesIndex,
+			Down: dropIndexes(tripMongo.QuotesCollection,
+				"created_at_1", "userID_1_route_key_1_created_at_-1", "fares.fare_id_1"),
+		},
+	}
+}
+
+// rideFaresTTLSeconds is the expiry migration 1 gives ride fares. It is fixed
+// here rather than read from domain.RideFareTTL so the migration cannot change
+// once released.
+const rideFaresTTLSeconds int32 = 24 * 60 * 60
+
+// expireRideFares creates the TTL index of ride fares. An index left with
+// another expiry by an earlier release is changed in place with collMod, so
+// fares keep expiring while the migration runs.
+func expireRideFares(ctx context.Context, db *mongo.Database) error {
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(rideFaresTTLSeconds).SetName("created_at_1"),
+	}
+
+	_, err := db.Collection(tripMongo.RideFaresCollection).Indexes().CreateOne(ctx, index)
+	if !isIndexOptionsConflict(err) {
+		return err
+	}
+
+	collMod := bson.D{
+		{Key: "collMod", Value: tripMongo.RideFaresCollection},
+		{Key: "index", Value: bson.D{
+			{Key: "name", Value: "created_at_1"},
+			{Key: "expireAfterSeconds", Value: rideFaresTTLSeconds},
+		}},
+	}
+	if err := db.RunCommand(ctx, collMod).Err(); err != nil {
+		return fmt.Errorf("failed to change the ride fares expiry: %w", err)
+	}
+	return nil
+}
+
+func dropIndexes(collection string, names ...string) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		for _, name := range names {
+			if _, err := db.Collection(collection).Indexes().DropOne(ctx, name); err != nil && !isIndexNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// isIndexNotFound lets a revert finish when an index or its collection was
+// already dropped by hand
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	return cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound"
+}
+
+// isIndexOptionsConflict reports an index that exists under the same name
+// with other options
+func isIndexOptionsConflict(err error) bool {
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	return cmdErr.Name == "IndexOptionsConflict"
+}
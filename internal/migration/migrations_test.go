@@ -0,0 +1,85 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestExpireRideFares(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("creates the ttl index", func(mt *mtest.T) {
+		// Setup
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		// Execute
+		err := expireRideFares(context.Background(), mt.DB)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if started := mt.GetStartedEvent(); started.CommandName != "createIndexes" {
+			t.Errorf("expected createIndexes, got %s", started.CommandName)
+		}
+		if started := mt.GetStartedEvent(); started != nil {
+			t.Errorf("expected no other command, got %s", started.CommandName)
+		}
+	})
+
+	mt.Run("changes the expiry of an existing index in place", func(mt *mtest.T) {
+		// Setup
+		mt.AddMockResponses(
+			mtest.CreateCommandErrorResponse(mtest.CommandError{
+				Code:    85,
+				Name:    "IndexOptionsConflict",
+				Message: "An equivalent index already exists with a different expireAfterSeconds",
+			}),
+			mtest.CreateSuccessResponse(),
+		)
+
+		// Execute
+		err := expireRideFares(context.Background(), mt.DB)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		commands := []string{}
+		var collMod bson.Raw
+		for started := mt.GetStartedEvent(); started != nil; started = mt.GetStartedEvent() {
+			commands = append(commands, started.CommandName)
+			if started.CommandName == "collMod" {
+				collMod = started.Command
+			}
+		}
+		if fmt.Sprint(commands) != "[createIndexes collMod]" {
+			t.Fatalf("expected the index to be modified rather than dropped, got %v", commands)
+		}
+		expireAfter := collMod.Lookup("index", "expireAfterSeconds").Int32()
+		if expireAfter != rideFaresTTLSeconds {
+			t.Errorf("expected expireAfterSeconds %d, got %d", rideFaresTTLSeconds, expireAfter)
+		}
+	})
+
+	mt.Run("other errors fail the migration", func(mt *mtest.T) {
+		// Setup
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code:    86,
+			Name:    "IndexKeySpecsConflict",
+			Message: "An existing index has the same name as the requested index",
+		}))
+
+		// Execute
+		err := expireRideFares(context.Background(), mt.DB)
+
+		// Verify
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package privacy
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/ride4Low/contracts/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+var routeType = reflect.TypeOf(types.OsrmApiResponse{})
+
+// plainRoute has the default struct encoding of a route, as stored before
+// encryption was enabled
+type plainRoute types.OsrmApiResponse
+
+// storedRoute keeps the distance and duration of every leg readable, for
+// receipts and retention, and seals the geometry of all legs in one value
+type storedRoute struct {
+	Routes   []storedLeg     `bson:"routes"`
+	Geometry *EncryptedValue `bson:"geometry"`
+}
+
+type storedLeg struct {
+	Distance float64 `bson:"distance"`
+	Duration float64 `bson:"duration"`
+}
+
+type routeGeometry struct {
+	Coordinates [][][]float64 `bson:"coordinates"`
+}
+
+// NewRegistry returns a BSON registry that encrypts route geometry with the
+// active key of the keyring. Routes stored in plaintext are still decoded.
+func NewRegistry(keyring *Keyring) *bsoncodec.Registry {
+	codec := &routeCodec{keyring: keyring}
+
+	reg := bson.NewRegistry()
+	reg.RegisterTypeEncoder(routeType, bsoncodec.ValueEncoderFunc(codec.EncodeValue))
+	reg.RegisterTypeDecoder(routeType, bsoncodec.ValueDecoderFunc(codec.DecodeValue))
+	return reg
+}
+
+type routeCodec struct {
+	keyring *Keyring
+}
+
+func (c *routeCodec) EncodeValue(ec bsoncodec.EncodeContext, vw bsonrw.ValueWriter, val reflect.Value) error {
+	if !val.IsValid() || val.Type() != routeType {
+		return bsoncodec.ValueEncoderError{Name: "RouteEncodeValue", Types: []reflect.Type{routeType}, Received: val}
+	}
+	route := val.Interface().(types.OsrmApiResponse)
+
+	stored := storedRoute{Routes: make([]storedLeg, len(route.Routes))}
+	geometry := routeGeometry{Coordinates: make([][][]float64, len(route.Routes))}
+	for i, leg := range route.Routes {
+		stored.Routes[i] = storedLeg{Distance: leg.Distance, Duration: leg.Duration}
+		geometry.Coordinates[i] = leg.Geometry.Coordinates
+	}
+
+	plaintext, err := bson.Marshal(geometry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal route geometry: %w", err)
+	}
+	stored.Geometry, err = c.keyring.Encrypt(plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt route geometry: %w", err)
+	}
+
+	doc, err := bson.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return bsonrw.Copier{}.CopyDocumentFromBytes(vw, doc)
+}
+
+func (c *routeCodec) DecodeValue(dc bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+	if !val.CanSet() || val.Type() != routeType {
+		return bsoncodec.ValueDecoderError{Name: "RouteDecodeValue", Types: []reflect.Type{routeType}, Received: val}
+	}
+
+	if vr.Type() == bsontype.Null {
+		val.Set(reflect.Zero(routeType))
+		return vr.ReadNull()
+	}
+
+	doc, err := bsonrw.Copier{}.CopyDocumentToBytes(vr)
+	if err != nil {
+		return err
+	}
+
+	var route types.OsrmApiResponse
+	if _, err := bson.Raw(doc).LookupErr("geometry"); err != nil {
+		if err := bson.Unmarshal(doc, (*plainRoute)(&route)); err != nil {
+			return err
+		}
+		val.Set(reflect.ValueOf(route))
+		return nil
+	}
+
+	var stored storedRoute
+	if err := bson.Unmarshal(doc, &stored); err != nil {
+		return err
+	}
+	plaintext, err := c.keyring.Decrypt(stored.Geometry)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt route geometry: %w", err)
+	}
+	var geometry routeGeometry
+	if err := bson.Unmarshal(plaintext, &geometry); err != nil {
+		return fmt.Errorf("failed to unmarshal route geometry: %w", err)
+	}
+
+	route.Routes = slices.Grow(route.Routes, len(stored.Routes))[:len(stored.Routes)]
+	for i, leg := range stored.Routes {
+		route.Routes[i].Distance = leg.Distance
+		route.Routes[i].Duration = leg.Duration
+		if i < len(geometry.Coordinates) {
+			route.Routes[i].Geometry.Coordinates = geometry.Coordinates[i]
+		}
+	}
+
+	val.Set(reflect.ValueOf(route))
+	return nil
+}
@@ -0,0 +1,183 @@
+package privacy
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func testRoute() *types.OsrmApiResponse {
+	route := &types.OsrmApiResponse{}
+	if err := bson.Unmarshal(mustMarshal(bson.D{{Key: "routes", Value: bson.A{
+		bson.D{
+			{Key: "distance", Value: 1000.0},
+			{Key: "duration", Value: 120.0},
+			{Key: "geometry", Value: bson.D{{Key: "coordinates", Value: bson.A{
+				bson.A{100.12345, 13.98765},
+				bson.A{100.56789, 13.01234},
+			}}}},
+		},
+	}}}), (*plainRoute)(route)); err != nil {
+		panic(err)
+	}
+	return route
+}
+
+func mustMarshal(v interface{}) []byte {
+	b, err := bson.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func containsFloat(b []byte, f float64) bool {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(f))
+	return bytes.Contains(b, buf[:])
+}
+
+func TestRouteCodec(t *testing.T) {
+	keyring, _ := NewKeyring(map[string][]byte{"k1": testKey(1)}, "k1")
+	reg := NewRegistry(keyring)
+
+	t.Run("encrypts the geometry", func(t *testing.T) {
+		fare := &types.RideFare{UserID: "user-123", Route: testRoute()}
+
+		data, err := bson.MarshalWithRegistry(reg, fare)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if containsFloat(data, 100.12345) || containsFloat(data, 13.01234) {
+			t.Error("expected no coordinates in the stored document")
+		}
+		raw := bson.Raw(data)
+		if keyID := raw.Lookup("route", "geometry", "key_id").StringValue(); keyID != "k1" {
+			t.Errorf("expected the geometry sealed with k1, got %q", keyID)
+		}
+		if distance := raw.Lookup("route", "routes").Array().Index(0).Value().Document().Lookup("distance").Double(); distance != 1000 {
+			t.Errorf("expected the distance to stay readable, got %v", distance)
+		}
+
+		var got types.RideFare
+		if err := bson.UnmarshalWithRegistry(reg, data, &got); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !reflect.DeepEqual(got.Route, fare.Route) {
+			t.Errorf("expected the route back, got %+v", got.Route)
+		}
+	})
+
+	t.Run("reads plaintext routes", func(t *testing.T) {
+		data := mustMarshal(bson.D{{Key: "route", Value: bson.Raw(mustMarshal((*plainRoute)(testRoute())))}})
+
+		var got types.RideFare
+		if err := bson.UnmarshalWithRegistry(reg, data, &got); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !reflect.DeepEqual(got.Route, testRoute()) {
+			t.Errorf("expected the plaintext route, got %+v", got.Route)
+		}
+	})
+
+	t.Run("nil route", func(t *testing.T) {
+		data, err := bson.MarshalWithRegistry(reg, &types.RideFare{UserID: "user-123"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		got := types.RideFare{Route: testRoute()}
+		if err := bson.UnmarshalWithRegistry(reg, data, &got); err != nil || got.Route != nil {
+			t.Errorf("expected no route, got %+v, %v", got.Route, err)
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		data, _ := bson.MarshalWithRegistry(reg, &types.RideFare{Route: testRoute()})
+		other, _ := NewKeyring(map[string][]byte{"k2": testKey(2)}, "k2")
+
+		var got types.RideFare
+		if err := bson.UnmarshalWithRegistry(NewRegistry(other), data, &got); err == nil {
+			t.Error("expected an error without the key")
+		}
+	})
+}
+
+func TestReencrypt(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("seals plaintext routes with the active key", func(mt *mtest.T) {
+		// Setup
+		keyring, _ := NewKeyring(map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, "k2")
+		responses := []bson.D{
+			mtest.CreateCursorResponse(0, mtest.TestDb+"."+mongo.RideFaresCollection, mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "route", Value: bson.Raw(mustMarshal((*plainRoute)(testRoute())))},
+			}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+		}
+		for _, field := range RouteFields[1:] {
+			responses = append(responses, mtest.CreateCursorResponse(0, mtest.TestDb+"."+field.Collection, mtest.FirstBatch))
+		}
+		mt.AddMockResponses(responses...)
+
+		// Execute
+		counts, err := Reencrypt(context.Background(), mt.DB, keyring)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if counts[mongo.RideFaresCollection] != 1 {
+			t.Errorf("expected 1 ride fare re-encrypted, got %v", counts)
+		}
+
+		mt.GetStartedEvent()
+		update := mt.GetStartedEvent()
+		if update == nil || update.CommandName != "update" {
+			t.Fatalf("expected an update, got %v", update)
+		}
+		set := update.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u", "$set").Document()
+		if keyID := set.Lookup("route", "geometry", "key_id").StringValue(); keyID != "k2" {
+			t.Errorf("expected the route sealed with the active key, got %q", keyID)
+		}
+	})
+}
+
+func TestFuzz(t *testing.T) {
+	tests := []struct {
+		value     float64
+		precision int
+		want      float64
+	}{
+		{value: 13.98765, precision: 3, want: 13.987},
+		{value: 100.56789, precision: 2, want: 100.56},
+		{value: -33.86789, precision: 2, want: -33.86},
+		{value: 13.98765, precision: 0, want: 13},
+	}
+
+	for _, tt := range tests {
+		if got := Fuzz(tt.value, tt.precision); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Fuzz(%v, %d) = %v, want %v", tt.value, tt.precision, got, tt.want)
+		}
+	}
+
+	if FuzzCoordinate(nil, 3) != nil {
+		t.Error("expected nil for a missing coordinate")
+	}
+	c := FuzzCoordinate(&domain.Coordinate{Latitude: 13.98765, Longitude: 100.56789}, 3)
+	if math.Abs(c.Latitude-13.987) > 1e-9 || math.Abs(c.Longitude-100.567) > 1e-9 {
+		t.Errorf("unexpected fuzzed coordinate %+v", c)
+	}
+}
@@ -0,0 +1,88 @@
+package privacy
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// DefaultCoordinatePrecision keeps 3 decimals, about 110 meters
+const DefaultCoordinatePrecision = 3
+
+// KeySize is the size of the AES-256 keys encrypting routes
+const KeySize = 32
+
+type Config struct {
+	// CoordinatePrecision is the number of decimals coordinates keep in logs
+	// and analytics events
+	CoordinatePrecision int
+	// Keys encrypt route geometry at rest, by key ID. Keys other than the
+	// active one are kept to read documents written before a rotation.
+	// Without keys routes are stored in plaintext.
+	Keys        map[string][]byte
+	ActiveKeyID string
+	// BlindIndexKey hashes the route keys of quotes when routes are
+	// encrypted. It is never rotated, or recent quotes would not be found.
+	BlindIndexKey []byte
+}
+
+func (c *Config) Validate() error {
+	if c.CoordinatePrecision < 0 || c.CoordinatePrecision > 6 {
+		return fmt.Errorf("coordinate precision must be between 0 and 6 decimals")
+	}
+	if len(c.Keys) == 0 {
+		return nil
+	}
+
+	for id, key := range c.Keys {
+		if len(key) != KeySize {
+			return fmt.Errorf("route encryption key %q must be %d bytes, got %d", id, KeySize, len(key))
+		}
+	}
+	if _, ok := c.Keys[c.ActiveKeyID]; !ok {
+		return fmt.Errorf("active route encryption key %q is not configured", c.ActiveKeyID)
+	}
+	if len(c.BlindIndexKey) != KeySize {
+		return fmt.Errorf("blind index key must be %d bytes with route encryption, got %d", KeySize, len(c.BlindIndexKey))
+	}
+
+	return nil
+}
+
+// Encrypted reports whether routes are encrypted at rest
+func (c *Config) Encrypted() bool {
+	return len(c.Keys) > 0
+}
+
+// ParseKeys parses comma separated id:base64-key pairs and returns the keys
+// by ID and the ID of the first one
+func ParseKeys(s string) (map[string][]byte, string, error) {
+	keys := map[string][]byte{}
+	first := ""
+
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(pair, ":")
+		if !ok || id == "" {
+			return nil, "", fmt.Errorf("expected id:base64-key, got %q", pair)
+		}
+		if _, ok := keys[id]; ok {
+			return nil, "", fmt.Errorf("duplicate key id %q", id)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, "", fmt.Errorf("key %q is not base64: %w", id, err)
+		}
+
+		keys[id] = key
+		if first == "" {
+			first = id
+		}
+	}
+
+	return keys, first, nil
+}
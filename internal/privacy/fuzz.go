@@ -0,0 +1,25 @@
+package privacy
+
+import (
+	"math"
+
+	"github.com/ride4Low/trip-service/internal/domain"
+)
+
+// Fuzz truncates a coordinate to precision decimals. Truncating rather than
+// rounding maps every point of a grid cell to the same corner.
+func Fuzz(value float64, precision int) float64 {
+	scale := math.Pow10(precision)
+	return math.Trunc(value*scale) / scale
+}
+
+// FuzzCoordinate returns a copy of c truncated to precision decimals
+func FuzzCoordinate(c *domain.Coordinate, precision int) *domain.Coordinate {
+	if c == nil {
+		return nil
+	}
+	return &domain.Coordinate{
+		Latitude:  Fuzz(c.Latitude, precision),
+		Longitude: Fuzz(c.Longitude, precision),
+	}
+}
@@ -0,0 +1,124 @@
+package privacy
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// EncryptedValue is a value sealed with AES-256-GCM. Data is the nonce
+// followed by the ciphertext.
+type EncryptedValue struct {
+	KeyID string `bson:"key_id"`
+	Data  []byte `bson:"data"`
+}
+
+// Keyring encrypts with the active key and decrypts with any configured key,
+// so documents written before a rotation stay readable until they are
+// re-encrypted
+type Keyring struct {
+	aeads  map[string]cipher.AEAD
+	active string
+}
+
+func NewKeyring(keys map[string][]byte, activeKeyID string) (*Keyring, error) {
+	k := &Keyring{
+		aeads:  make(map[string]cipher.AEAD, len(keys)),
+		active: activeKeyID,
+	}
+
+	for id, key := range keys {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("key %q must be %d bytes, got %d", id, KeySize, len(key))
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher for key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher for key %q: %w", id, err)
+		}
+		k.aeads[id] = aead
+	}
+
+	if _, ok := keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("%w: active key %q", ErrUnknownKey, activeKeyID)
+	}
+
+	return k, nil
+}
+
+// NewKeyringFromConfig returns nil when no keys are configured
+func NewKeyringFromConfig(cfg *Config) (*Keyring, error) {
+	if !cfg.Encrypted() {
+		return nil, nil
+	}
+	return NewKeyring(cfg.Keys, cfg.ActiveKeyID)
+}
+
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+func (k *Keyring) Encrypt(plaintext []byte) (*EncryptedValue, error) {
+	aead := k.aeads[k.active]
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return &EncryptedValue{
+		KeyID: k.active,
+		Data:  aead.Seal(nonce, nonce, plaintext, []byte(k.active)),
+	}, nil
+}
+
+func (k *Keyring) Decrypt(v *EncryptedValue) ([]byte, error) {
+	aead, ok := k.aeads[v.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, v.KeyID)
+	}
+	if len(v.Data) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted value is too short")
+	}
+
+	nonce, ciphertext := v.Data[:aead.NonceSize()], v.Data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(v.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with key %q: %w", v.KeyID, err)
+	}
+
+	return plaintext, nil
+}
+
+// BlindIndex hashes values that are looked up but must not be stored, such
+// as the route keys of quotes. Its key is not rotated with the encryption
+// keys, as stored hashes would no longer match.
+type BlindIndex struct {
+	key []byte
+}
+
+func NewBlindIndex(key []byte) (*BlindIndex, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("blind index key must be %d bytes, got %d", KeySize, len(key))
+	}
+	return &BlindIndex{key: key}, nil
+}
+
+// Blind returns a keyed hash of value that can be indexed and compared
+// without storing value
+func (b *BlindIndex) Blind(value string) string {
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
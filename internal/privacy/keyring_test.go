@@ -0,0 +1,153 @@
+package privacy
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestKeyring(t *testing.T) {
+	old, err := NewKeyring(map[string][]byte{"k1": testKey(1)}, "k1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	rotated, err := NewKeyring(map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, "k2")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		v, err := rotated.Encrypt([]byte("secret"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if v.KeyID != "k2" || bytes.Contains(v.Data, []byte("secret")) {
+			t.Errorf("expected ciphertext under k2, got %+v", v)
+		}
+
+		plaintext, err := rotated.Decrypt(v)
+		if err != nil || string(plaintext) != "secret" {
+			t.Errorf("expected secret, got %q, %v", plaintext, err)
+		}
+	})
+
+	t.Run("values of a retired key stay readable", func(t *testing.T) {
+		v, _ := old.Encrypt([]byte("secret"))
+
+		plaintext, err := rotated.Decrypt(v)
+		if err != nil || string(plaintext) != "secret" {
+			t.Errorf("expected secret, got %q, %v", plaintext, err)
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		v, _ := rotated.Encrypt([]byte("secret"))
+
+		if _, err := old.Decrypt(v); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("expected ErrUnknownKey, got %v", err)
+		}
+	})
+
+	t.Run("tampered value", func(t *testing.T) {
+		v, _ := rotated.Encrypt([]byte("secret"))
+		v.Data[len(v.Data)-1] ^= 1
+
+		if _, err := rotated.Decrypt(v); err == nil {
+			t.Error("expected an error for a tampered value")
+		}
+	})
+
+	t.Run("key ID is authenticated", func(t *testing.T) {
+		same, _ := NewKeyring(map[string][]byte{"a": testKey(1), "b": testKey(1)}, "a")
+		v, _ := same.Encrypt([]byte("secret"))
+		v.KeyID = "b"
+
+		if _, err := same.Decrypt(v); err == nil {
+			t.Error("expected an error for a relabelled value")
+		}
+	})
+
+	if _, err := NewKeyring(map[string][]byte{"k1": testKey(1)}, "k2"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey for a missing active key, got %v", err)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	index, err := NewBlindIndex(testKey(3))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	other, _ := NewBlindIndex(testKey(4))
+
+	if index.Blind("route") != index.Blind("route") {
+		t.Error("expected a stable hash")
+	}
+	if index.Blind("route") == other.Blind("route") {
+		t.Error("expected the hash to depend on the key")
+	}
+	if _, err := NewBlindIndex([]byte("short")); err == nil {
+		t.Error("expected an error for a short key")
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(testKey(1))
+	k2 := base64.StdEncoding.EncodeToString(testKey(2))
+
+	t.Run("first key is active", func(t *testing.T) {
+		keys, first, err := ParseKeys("2026-10:" + k2 + ", 2026-01:" + k1)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		cfg := &Config{CoordinatePrecision: 2, Keys: keys, ActiveKeyID: first, BlindIndexKey: testKey(3)}
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("expected a valid config, got %v", err)
+		}
+		if first != "2026-10" || len(keys) != 2 {
+			t.Errorf("unexpected keys: %v, active %q", keys, first)
+		}
+	})
+
+	t.Run("no keys", func(t *testing.T) {
+		keys, _, err := ParseKeys("")
+		if err != nil || len(keys) != 0 {
+			t.Errorf("expected plaintext routes, got %v, %v", keys, err)
+		}
+	})
+
+	t.Run("malformed keys", func(t *testing.T) {
+		for _, keys := range []string{"k1", "k1:not base64", "k1:" + k1 + ",k1:" + k2} {
+			if _, _, err := ParseKeys(keys); err == nil {
+				t.Errorf("expected an error for %q", keys)
+			}
+		}
+	})
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "plaintext", cfg: Config{CoordinatePrecision: 3}},
+		{name: "encrypted", cfg: Config{CoordinatePrecision: 3, Keys: map[string][]byte{"k1": testKey(1)}, ActiveKeyID: "k1", BlindIndexKey: testKey(3)}},
+		{name: "encrypted without blind index key", cfg: Config{CoordinatePrecision: 3, Keys: map[string][]byte{"k1": testKey(1)}, ActiveKeyID: "k1"}, wantErr: true},
+		{name: "precision too high", cfg: Config{CoordinatePrecision: 7}, wantErr: true},
+		{name: "short key", cfg: Config{Keys: map[string][]byte{"k1": []byte("short")}, ActiveKeyID: "k1"}, wantErr: true},
+		{name: "missing active key", cfg: Config{Keys: map[string][]byte{"k1": testKey(1)}, ActiveKeyID: "k2"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package privacy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"github.com/ride4Low/trip-service/internal/retention"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RouteField is where routes are stored in the documents of a collection
+type RouteField struct {
+	Collection string
+	Path       string
+}
+
+// RouteFields lists every stored route, including the trip snapshots of the
+// audit trail and the trips archived unredacted
+var RouteFields = []RouteField{
+	{Collection: mongo.RideFaresCollection, Path: "route"},
+	{Collection: mongo.QuotesCollection, Path: "route"},
+	{Collection: mongo.TripsCollection, Path: "rideFare.route"},
+	{Collection: mongo.TripEventsCollection, Path: "before.rideFare.route"},
+	{Collection: mongo.TripEventsCollection, Path: "after.rideFare.route"},
+	{Collection: mongo.TripsCollection + retention.ArchiveSuffix, Path: "rideFare.route"},
+	{Collection: mongo.TripEventsCollection + retention.ArchiveSuffix, Path: "before.rideFare.route"},
+	{Collection: mongo.TripEventsCollection + retention.ArchiveSuffix, Path: "after.rideFare.route"},
+}
+
+// Reencrypt encrypts the routes stored in plaintext or under a key other than
+// the active one with the active key. Once it has run, retired keys can be
+// removed from the configuration. It returns the number of documents
+// rewritten per collection.
+func Reencrypt(ctx context.Context, db *mongoDriver.Database, keyring *Keyring) (map[string]int, error) {
+	reg := NewRegistry(keyring)
+	counts := map[string]int{}
+
+	for _, field := range RouteFields {
+		collection := db.Collection(field.Collection, options.Collection().SetRegistry(reg))
+		n, err := reencryptField(ctx, collection, reg, field.Path, keyring.ActiveKeyID())
+		counts[field.Collection] += n
+		if err != nil {
+			return counts, fmt.Errorf("failed to re-encrypt %s.%s: %w", field.Collection, field.Path, err)
+		}
+	}
+
+	return counts, nil
+}
+
+func reencryptField(ctx context.Context, collection *mongoDriver.Collection, reg *bsoncodec.Registry, path, activeKeyID string) (int, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{path + ".geometry.key_id": bson.M{"$exists": true, "$ne": activeKeyID}},
+		bson.M{path + ".routes.geometry": bson.M{"$exists": true}},
+	}}
+	opts := options.Find().SetProjection(bson.M{path: 1})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	n := 0
+	for cursor.Next(ctx) {
+		value, err := cursor.Current.LookupErr(strings.Split(path, ".")...)
+		if err != nil {
+			continue
+		}
+
+		var route types.OsrmApiResponse
+		if err := value.UnmarshalWithRegistry(reg, &route); err != nil {
+			return n, err
+		}
+
+		// Encoding through the collection registry seals the route with the
+		// active key
+		update := bson.M{"$set": bson.M{path: &route}}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": cursor.Current.Lookup("_id")}, update); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, cursor.Err()
+}
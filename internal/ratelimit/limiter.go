@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second and holding at
+// most Burst tokens
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) IsZero() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// ParseLimit parses a limit written as "rate:burst", e.g. "0.5:3"
+func ParseLimit(s string) (Limit, error) {
+	rateStr, burstStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid limit %q: expected rate:burst", s)
+	}
+
+	rate, err := strconv.ParseFloat(rateStr, 64)
+	if err != nil || rate <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate in limit %q", s)
+	}
+
+	burst, err := strconv.Atoi(burstStr)
+	if err != nil || burst <= 0 {
+		return Limit{}, fmt.Errorf("invalid burst in limit %q", s)
+	}
+
+	return Limit{Rate: rate, Burst: burst}, nil
+}
+
+// Bucket is the token bucket of a key
+type Bucket struct {
+	Key   string
+	Limit Limit
+}
+
+// Limiter takes one token from each of the buckets, or from none of them
+// when one is empty, so a rejected call does not use up the other limits.
+// It then reports how long until every bucket has a token.
+type Limiter interface {
+	Allow(ctx context.Context, buckets ...Bucket) (allowed bool, retryAfter time.Duration, err error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// memoryLimiter keeps buckets in process, so limits only hold per replica
+type memoryLimiter struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	maxKeys int
+	buckets map[string]*bucket
+}
+
+// MemoryOption configures the in-memory limiter
+type MemoryOption func(*memoryLimiter)
+
+// WithClock replaces time.Now, e.g. to refill buckets in tests
+func WithClock(now func() time.Time) MemoryOption {
+	return func(l *memoryLimiter) {
+		l.now = now
+	}
+}
+
+// WithMaxKeys bounds how many buckets are kept before full ones are dropped
+func WithMaxKeys(maxKeys int) MemoryOption {
+	return func(l *memoryLimiter) {
+		l.maxKeys = maxKeys
+	}
+}
+
+func NewMemoryLimiter(opts ...MemoryOption) Limiter {
+	l := &memoryLimiter{
+		now:     time.Now,
+		maxKeys: 100000,
+		buckets: map[string]*bucket{},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+func (l *memoryLimiter) Allow(ctx context.Context, buckets ...Bucket) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	refilled := make([]*bucket, len(buckets))
+	var retryAfter time.Duration
+	for i, bk := range buckets {
+		b := l.refill(now, bk)
+		refilled[i] = b
+		if b.tokens < 1 {
+			retryAfter = max(retryAfter, waitFor(b.tokens, bk.Limit))
+		}
+	}
+
+	if retryAfter > 0 {
+		return false, retryAfter, nil
+	}
+
+	for _, b := range refilled {
+		b.tokens--
+	}
+	return true, 0, nil
+}
+
+// refill returns the bucket of bk refilled up to now, creating it full
+func (l *memoryLimiter) refill(now time.Time, bk Bucket) *bucket {
+	b, ok := l.buckets[bk.Key]
+	if !ok {
+		if len(l.buckets) >= l.maxKeys {
+			l.evict(now)
+		}
+		b = &bucket{tokens: float64(bk.Limit.Burst), last: now}
+		l.buckets[bk.Key] = b
+	}
+
+	b.limit = bk.Limit
+	b.tokens = refill(b.tokens, now.Sub(b.last), bk.Limit)
+	b.last = now
+	return b
+}
+
+// evict drops the buckets that have refilled completely under their own
+// limit, as a new bucket would start in the same state
+func (l *memoryLimiter) evict(now time.Time) {
+	for key, b := range l.buckets {
+		if refill(b.tokens, now.Sub(b.last), b.limit) >= float64(b.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func refill(tokens float64, elapsed time.Duration, limit Limit) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate)
+}
+
+func waitFor(tokens float64, limit Limit) time.Duration {
+	return time.Duration(math.Ceil((1 - tokens) / limit.Rate * float64(time.Second)))
+}
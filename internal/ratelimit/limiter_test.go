@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	t.Run("allows the burst then refills at the rate", func(t *testing.T) {
+		// Setup
+		now := time.Now()
+		limiter := NewMemoryLimiter(WithClock(func() time.Time { return now }))
+		limit := Limit{Rate: 2, Burst: 3}
+		ctx := context.Background()
+
+		// Execute & Verify
+		for i := 0; i < 3; i++ {
+			if allowed, _, _ := limiter.Allow(ctx, Bucket{Key: "user-1", Limit: limit}); !allowed {
+				t.Fatalf("expected call %d to be allowed", i+1)
+			}
+		}
+
+		allowed, retryAfter, err := limiter.Allow(ctx, Bucket{Key: "user-1", Limit: limit})
+		if err != nil || allowed {
+			t.Fatalf("expected the call over the burst to be rejected, got %v, %v", allowed, err)
+		}
+		if retryAfter != 500*time.Millisecond {
+			t.Errorf("expected to retry after 500ms, got %v", retryAfter)
+		}
+
+		if allowed, _, _ := limiter.Allow(ctx, Bucket{Key: "user-2", Limit: limit}); !allowed {
+			t.Error("expected other keys to have their own bucket")
+		}
+
+		now = now.Add(500 * time.Millisecond)
+		if allowed, _, _ := limiter.Allow(ctx, Bucket{Key: "user-1", Limit: limit}); !allowed {
+			t.Error("expected a token after the retry delay")
+		}
+	})
+
+	t.Run("evicts full buckets", func(t *testing.T) {
+		// Setup
+		now := time.Now()
+		limiter := NewMemoryLimiter(WithClock(func() time.Time { return now }), WithMaxKeys(2)).(*memoryLimiter)
+		limit := Limit{Rate: 1, Burst: 1}
+
+		// Execute
+		limiter.Allow(context.Background(), Bucket{Key: "a", Limit: limit})
+		limiter.Allow(context.Background(), Bucket{Key: "b", Limit: limit})
+		now = now.Add(time.Second)
+		limiter.Allow(context.Background(), Bucket{Key: "c", Limit: limit})
+
+		// Verify
+		if len(limiter.buckets) != 1 {
+			t.Errorf("expected the refilled buckets to be evicted, got %d buckets", len(limiter.buckets))
+		}
+	})
+
+	t.Run("evicts buckets by their own limit", func(t *testing.T) {
+		// Setup
+		now := time.Now()
+		limiter := NewMemoryLimiter(WithClock(func() time.Time { return now }), WithMaxKeys(2)).(*memoryLimiter)
+		slow := Limit{Rate: 0.1, Burst: 1}
+		fast := Limit{Rate: 10, Burst: 1}
+
+		// Execute
+		limiter.Allow(context.Background(), Bucket{Key: "slow", Limit: slow})
+		limiter.Allow(context.Background(), Bucket{Key: "fast", Limit: fast})
+		now = now.Add(time.Second)
+		limiter.Allow(context.Background(), Bucket{Key: "new", Limit: fast})
+
+		// Verify
+		if _, ok := limiter.buckets["slow"]; !ok {
+			t.Error("expected the bucket still refilling at its own rate to be kept")
+		}
+		if _, ok := limiter.buckets["fast"]; ok {
+			t.Error("expected the refilled bucket to be evicted")
+		}
+	})
+
+	t.Run("spends no token when a bucket is empty", func(t *testing.T) {
+		// Setup
+		now := time.Now()
+		limiter := NewMemoryLimiter(WithClock(func() time.Time { return now }))
+		user := Bucket{Key: "user-1", Limit: Limit{Rate: 1, Burst: 2}}
+		ip := Bucket{Key: "10.0.0.1", Limit: Limit{Rate: 0.5, Burst: 1}}
+		ctx := context.Background()
+
+		// Execute
+		if allowed, _, _ := limiter.Allow(ctx, user, ip); !allowed {
+			t.Fatal("expected the first call to be allowed")
+		}
+		allowed, retryAfter, err := limiter.Allow(ctx, user, ip)
+
+		// Verify
+		if err != nil || allowed {
+			t.Fatalf("expected the call over the ip limit to be rejected, got %v, %v", allowed, err)
+		}
+		if retryAfter != 2*time.Second {
+			t.Errorf("expected to retry once every bucket has a token, after 2s, got %v", retryAfter)
+		}
+		if allowed, _, _ := limiter.Allow(ctx, user); !allowed {
+			t.Error("expected the rejected call to leave the user bucket untouched")
+		}
+	})
+}
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("0.5:3")
+	if err != nil || limit.Rate != 0.5 || limit.Burst != 3 {
+		t.Errorf("expected 0.5:3, got %+v, %v", limit, err)
+	}
+
+	for _, s := range []string{"", "1", "a:3", "1:b", "0:3", "1:0"} {
+		if _, err := ParseLimit(s); err == nil {
+			t.Errorf("expected %q to be rejected", s)
+		}
+	}
+}
+
+func TestRedisLimiter(t *testing.T) {
+	url := os.Getenv("TRIP_TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TRIP_TEST_REDIS_URL is not set")
+	}
+
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatalf("invalid TRIP_TEST_REDIS_URL: %v", err)
+	}
+	client := redis.NewClient(opts)
+	t.Cleanup(func() { client.Close() })
+
+	// Setup
+	limiter := NewRedisLimiter(client, fmt.Sprintf("trip-service-test:%d:", time.Now().UnixNano()))
+	limit := Limit{Rate: 1, Burst: 2}
+	ctx := context.Background()
+
+	// Execute & Verify
+	for i := 0; i < 2; i++ {
+		if allowed, _, err := limiter.Allow(ctx, Bucket{Key: "user-1", Limit: limit}); err != nil || !allowed {
+			t.Fatalf("expected call %d to be allowed, got %v, %v", i+1, allowed, err)
+		}
+	}
+
+	allowed, retryAfter, err := limiter.Allow(ctx, Bucket{Key: "user-1", Limit: limit})
+	if err != nil || allowed {
+		t.Fatalf("expected the call over the burst to be rejected, got %v, %v", allowed, err)
+	}
+	if retryAfter <= 0 || retryAfter > time.Second {
+		t.Errorf("expected to retry within a second, got %v", retryAfter)
+	}
+}
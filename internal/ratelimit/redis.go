@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript refills the buckets of KEYS, whose rate and burst
+// follow in ARGV pair by pair, and takes a token from each only when all of
+// them have one. It uses the Redis clock so every replica agrees on the
+// time. It returns whether the call is allowed and otherwise the
+// milliseconds until every bucket has a token.
+var tokenBucketScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local tokens = {}
+local retry = 0
+for i, key in ipairs(KEYS) do
+	local rate = tonumber(ARGV[2 * i - 1])
+	local burst = tonumber(ARGV[2 * i])
+
+	local state = redis.call('HMGET', key, 'tokens', 'ts')
+	local current = tonumber(state[1])
+	local ts = tonumber(state[2])
+	if current == nil or ts == nil then
+		current = burst
+		ts = now
+	end
+
+	tokens[i] = math.min(burst, current + math.max(0, now - ts) / 1000 * rate)
+	if tokens[i] < 1 then
+		retry = math.max(retry, math.ceil((1 - tokens[i]) / rate * 1000))
+	end
+end
+
+local allowed = 0
+if retry == 0 then
+	allowed = 1
+end
+
+for i, key in ipairs(KEYS) do
+	local rate = tonumber(ARGV[2 * i - 1])
+	local burst = tonumber(ARGV[2 * i])
+	if allowed == 1 then
+		tokens[i] = tokens[i] - 1
+	end
+	redis.call('HSET', key, 'tokens', tostring(tokens[i]), 'ts', now)
+	redis.call('PEXPIRE', key, math.ceil(burst / rate * 1000))
+end
+return {allowed, retry}
+`)
+
+// redisLimiter shares buckets between replicas through Redis. The buckets of
+// a call are updated by one script, so on a Redis Cluster their keys must
+// hash to the same slot: callers put a shared {hash tag} in the keys.
+type redisLimiter struct {
+	client redis.Scripter
+	prefix string
+}
+
+func NewRedisLimiter(client redis.Scripter, prefix string) Limiter {
+	return &redisLimiter{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (l *redisLimiter) Allow(ctx context.Context, buckets ...Bucket) (bool, time.Duration, error) {
+	if len(buckets) == 0 {
+		return true, 0, nil
+	}
+
+	keys := make([]string, len(buckets))
+	args := make([]interface{}, 0, 2*len(buckets))
+	for i, b := range buckets {
+		keys[i] = l.prefix + b.Key
+		args = append(args, b.Limit.Rate, b.Limit.Burst)
+	}
+
+	result, err := tokenBucketScript.Run(ctx, l.client, keys, args...).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to run the rate limit script: %w", err)
+	}
+
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit script result: %v", result)
+	}
+
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
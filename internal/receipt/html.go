@@ -0,0 +1,55 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"github.com/ride4Low/trip-service/internal/domain"
+)
+
+var htmlTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"amount":   formatAmount,
+	"distance": formatDistance,
+	"duration": formatDuration,
+	"datetime": func(r *domain.Receipt) string { return r.CompletedAt.UTC().Format("2006-01-02 15:04 MST") },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Receipt {{.TripID}}</title>
+<style>
+body { font-family: sans-serif; max-width: 480px; margin: 2em auto; color: #222; }
+table { width: 100%; border-collapse: collapse; }
+td { padding: 4px 0; }
+td.amount { text-align: right; }
+tr.total td { border-top: 1px solid #222; font-weight: bold; }
+.muted { color: #777; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Trip receipt</h1>
+<p class="muted">Trip {{.TripID}} &middot; completed {{datetime .}}</p>
+<h2>Route</h2>
+<p>{{if .Route.Pickup}}{{printf "%.5f, %.5f" .Route.Pickup.Latitude .Route.Pickup.Longitude}} &rarr; {{printf "%.5f, %.5f" .Route.Dropoff.Latitude .Route.Dropoff.Longitude}}<br>{{end}}{{distance .Route.DistanceMeters}} &middot; {{duration .Route.DurationSeconds}}</p>
+{{if .Driver}}<h2>Driver</h2>
+<p>{{.Driver.Name}} &middot; {{.Driver.CarPlate}} &middot; {{.PackageSlug}}</p>
+{{end}}<h2>Fare</h2>
+<table>
+{{$currency := .Currency}}{{range .Fare}}<tr><td>{{.Label}}</td><td class="amount">{{amount .AmountInCents $currency}}</td></tr>
+{{end}}{{if .DiscountInCents}}<tr><td>Discount</td><td class="amount">-{{amount .DiscountInCents .Currency}}</td></tr>
+{{end}}{{if .TaxInCents}}<tr><td class="muted">Included tax</td><td class="amount muted">{{amount .TaxInCents .Currency}}</td></tr>
+{{end}}{{if .TipInCents}}<tr><td>Tip</td><td class="amount">{{amount .TipInCents .Currency}}</td></tr>
+{{end}}<tr class="total"><td>Total</td><td class="amount">{{amount .TotalInCents .Currency}}</td></tr>
+</table>
+</body>
+</html>
+`))
+
+func renderHTML(r *domain.Receipt) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, r); err != nil {
+		return nil, fmt.Errorf("failed to render receipt: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,101 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ride4Low/trip-service/internal/domain"
+)
+
+// receiptLines lays out a receipt as plain text lines for the PDF renderer
+func receiptLines(r *domain.Receipt) []string {
+	lines := []string{
+		"Trip receipt",
+		"",
+		"Trip: " + r.TripID,
+		"Completed: " + r.CompletedAt.UTC().Format("2006-01-02 15:04 MST"),
+		"",
+		fmt.Sprintf("Route: %s, %s", formatDistance(r.Route.DistanceMeters), formatDuration(r.Route.DurationSeconds)),
+	}
+	if r.Route.Pickup != nil && r.Route.Dropoff != nil {
+		lines = append(lines, fmt.Sprintf("From %.5f, %.5f to %.5f, %.5f",
+			r.Route.Pickup.Latitude, r.Route.Pickup.Longitude,
+			r.Route.Dropoff.Latitude, r.Route.Dropoff.Longitude))
+	}
+	if r.Driver != nil {
+		lines = append(lines, fmt.Sprintf("Driver: %s, %s (%s)", r.Driver.Name, r.Driver.CarPlate, r.PackageSlug))
+	}
+
+	lines = append(lines, "")
+	for _, item := range r.Fare {
+		lines = append(lines, item.Label+": "+formatAmount(item.AmountInCents, r.Currency))
+	}
+	if r.DiscountInCents > 0 {
+		lines = append(lines, "Discount: -"+formatAmount(r.DiscountInCents, r.Currency))
+	}
+	if r.TaxInCents > 0 {
+		lines = append(lines, "Included tax: "+formatAmount(r.TaxInCents, r.Currency))
+	}
+	if r.TipInCents > 0 {
+		lines = append(lines, "Tip: "+formatAmount(r.TipInCents, r.Currency))
+	}
+	lines = append(lines, "Total: "+formatAmount(r.TotalInCents, r.Currency))
+
+	return lines
+}
+
+// renderPDF writes a single page PDF with the receipt as Helvetica text. The
+// layout is simple enough that a PDF library is not worth the dependency.
+func renderPDF(r *domain.Receipt) []byte {
+	var content bytes.Buffer
+	content.WriteString("BT\n/F1 11 Tf\n14 TL\n50 790 Td\n")
+	for _, line := range receiptLines(r) {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", escapePDFString(line))
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
+
+// escapePDFString escapes a PDF literal string. Characters outside the
+// standard font encoding are replaced.
+func escapePDFString(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(c)
+		case c < 32 || c > 126:
+			b.WriteRune('?')
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
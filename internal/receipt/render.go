@@ -0,0 +1,44 @@
+package receipt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/ride4Low/trip-service/internal/domain"
+)
+
+// Render renders a receipt in the given format and returns it together with
+// its content type. An empty format renders JSON.
+func Render(r *domain.Receipt, format string) ([]byte, string, error) {
+	switch strings.ToLower(format) {
+	case "", domain.ReceiptFormatJSON:
+		data, err := sonic.Marshal(r)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to marshal receipt: %w", err)
+		}
+		return data, "application/json", nil
+	case domain.ReceiptFormatHTML:
+		data, err := renderHTML(r)
+		if err != nil {
+			return nil, "", err
+		}
+		return data, "text/html; charset=utf-8", nil
+	case domain.ReceiptFormatPDF:
+		return renderPDF(r), "application/pdf", nil
+	default:
+		return nil, "", fmt.Errorf("%w: %s", domain.ErrUnsupportedReceiptFormat, format)
+	}
+}
+
+func formatAmount(cents float64, currency string) string {
+	return fmt.Sprintf("%.2f %s", cents/100, currency)
+}
+
+func formatDistance(meters float64) string {
+	return fmt.Sprintf("%.1f km", meters/1000)
+}
+
+func formatDuration(seconds float64) string {
+	return fmt.Sprintf("%.0f min", seconds/60)
+}
@@ -0,0 +1,95 @@
+package receipt
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/ride4Low/trip-service/internal/domain"
+)
+
+func testReceipt() *domain.Receipt {
+	return &domain.Receipt{
+		TripID:      "trip-123",
+		RiderID:     "rider-1",
+		PackageSlug: "suv",
+		Currency:    "USD",
+		Route: domain.ReceiptRoute{
+			Pickup:          &domain.Coordinate{Latitude: 13.736717, Longitude: 100.523186},
+			Dropoff:         &domain.Coordinate{Latitude: 13.746717, Longitude: 100.533186},
+			DistanceMeters:  1000,
+			DurationSeconds: 600,
+		},
+		Driver: &domain.ReceiptDriver{Name: "Jane (Doe)", CarPlate: "AB-123"},
+		Fare: []domain.ReceiptLineItem{
+			{Label: "Base fare", AmountInCents: 200},
+			{Label: "Distance", AmountInCents: 1500},
+			{Label: "Time", AmountInCents: 150},
+		},
+		FareInCents:  1850,
+		TipInCents:   300,
+		TotalInCents: 2150,
+		CompletedAt:  time.Date(2025, 1, 2, 10, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		content, contentType, err := Render(testReceipt(), domain.ReceiptFormatJSON)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if contentType != "application/json" {
+			t.Errorf("unexpected content type %s", contentType)
+		}
+
+		var decoded domain.Receipt
+		if err := sonic.Unmarshal(content, &decoded); err != nil {
+			t.Fatalf("expected valid json, got %v", err)
+		}
+		if decoded.TotalInCents != 2150 {
+			t.Errorf("expected total 2150, got %f", decoded.TotalInCents)
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		content, contentType, err := Render(testReceipt(), domain.ReceiptFormatHTML)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !strings.HasPrefix(contentType, "text/html") {
+			t.Errorf("unexpected content type %s", contentType)
+		}
+		for _, expected := range []string{"trip-123", "Tip", "3.00 USD", "21.50 USD", "AB-123"} {
+			if !bytes.Contains(content, []byte(expected)) {
+				t.Errorf("expected html to contain %q", expected)
+			}
+		}
+	})
+
+	t.Run("pdf", func(t *testing.T) {
+		content, contentType, err := Render(testReceipt(), domain.ReceiptFormatPDF)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if contentType != "application/pdf" {
+			t.Errorf("unexpected content type %s", contentType)
+		}
+		if !bytes.HasPrefix(content, []byte("%PDF-1.4")) || !bytes.HasSuffix(content, []byte("%%EOF\n")) {
+			t.Error("expected a complete pdf document")
+		}
+		if !bytes.Contains(content, []byte(`(Driver: Jane \(Doe\), AB-123 \(suv\)) Tj`)) {
+			t.Error("expected parentheses in text to be escaped")
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, _, err := Render(testReceipt(), "docx")
+		if !errors.Is(err, domain.ErrUnsupportedReceiptFormat) {
+			t.Errorf("expected ErrUnsupportedReceiptFormat, got %v", err)
+		}
+	})
+}
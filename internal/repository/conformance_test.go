@@ -0,0 +1,586 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ride4Low/contracts/proto/driver"
+	"github.com/ride4Low/contracts/proto/trip"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// runRepositoryConformance checks the behaviour every domain.Repository
+// implementation must share, so the in-memory repository can stand in for
+// Mongo without callers noticing.
+func runRepositoryConformance(t *testing.T, newRepo func(t *testing.T) domain.Repository) {
+	ctx := context.Background()
+
+	t.Run("ride fares", func(t *testing.T) {
+		repo := newRepo(t)
+
+		fare := &types.RideFare{UserID: "user-123", PackageSlug: "suv", TotalPriceInCents: 1850}
+		if err := repo.SaveRideFare(ctx, fare); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if fare.ID.IsZero() {
+			t.Fatal("expected ID to be set")
+		}
+		if fare.CreatedAt.IsZero() {
+			t.Error("expected CreatedAt to be set")
+		}
+
+		got, err := repo.GetRideFareByID(ctx, fare.ID.Hex())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got.UserID != "user-123" || got.PackageSlug != "suv" || got.TotalPriceInCents != 1850 {
+			t.Errorf("unexpected fare: %+v", got)
+		}
+
+		if _, err := repo.GetRideFareByID(ctx, primitive.NewObjectID().Hex()); !errors.Is(err, domain.ErrFareNotFound) {
+			t.Errorf("expected ErrFareNotFound for an unknown fare, got %v", err)
+		}
+		if _, err := repo.GetRideFareByID(ctx, "not-an-id"); !errors.Is(err, domain.ErrInvalidID) {
+			t.Errorf("expected ErrInvalidID for an invalid id, got %v", err)
+		}
+	})
+
+	t.Run("quote fares are saved whole", func(t *testing.T) {
+		repo := newRepo(t)
+
+		// The fares of a preview are written with their quote, in one document
+		quote := &domain.Quote{
+			UserID: "user-123",
+			Fares: []domain.QuoteFare{
+				{FareID: primitive.NewObjectID(), PackageSlug: "suv", TotalPriceInCents: 1850},
+				{FareID: primitive.NewObjectID(), PackageSlug: "sedan", TotalPriceInCents: 2000},
+			},
+		}
+		if err := repo.SaveQuote(ctx, quote); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		for _, fare := range quote.Fares {
+			if _, err := repo.GetRideFareByID(ctx, fare.FareID.Hex()); err != nil {
+				t.Errorf("expected fare %s to be saved, got %v", fare.PackageSlug, err)
+			}
+		}
+
+		// A failed write saves none of its fares
+		failed := &domain.Quote{
+			ID:     quote.ID,
+			UserID: "user-123",
+			Fares:  []domain.QuoteFare{{FareID: primitive.NewObjectID(), PackageSlug: "van", TotalPriceInCents: 2050}},
+		}
+		if err := repo.SaveQuote(ctx, failed); !mongoDriver.IsDuplicateKeyError(err) {
+			t.Fatalf("expected a duplicate key error, got %v", err)
+		}
+		if _, err := repo.GetRideFareByID(ctx, failed.Fares[0].FareID.Hex()); !errors.Is(err, domain.ErrFareNotFound) {
+			t.Errorf("expected no fare of the failed quote to be saved, got %v", err)
+		}
+	})
+
+	t.Run("quotes", func(t *testing.T) {
+		repo := newRepo(t)
+
+		route := &types.OsrmApiResponse{}
+		route.Routes = make([]struct {
+			Distance float64 `json:"distance"`
+			Duration float64 `json:"duration"`
+			Geometry struct {
+				Coordinates [][]float64 `json:"coordinates"`
+			} `json:"geometry"`
+		}, 1)
+		route.Routes[0].Distance = 1000
+		route.Routes[0].Geometry.Coordinates = [][]float64{{100.0, 13.0}, {100.1, 13.1}}
+
+		// The fares of a quote are only stored on the quote
+		fare := &types.RideFare{ID: primitive.NewObjectID()}
+
+		before := time.Now().Add(-time.Minute)
+		quote := &domain.Quote{
+			UserID:   "user-123",
+			RouteKey: "route-key",
+			Route:    route,
+			Fares:    []domain.QuoteFare{{FareID: fare.ID, PackageSlug: "suv", TotalPriceInCents: 1850}},
+		}
+		if err := repo.SaveQuote(ctx, quote); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if quote.ID.IsZero() || quote.CreatedAt.IsZero() {
+			t.Fatalf("expected ID and CreatedAt to be set, got %+v", quote)
+		}
+
+		got, err := repo.FindRecentQuote(ctx, "user-123", "route-key", before)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got == nil || got.ID != quote.ID || len(got.Fares) != 1 || got.Fares[0].FareID != fare.ID {
+			t.Errorf("unexpected quote: %+v", got)
+		}
+
+		for _, tc := range []struct{ userID, routeKey string }{
+			{"user-456", "route-key"},
+			{"user-123", "other-route"},
+		} {
+			got, err := repo.FindRecentQuote(ctx, tc.userID, tc.routeKey, before)
+			if err != nil || got != nil {
+				t.Errorf("expected no quote for %s on %s, got %+v, %v", tc.userID, tc.routeKey, got, err)
+			}
+		}
+		if got, err := repo.FindRecentQuote(ctx, "user-123", "route-key", time.Now().Add(time.Minute)); err != nil || got != nil {
+			t.Errorf("expected no quote older than the window, got %+v, %v", got, err)
+		}
+
+		// The fare is booked with the route of its quote
+		gotFare, err := repo.GetRideFareByID(ctx, fare.ID.Hex())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if gotFare.Route == nil || len(gotFare.Route.Routes) != 1 || gotFare.Route.Routes[0].Distance != 1000 {
+			t.Errorf("expected the route of the quote, got %+v", gotFare.Route)
+		}
+		if gotFare.ID != fare.ID || gotFare.UserID != "user-123" || gotFare.TotalPriceInCents != 1850 || gotFare.CreatedAt.IsZero() {
+			t.Errorf("expected the fare of the quote, got %+v", gotFare)
+		}
+	})
+
+	t.Run("trips", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateTrip(ctx, &types.Trip{
+			ID:       primitive.NewObjectID(),
+			UserID:   "user-123",
+			Status:   domain.TripStatusPending,
+			RideFare: &types.RideFare{PackageSlug: "suv", TotalPriceInCents: 1850},
+			Driver:   &trip.TripDriver{},
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		tripID := created.ID.Hex()
+
+		got, err := repo.GetTripByID(ctx, tripID)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got.Status != domain.TripStatusPending || got.RideFare.TotalPriceInCents != 1850 {
+			t.Errorf("unexpected trip: %+v", got)
+		}
+
+		if _, err := repo.CreateTrip(ctx, created); !mongoDriver.IsDuplicateKeyError(err) {
+			t.Errorf("expected a duplicate key error, got %v", err)
+		}
+		if _, err := repo.GetTripByID(ctx, primitive.NewObjectID().Hex()); !errors.Is(err, domain.ErrTripNotFound) {
+			t.Errorf("expected ErrTripNotFound for an unknown trip, got %v", err)
+		}
+		if _, err := repo.GetTripByID(ctx, "not-an-id"); !errors.Is(err, domain.ErrInvalidID) {
+			t.Errorf("expected ErrInvalidID for an invalid id, got %v", err)
+		}
+
+		// Updates
+		if err := repo.UpdateTrip(ctx, tripID, domain.TripStatusAccepted, &driver.Driver{Id: "driver-1", Name: "Jane"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		got, err = repo.GetTripByID(ctx, tripID)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got.Status != domain.TripStatusAccepted || got.Driver.GetId() != "driver-1" {
+			t.Errorf("expected accepted trip with driver-1, got %+v", got)
+		}
+
+		if err := repo.UpdateTrip(ctx, tripID, domain.TripStatusAccepted, nil); !errors.Is(err, domain.ErrTripNotModified) {
+			t.Errorf("expected ErrTripNotModified when the update changes nothing, got %v", err)
+		}
+		if err := repo.UpdateTrip(ctx, primitive.NewObjectID().Hex(), domain.TripStatusPaid, nil); !errors.Is(err, domain.ErrTripNotFound) {
+			t.Errorf("expected ErrTripNotFound for an unknown trip, got %v", err)
+		}
+		if err := repo.UpdateTrip(ctx, "not-an-id", domain.TripStatusPaid, nil); !errors.Is(err, domain.ErrInvalidID) {
+			t.Errorf("expected ErrInvalidID for an invalid id, got %v", err)
+		}
+
+		// Replace upserts
+		replaced := &types.Trip{ID: primitive.NewObjectID(), UserID: "user-456", Status: domain.TripStatusPaid}
+		if err := repo.ReplaceTrip(ctx, replaced); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		replaced.Status = domain.TripStatusAccepted
+		if err := repo.ReplaceTrip(ctx, replaced); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		got, err = repo.GetTripByID(ctx, replaced.ID.Hex())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got.Status != domain.TripStatusAccepted || got.UserID != "user-456" {
+			t.Errorf("unexpected replaced trip: %+v", got)
+		}
+	})
+
+	t.Run("trip events", func(t *testing.T) {
+		repo := newRepo(t)
+
+		tripA, tripB := primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex()
+		base := time.Now().Truncate(time.Millisecond)
+		events := []*domain.TripEvent{
+			{TripID: tripA, Type: domain.TripEventStatusChanged, ToStatus: domain.TripStatusAccepted, Version: 2, CreatedAt: base.Add(time.Second)},
+			{TripID: tripA, Type: domain.TripEventCreated, ToStatus: domain.TripStatusPending, Version: 1, CreatedAt: base},
+			{TripID: tripB, Type: domain.TripEventCreated, ToStatus: domain.TripStatusPending},
+		}
+		for _, e := range events {
+			if err := repo.SaveTripEvent(ctx, e); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if e.ID.IsZero() {
+				t.Error("expected ID to be set")
+			}
+		}
+
+		got, err := repo.GetTripEvents(ctx, tripA)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(got) != 2 || got[0].Version != 1 || got[1].Version != 2 {
+			t.Fatalf("expected trip A events in chronological order, got %+v", got)
+		}
+
+		duplicate := &domain.TripEvent{TripID: tripA, Type: domain.TripEventStatusChanged, Version: 2}
+		if err := repo.SaveTripEvent(ctx, duplicate); !mongoDriver.IsDuplicateKeyError(err) {
+			t.Errorf("expected a duplicate key error for a reused version, got %v", err)
+		}
+
+		empty, err := repo.GetTripEvents(ctx, primitive.NewObjectID().Hex())
+		if err != nil || empty == nil || len(empty) != 0 {
+			t.Errorf("expected an empty timeline for an unknown trip, got %v, %v", empty, err)
+		}
+
+		ids, err := repo.GetEventTripIDs(ctx)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		sort.Strings(ids)
+		expected := []string{tripA, tripB}
+		sort.Strings(expected)
+		if fmt.Sprint(ids) != fmt.Sprint(expected) {
+			t.Errorf("expected trip ids %v, got %v", expected, ids)
+		}
+	})
+
+	t.Run("ratings and tips", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateTrip(ctx, &types.Trip{ID: primitive.NewObjectID(), UserID: "user-123", Status: domain.TripStatusPaid})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		tripID := created.ID.Hex()
+
+		rating := &domain.Rating{TripID: tripID, RaterRole: domain.RaterRider, RaterID: "user-123", Stars: 5}
+		if err := repo.SaveRating(ctx, rating); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		again := &domain.Rating{TripID: tripID, RaterRole: domain.RaterRider, RaterID: "user-123", Stars: 1}
+		if err := repo.SaveRating(ctx, again); !errors.Is(err, domain.ErrAlreadyRated) {
+			t.Errorf("expected ErrAlreadyRated, got %v", err)
+		}
+		if got, err := repo.GetRating(ctx, tripID, domain.RaterRider); err != nil || got == nil || got.ID != rating.ID || got.Stars != 5 {
+			t.Errorf("expected the first rating, got %+v, %v", got, err)
+		}
+		if got, err := repo.GetRating(ctx, tripID, domain.RaterDriver); err != nil || got != nil {
+			t.Errorf("expected no driver rating yet, got %+v, %v", got, err)
+		}
+		orphan := &domain.Rating{TripID: primitive.NewObjectID().Hex(), RaterRole: domain.RaterRider, RaterID: "user-123", Stars: 5}
+		if err := repo.SaveRating(ctx, orphan); !errors.Is(err, domain.ErrTripNotFound) {
+			t.Errorf("expected ErrTripNotFound for an unknown trip, got %v", err)
+		}
+		if data, err := repo.GetUserData(ctx, "user-123"); err != nil || len(data.Ratings) != 1 {
+			t.Errorf("expected no rating to be left for the unknown trip, got %+v, %v", data, err)
+		}
+		driverRating := &domain.Rating{TripID: tripID, RaterRole: domain.RaterDriver, RaterID: "driver-1", Stars: 4}
+		if err := repo.SaveRating(ctx, driverRating); err != nil {
+			t.Errorf("expected the driver to be able to rate too, got %v", err)
+		}
+
+		tip, err := repo.GetTip(ctx, tripID)
+		if err != nil || tip != nil {
+			t.Fatalf("expected no tip yet, got %v, %v", tip, err)
+		}
+		if err := repo.SaveTip(ctx, tripID, &domain.Tip{AmountInCents: 300, RiderID: "user-123"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if err := repo.SaveTip(ctx, tripID, &domain.Tip{AmountInCents: 500}); !errors.Is(err, domain.ErrTipAlreadyAdded) {
+			t.Errorf("expected ErrTipAlreadyAdded, got %v", err)
+		}
+		if err := repo.SaveTip(ctx, primitive.NewObjectID().Hex(), &domain.Tip{AmountInCents: 300}); !errors.Is(err, domain.ErrTripNotFound) {
+			t.Errorf("expected ErrTripNotFound for an unknown trip, got %v", err)
+		}
+		if _, err := repo.GetTip(ctx, primitive.NewObjectID().Hex()); !errors.Is(err, domain.ErrTripNotFound) {
+			t.Errorf("expected ErrTripNotFound for an unknown trip, got %v", err)
+		}
+		tip, err = repo.GetTip(ctx, tripID)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if tip == nil || tip.AmountInCents != 300 {
+			t.Errorf("expected a tip of 300, got %+v", tip)
+		}
+
+		// Projecting the trip again keeps its tip
+		if err := repo.ReplaceTrip(ctx, &types.Trip{ID: created.ID, UserID: "user-123", Status: domain.TripStatusPaid}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if tip, err := repo.GetTip(ctx, tripID); err != nil || tip == nil || tip.AmountInCents != 300 {
+			t.Errorf("expected the tip to survive the projection, got %+v, %v", tip, err)
+		}
+		if err := repo.SaveTip(ctx, tripID, &domain.Tip{AmountInCents: 500}); !errors.Is(err, domain.ErrTipAlreadyAdded) {
+			t.Errorf("expected ErrTipAlreadyAdded after the projection, got %v", err)
+		}
+
+		// The trip document itself is unaffected by ratings and tips
+		got, err := repo.GetTripByID(ctx, tripID)
+		if err != nil || got.Status != domain.TripStatusPaid {
+			t.Errorf("expected the paid trip to be readable, got %+v, %v", got, err)
+		}
+	})
+
+	t.Run("user data", func(t *testing.T) {
+		for _, pseudonym := range []string{"erased-1", ""} {
+			repo := newRepo(t)
+
+			fare := &types.RideFare{UserID: "user-123", PackageSlug: "suv", TotalPriceInCents: 1850}
+			if err := repo.SaveRideFare(ctx, fare); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			quote := &domain.Quote{UserID: "user-123", RouteKey: "route-1", Route: &types.OsrmApiResponse{}, Fares: []domain.QuoteFare{{FareID: fare.ID, PackageSlug: "suv", TotalPriceInCents: 1850}}}
+			if err := repo.SaveQuote(ctx, quote); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			created, err := repo.CreateTrip(ctx, &types.Trip{ID: primitive.NewObjectID(), UserID: "user-123", Status: domain.TripStatusPaid, RideFare: fare})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			tripID := created.ID.Hex()
+			if _, err := repo.CreateTrip(ctx, &types.Trip{ID: primitive.NewObjectID(), UserID: "user-456", Status: domain.TripStatusPaid}); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if err := repo.SaveTripEvent(ctx, &domain.TripEvent{TripID: tripID, Type: domain.TripEventCreated, Version: 1, Actor: domain.Actor{Type: domain.ActorRider, ID: "user-123"}}); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if err := repo.SaveRating(ctx, &domain.Rating{TripID: tripID, RaterRole: domain.RaterRider, RaterID: "user-123", RateeID: "driver-1", Stars: 5, Comment: "Great ride"}); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			data, err := repo.GetUserData(ctx, "user-123")
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(data.Trips) != 1 || len(data.Trips[0].Events) != 1 || len(data.RideFares) != 1 || len(data.Quotes) != 1 || len(data.Ratings) != 1 {
+				t.Fatalf("expected only the data of user-123, got %+v", data)
+			}
+
+			report, err := repo.EraseUserData(ctx, "user-123", pseudonym)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			want := domain.ErasureReport{Trips: 1, TripEvents: 1, Ratings: 1, RideFares: 1, Quotes: 1}
+			if *report != want {
+				t.Errorf("expected report %+v, got %+v", want, *report)
+			}
+
+			trip, err := repo.GetTripByID(ctx, tripID)
+			if err != nil {
+				t.Fatalf("expected the erased trip to be kept, got %v", err)
+			}
+			if trip.UserID != pseudonym || trip.Status != domain.TripStatusPaid {
+				t.Errorf("expected user ID %q on the kept trip, got %+v", pseudonym, trip)
+			}
+			if _, err := repo.GetRideFareByID(ctx, fare.ID.Hex()); !errors.Is(err, domain.ErrFareNotFound) {
+				t.Errorf("expected the ride fare to be deleted, got %v", err)
+			}
+			events, err := repo.GetTripEvents(ctx, tripID)
+			if err != nil || len(events) != 1 || events[0].Actor.ID != pseudonym {
+				t.Errorf("expected the event actor to be erased, got %+v, %v", events, err)
+			}
+
+			if data, err := repo.GetUserData(ctx, "user-123"); err != nil || len(data.Trips) != 0 || len(data.Quotes) != 0 || len(data.Ratings) != 0 {
+				t.Errorf("expected nothing left for user-123, got %+v, %v", data, err)
+			}
+			if data, err := repo.GetUserData(ctx, "user-456"); err != nil || len(data.Trips) != 1 {
+				t.Errorf("expected other users to be untouched, got %+v, %v", data, err)
+			}
+		}
+	})
+
+	t.Run("trip search", func(t *testing.T) {
+		repo := newRepo(t)
+
+		seed := []*types.Trip{
+			{UserID: "user-123", Status: domain.TripStatusPending, RideFare: &types.RideFare{PackageSlug: "suv"}, Driver: &trip.TripDriver{}},
+			{UserID: "user-123", Status: domain.TripStatusAccepted, RideFare: &types.RideFare{PackageSlug: "sedan"}, Driver: &trip.TripDriver{Id: "driver-1"}},
+			{UserID: "user-456", Status: domain.TripStatusAccepted, RideFare: &types.RideFare{PackageSlug: "suv"}, Driver: &trip.TripDriver{Id: "driver-2"}},
+		}
+		var ids []string
+		for _, tr := range seed {
+			tr.ID = primitive.NewObjectID()
+			if _, err := repo.CreateTrip(ctx, tr); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			ids = append(ids, tr.ID.Hex())
+		}
+
+		tests := []struct {
+			name   string
+			filter domain.TripFilter
+			want   []string
+		}{
+			{name: "everything", want: ids},
+			{name: "statuses", filter: domain.TripFilter{Statuses: []string{domain.TripStatusAccepted, domain.TripStatusPaid}}, want: ids[1:]},
+			{name: "user", filter: domain.TripFilter{UserID: "user-123"}, want: ids[:2]},
+			{name: "driver", filter: domain.TripFilter{DriverID: "driver-2"}, want: ids[2:]},
+			{name: "package", filter: domain.TripFilter{PackageSlug: "suv", UserID: "user-456"}, want: ids[2:]},
+			{name: "created", filter: domain.TripFilter{CreatedAfter: time.Now().Add(-time.Hour), CreatedBefore: time.Now().Add(time.Hour)}, want: ids},
+			{name: "created later", filter: domain.TripFilter{CreatedAfter: time.Now().Add(time.Hour)}, want: nil},
+			{name: "page", filter: domain.TripFilter{Limit: 2}, want: ids[:2]},
+			{name: "next page", filter: domain.TripFilter{AfterID: ids[1], Limit: 2}, want: ids[2:]},
+		}
+
+		for _, tt := range tests {
+			got, err := repo.SearchTrips(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("%s: expected no error, got %v", tt.name, err)
+			}
+			var gotIDs []string
+			for _, tr := range got {
+				gotIDs = append(gotIDs, tr.ID.Hex())
+			}
+			if fmt.Sprint(gotIDs) != fmt.Sprint(tt.want) {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.want, gotIDs)
+			}
+		}
+
+		if _, err := repo.SearchTrips(ctx, domain.TripFilter{AfterID: "not-an-id"}); !errors.Is(err, domain.ErrInvalidID) {
+			t.Errorf("expected ErrInvalidID for an invalid page token, got %v", err)
+		}
+	})
+
+	t.Run("trip drivers", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateTrip(ctx, &types.Trip{ID: primitive.NewObjectID(), UserID: "user-123", Status: domain.TripStatusAccepted, Driver: &trip.TripDriver{Id: "driver-1"}})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		tripID := created.ID.Hex()
+
+		if err := repo.SetTripDriver(ctx, tripID, &trip.TripDriver{Id: "driver-2", Name: "Joe"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		got, err := repo.GetTripByID(ctx, tripID)
+		if err != nil || got.Driver.GetId() != "driver-2" || got.Status != domain.TripStatusAccepted {
+			t.Errorf("expected driver-2 on the accepted trip, got %+v, %v", got, err)
+		}
+
+		if err := repo.SetTripDriver(ctx, tripID, nil); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		got, err = repo.GetTripByID(ctx, tripID)
+		if err != nil || got.Driver.GetId() != "" {
+			t.Errorf("expected the driver to be unassigned, got %+v, %v", got, err)
+		}
+
+		if err := repo.SetTripDriver(ctx, tripID, nil); !errors.Is(err, domain.ErrTripNotModified) {
+			t.Errorf("expected ErrTripNotModified when the driver is unchanged, got %v", err)
+		}
+		if err := repo.SetTripDriver(ctx, primitive.NewObjectID().Hex(), nil); !errors.Is(err, domain.ErrTripNotFound) {
+			t.Errorf("expected ErrTripNotFound for an unknown trip, got %v", err)
+		}
+	})
+
+	t.Run("quote expiry", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var quotes []*domain.Quote
+		for _, userID := range []string{"user-123", "user-123", "user-456"} {
+			fare := &types.RideFare{UserID: userID, PackageSlug: "suv", TotalPriceInCents: 1850}
+			if err := repo.SaveRideFare(ctx, fare); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			quote := &domain.Quote{
+				UserID:   userID,
+				RouteKey: "route-key",
+				Route:    &types.OsrmApiResponse{},
+				Fares:    []domain.QuoteFare{{FareID: fare.ID, PackageSlug: "suv", TotalPriceInCents: 1850}},
+			}
+			if err := repo.SaveQuote(ctx, quote); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			quotes = append(quotes, quote)
+		}
+		expiredAt := time.Now().Add(-domain.RideFareTTL - time.Second)
+
+		expired, err := repo.ExpireQuotes(ctx, domain.QuoteFilter{IDs: []string{quotes[0].ID.Hex()}}, expiredAt)
+		if err != nil || expired != 1 {
+			t.Fatalf("expected 1 quote expired by id, got %d, %v", expired, err)
+		}
+		// Mongo purges the backdated fare in the background
+		if fare, err := repo.GetRideFareByID(ctx, quotes[0].Fares[0].FareID.Hex()); !errors.Is(err, domain.ErrFareNotFound) && (err != nil || fare.CreatedAt.After(expiredAt)) {
+			t.Errorf("expected the fare of the expired quote to be past its ttl, got %+v, %v", fare, err)
+		}
+		if got, err := repo.FindRecentQuote(ctx, "user-123", "route-key", time.Now().Add(-time.Minute)); err != nil || got == nil || got.ID != quotes[1].ID {
+			t.Errorf("expected only the other quote of user-123 to be reused, got %+v, %v", got, err)
+		}
+
+		// Already expired quotes are not counted again
+		expired, err = repo.ExpireQuotes(ctx, domain.QuoteFilter{UserID: "user-123"}, expiredAt)
+		if err != nil || expired != 1 {
+			t.Fatalf("expected 1 quote expired by user, got %d, %v", expired, err)
+		}
+		if got, err := repo.FindRecentQuote(ctx, "user-123", "route-key", time.Now().Add(-time.Minute)); err != nil || got != nil {
+			t.Errorf("expected no quote left for user-123, got %+v, %v", got, err)
+		}
+		if _, err := repo.GetRideFareByID(ctx, quotes[2].Fares[0].FareID.Hex()); err != nil {
+			t.Errorf("expected other users to be untouched, got %v", err)
+		}
+	})
+}
+
+func TestMongoRepositoryConformance(t *testing.T) {
+	uri := os.Getenv("TRIP_TEST_MONGODB_URI")
+	if uri == "" {
+		t.Skip("TRIP_TEST_MONGODB_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongoDriver.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("failed to connect to MongoDB: %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect(ctx) })
+
+	runRepositoryConformance(t, func(t *testing.T) domain.Repository {
+		db := client.Database(fmt.Sprintf("trip_service_test_%s", primitive.NewObjectID().Hex()))
+		t.Cleanup(func() { db.Drop(ctx) })
+
+		if err := mongo.CreateTripEventsIndex(ctx, db); err != nil {
+			t.Fatalf("failed to create trip events index: %v", err)
+		}
+		if err := mongo.CreateRatingsIndex(ctx, db); err != nil {
+			t.Fatalf("failed to create ratings index: %v", err)
+		}
+		if err := mongo.CreateQuotesIndex(ctx, db); err != nil {
+			t.Fatalf("failed to create quotes index: %v", err)
+		}
+
+		return NewRepository(db)
+	})
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/bsonpath"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// tripDocument is a trip with the fields stored next to it
+type tripDocument struct {
+	types.Trip `bson:",inline"`
+	Tip        *domain.Tip `bson:"tip,omitempty"`
+}
+
+// eraser removes the personal data of one rider from the documents that
+// must be kept for accounting. With an empty pseudonym the rider's ID is
+// removed, otherwise it is replaced.
+type eraser struct {
+	userID    string
+	pseudonym string
+}
+
+func (e *eraser) trip(doc bson.D) bson.D {
+	doc = bsonpath.Clone(doc)
+	for _, path := range [][]string{{"userID"}, {"rideFare", "userID"}, {"tip", "rider_id"}} {
+		doc = bsonpath.Update(doc, path, e.id)
+	}
+	doc = bsonpath.Update(doc, []string{"rideFare", "route"}, removeGeometry)
+	for _, role := range []string{"rider", "driver"} {
+		doc = bsonpath.Update(doc, []string{"ratings", role}, bsonpath.Embedded(e.rating))
+	}
+	return doc
+}
+
+func (e *eraser) tripEvent(doc bson.D) bson.D {
+	doc = bsonpath.Clone(doc)
+	doc = bsonpath.Update(doc, []string{"actor", "id"}, e.id)
+	for _, snapshot := range []string{"before", "after"} {
+		doc = bsonpath.Update(doc, []string{snapshot}, bsonpath.Embedded(e.trip))
+	}
+	return doc
+}
+
+// rating drops the comments the rider wrote, as free text may name people
+// or places
+func (e *eraser) rating(doc bson.D) bson.D {
+	doc = bsonpath.Clone(doc)
+	if lookupField(doc, "rater_id") == e.userID {
+		doc = bsonpath.Update(doc, []string{"comment"}, bsonpath.Remove)
+	}
+	doc = bsonpath.Update(doc, []string{"rater_id"}, e.id)
+	doc = bsonpath.Update(doc, []string{"ratee_id"}, e.id)
+	return doc
+}
+
+// id replaces the rider's ID and leaves the IDs of other users alone
+func (e *eraser) id(v interface{}) (interface{}, bool) {
+	if v != e.userID {
+		return v, true
+	}
+	if e.pseudonym == "" {
+		return nil, false
+	}
+	return e.pseudonym, true
+}
+
+// removeGeometry keeps the distance and duration of a route, which the
+// receipt shows, and drops the coordinates, plain or encrypted
+var removeGeometry = bsonpath.UpdateRouteLegs(func(leg bson.D) bson.D {
+	return bsonpath.Update(leg, []string{"geometry"}, bsonpath.Remove)
+})
@@ -0,0 +1,900 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ride4Low/contracts/proto/driver"
+	"github.com/ride4Low/contracts/proto/trip"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"github.com/ride4Low/trip-service/internal/bsonpath"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryRepository is an in-memory Repository for tests and local
+// development. Documents are stored BSON encoded, so they go through the same
+// marshalling as with Mongo and callers never share memory with the store.
+type memoryRepository struct {
+	mu          sync.RWMutex
+	now         func() time.Time
+	rideFareTTL time.Duration
+
+	rideFares  map[primitive.ObjectID]bson.D
+	trips      map[primitive.ObjectID]bson.D
+	tripEvents []bson.D
+	ratings    map[string]bson.D
+	quotes     map[primitive.ObjectID]bson.D
+}
+
+// MemoryOption configures the in-memory repository
+type MemoryOption func(*memoryRepository)
+
+// WithClock replaces time.Now, e.g. to expire ride fares in tests
+func WithClock(now func() time.Time) MemoryOption {
+	return func(r *memoryRepository) {
+		r.now = now
+	}
+}
+
+// WithRideFareTTL overrides how long ride fares are kept
+func WithRideFareTTL(ttl time.Duration) MemoryOption {
+	return func(r *memoryRepository) {
+		r.rideFareTTL = ttl
+	}
+}
+
+func NewMemoryRepository(opts ...MemoryOption) domain.Repository {
+	r := &memoryRepository{
+		now:         time.Now,
+		rideFareTTL: domain.RideFareTTL,
+		rideFares:   map[primitive.ObjectID]bson.D{},
+		trips:       map[primitive.ObjectID]bson.D{},
+		ratings:     map[string]bson.D{},
+		quotes:      map[primitive.ObjectID]bson.D{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *memoryRepository) SaveRideFare(ctx context.Context, rideFare *types.RideFare) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rideFare.CreatedAt = r.now()
+	if rideFare.ID.IsZero() {
+		rideFare.ID = primitive.NewObjectID()
+	}
+
+	if _, ok := r.rideFares[rideFare.ID]; ok {
+		return duplicateKeyError(mongo.RideFaresCollection, "_id")
+	}
+
+	doc, err := toDocument(rideFare)
+	if err != nil {
+		return err
+	}
+	r.rideFares[rideFare.ID] = doc
+
+	return nil
+}
+
+func (r *memoryRepository) GetRideFareByID(ctx context.Context, id string) (*types.RideFare, error) {
+	_id, err := parseObjectID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The fares of a preview are stored on its quote
+	quote, err := r.quoteOfFare(_id)
+	if err != nil {
+		return nil, err
+	}
+
+	var fare *types.RideFare
+	if quote != nil {
+		fare = quote.RideFare(_id)
+	} else if doc, ok := r.rideFares[_id]; ok {
+		fare = &types.RideFare{}
+		if err := fromDocument(doc, fare); err != nil {
+			return nil, err
+		}
+	}
+	if fare == nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrFareNotFound, id)
+	}
+
+	// Mongo removes expired quotes and fares in the background through the
+	// TTL indexes
+	if !fare.CreatedAt.Add(r.rideFareTTL).After(r.now()) {
+		if quote != nil {
+			delete(r.quotes, quote.ID)
+		}
+		delete(r.rideFares, _id)
+		return nil, fmt.Errorf("%w: %s", domain.ErrFareNotFound, id)
+	}
+
+	return fare, nil
+}
+
+func (r *memoryRepository) quoteOfFare(fareID primitive.ObjectID) (*domain.Quote, error) {
+	for _, doc := range r.quotes {
+		var quote domain.Quote
+		if err := fromDocument(doc, &quote); err != nil {
+			return nil, err
+		}
+		for _, f := range quote.Fares {
+			if f.FareID == fareID {
+				return &quote, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRepository) SaveQuote(ctx context.Context, quote *domain.Quote) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	quote.CreatedAt = r.now()
+	if quote.ID.IsZero() {
+		quote.ID = primitive.NewObjectID()
+	}
+
+	if _, ok := r.quotes[quote.ID]; ok {
+		return duplicateKeyError(mongo.QuotesCollection, "_id")
+	}
+
+	doc, err := toDocument(quote)
+	if err != nil {
+		return err
+	}
+	r.quotes[quote.ID] = doc
+
+	return nil
+}
+
+func (r *memoryRepository) FindRecentQuote(ctx context.Context, userID, routeKey string, since time.Time) (*domain.Quote, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest *domain.Quote
+	for _, doc := range r.quotes {
+		var quote domain.Quote
+		if err := fromDocument(doc, &quote); err != nil {
+			return nil, err
+		}
+		if quote.UserID != userID || quote.RouteKey != routeKey || quote.CreatedAt.Before(since) {
+			continue
+		}
+		if latest == nil || quote.CreatedAt.After(latest.CreatedAt) {
+			latest = &quote
+		}
+	}
+
+	return latest, nil
+}
+
+func (r *memoryRepository) CreateTrip(ctx context.Context, trip *types.Trip) (*types.Trip, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if trip.ID.IsZero() {
+		trip.ID = primitive.NewObjectID()
+	}
+
+	if _, ok := r.trips[trip.ID]; ok {
+		return nil, duplicateKeyError(mongo.TripsCollection, "_id")
+	}
+
+	doc, err := toDocument(trip)
+	if err != nil {
+		return nil, err
+	}
+	r.trips[trip.ID] = doc
+
+	return trip, nil
+}
+
+func (r *memoryRepository) GetTripByID(ctx context.Context, id string) (*types.Trip, error) {
+	_id, err := parseObjectID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	doc, ok := r.trips[_id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrTripNotFound, id)
+	}
+
+	var trip types.Trip
+	if err := fromDocument(doc, &trip); err != nil {
+		return nil, err
+	}
+
+	return &trip, nil
+}
+
+func (r *memoryRepository) UpdateTrip(ctx context.Context, tripID string, status string, driver *driver.Driver) error {
+	_id, err := parseObjectID(tripID)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	doc, ok := r.trips[_id]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	updated := setField(bsonpath.Clone(doc), "status", status)
+	if driver != nil {
+		driverDoc, err := toDocument(driver)
+		if err != nil {
+			return err
+		}
+		updated = setField(updated, "driver", driverDoc)
+	}
+
+	modified, err := documentsDiffer(doc, updated)
+	if err != nil {
+		return err
+	}
+
+	if !modified {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotModified, tripID)
+	}
+
+	r.trips[_id] = updated
+	return nil
+}
+
+func (r *memoryRepository) SaveTripEvent(ctx context.Context, event *domain.TripEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.Version > 0 {
+		for _, doc := range r.tripEvents {
+			var stored domain.TripEvent
+			if err := fromDocument(doc, &stored); err != nil {
+				return err
+			}
+			if stored.TripID == event.TripID && stored.Version == event.Version {
+				return duplicateKeyError(mongo.TripEventsCollection, "trip_id_1_version_1")
+			}
+		}
+	}
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = r.now()
+	}
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+
+	doc, err := toDocument(event)
+	if err != nil {
+		return err
+	}
+	r.tripEvents = append(r.tripEvents, doc)
+
+	return nil
+}
+
+func (r *memoryRepository) GetTripEvents(ctx context.Context, tripID string) ([]*domain.TripEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := []*domain.TripEvent{}
+	for _, doc := range r.tripEvents {
+		var event domain.TripEvent
+		if err := fromDocument(doc, &event); err != nil {
+			return nil, err
+		}
+		if event.TripID == tripID {
+			events = append(events, &event)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+			return events[i].CreatedAt.Before(events[j].CreatedAt)
+		}
+		return bytes.Compare(events[i].ID[:], events[j].ID[:]) < 0
+	})
+
+	return events, nil
+}
+
+func (r *memoryRepository) GetEventTripIDs(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := map[string]bool{}
+	tripIDs := []string{}
+	for _, doc := range r.tripEvents {
+		tripID, _ := lookupField(doc, "trip_id").(string)
+		if !seen[tripID] {
+			seen[tripID] = true
+			tripIDs = append(tripIDs, tripID)
+		}
+	}
+
+	return tripIDs, nil
+}
+
+func (r *memoryRepository) ReplaceTrip(ctx context.Context, trip *types.Trip) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fields, err := projectedTripFields(trip)
+	if err != nil {
+		return err
+	}
+
+	doc, ok := r.trips[trip.ID]
+	if !ok {
+		doc = bson.D{{Key: "_id", Value: trip.ID}}
+	}
+	doc = bsonpath.Clone(doc)
+	for _, e := range fields {
+		doc = setField(doc, e.Key, e.Value)
+	}
+	r.trips[trip.ID] = doc
+
+	return nil
+}
+
+func (r *memoryRepository) SaveRating(ctx context.Context, rating *domain.Rating) error {
+	_id, err := parseObjectID(rating.TripID)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trip, ok := r.trips[_id]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, rating.TripID)
+	}
+
+	key := rating.TripID + "/" + rating.RaterRole
+	if _, ok := r.ratings[key]; ok {
+		return domain.ErrAlreadyRated
+	}
+
+	rating.CreatedAt = r.now()
+	if rating.ID.IsZero() {
+		rating.ID = primitive.NewObjectID()
+	}
+
+	doc, err := toDocument(rating)
+	if err != nil {
+		return err
+	}
+	r.ratings[key] = doc
+
+	ratings, _ := lookupField(trip, "ratings").(bson.D)
+	ratings = setField(bsonpath.Clone(ratings), rating.RaterRole, doc)
+	r.trips[_id] = setField(bsonpath.Clone(trip), "ratings", ratings)
+
+	return nil
+}
+
+func (r *memoryRepository) GetRating(ctx context.Context, tripID, raterRole string) (*domain.Rating, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	doc, ok := r.ratings[tripID+"/"+raterRole]
+	if !ok {
+		return nil, nil
+	}
+
+	var rating domain.Rating
+	if err := fromDocument(doc, &rating); err != nil {
+		return nil, err
+	}
+
+	return &rating, nil
+}
+
+func (r *memoryRepository) SaveTip(ctx context.Context, tripID string, tip *domain.Tip) error {
+	_id, err := parseObjectID(tripID)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tip.CreatedAt = r.now()
+
+	trip, ok := r.trips[_id]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+	if lookupField(trip, "tip") != nil {
+		return domain.ErrTipAlreadyAdded
+	}
+
+	doc, err := toDocument(tip)
+	if err != nil {
+		return err
+	}
+	r.trips[_id] = setField(bsonpath.Clone(trip), "tip", doc)
+
+	return nil
+}
+
+func (r *memoryRepository) GetTip(ctx context.Context, tripID string) (*domain.Tip, error) {
+	_id, err := parseObjectID(tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	trip, ok := r.trips[_id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	doc, ok := lookupField(trip, "tip").(bson.D)
+	if !ok {
+		return nil, nil
+	}
+
+	var tip domain.Tip
+	if err := fromDocument(doc, &tip); err != nil {
+		return nil, err
+	}
+
+	return &tip, nil
+}
+
+func (r *memoryRepository) GetUserData(ctx context.Context, userID string) (*domain.UserData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	data := &domain.UserData{
+		UserID:     userID,
+		ExportedAt: r.now(),
+		Trips:      []*domain.UserTrip{},
+		RideFares:  []*types.RideFare{},
+		Quotes:     []*domain.Quote{},
+		Ratings:    []*domain.Rating{},
+	}
+
+	byID := map[string]*domain.UserTrip{}
+	for _, id := range sortedIDs(r.trips) {
+		var trip tripDocument
+		if err := fromDocument(r.trips[id], &trip); err != nil {
+			return nil, err
+		}
+		if trip.UserID != userID {
+			continue
+		}
+
+		userTrip := &domain.UserTrip{Trip: &trip.Trip, Tip: trip.Tip, Events: []*domain.TripEvent{}}
+		byID[id.Hex()] = userTrip
+		data.Trips = append(data.Trips, userTrip)
+	}
+
+	for _, doc := range r.tripEvents {
+		var event domain.TripEvent
+		if err := fromDocument(doc, &event); err != nil {
+			return nil, err
+		}
+		if trip, ok := byID[event.TripID]; ok {
+			trip.Events = append(trip.Events, &event)
+		}
+	}
+
+	for _, id := range sortedIDs(r.rideFares) {
+		var fare types.RideFare
+		if err := fromDocument(r.rideFares[id], &fare); err != nil {
+			return nil, err
+		}
+		if fare.UserID == userID {
+			data.RideFares = append(data.RideFares, &fare)
+		}
+	}
+
+	for _, id := range sortedIDs(r.quotes) {
+		var quote domain.Quote
+		if err := fromDocument(r.quotes[id], &quote); err != nil {
+			return nil, err
+		}
+		if quote.UserID == userID {
+			data.Quotes = append(data.Quotes, &quote)
+		}
+	}
+
+	for _, doc := range r.ratings {
+		var rating domain.Rating
+		if err := fromDocument(doc, &rating); err != nil {
+			return nil, err
+		}
+		if rating.RaterID == userID || rating.RateeID == userID {
+			data.Ratings = append(data.Ratings, &rating)
+		}
+	}
+	sort.Slice(data.Ratings, func(i, j int) bool {
+		return bytes.Compare(data.Ratings[i].ID[:], data.Ratings[j].ID[:]) < 0
+	})
+
+	return data, nil
+}
+
+func (r *memoryRepository) EraseUserData(ctx context.Context, userID, pseudonym string) (*domain.ErasureReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := &eraser{userID: userID, pseudonym: pseudonym}
+	report := &domain.ErasureReport{}
+
+	tripIDs := map[string]bool{}
+	for id, doc := range r.trips {
+		if lookupField(doc, "userID") == userID {
+			tripIDs[id.Hex()] = true
+			r.trips[id] = e.trip(doc)
+			report.Trips++
+		}
+	}
+
+	for i, doc := range r.tripEvents {
+		if tripID, _ := lookupField(doc, "trip_id").(string); tripIDs[tripID] {
+			r.tripEvents[i] = e.tripEvent(doc)
+			report.TripEvents++
+		}
+	}
+
+	for key, doc := range r.ratings {
+		if lookupField(doc, "rater_id") == userID || lookupField(doc, "ratee_id") == userID {
+			r.ratings[key] = e.rating(doc)
+			report.Ratings++
+		}
+	}
+
+	for id, doc := range r.rideFares {
+		if lookupField(doc, "userID") == userID {
+			delete(r.rideFares, id)
+			report.RideFares++
+		}
+	}
+
+	for id, doc := range r.quotes {
+		if lookupField(doc, "userID") == userID {
+			delete(r.quotes, id)
+			report.Quotes++
+		}
+	}
+
+	return report, nil
+}
+
+func (r *memoryRepository) SetTripDriver(ctx context.Context, tripID string, driver *trip.TripDriver) error {
+	_id, err := parseObjectID(tripID)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	doc, ok := r.trips[_id]
+	if !ok {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	if driver == nil {
+		driver = &trip.TripDriver{}
+	}
+	driverDoc, err := toDocument(driver)
+	if err != nil {
+		return err
+	}
+	updated := setField(bsonpath.Clone(doc), "driver", driverDoc)
+
+	modified, err := documentsDiffer(doc, updated)
+	if err != nil {
+		return err
+	}
+
+	if !modified {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotModified, tripID)
+	}
+
+	r.trips[_id] = updated
+	return nil
+}
+
+func (r *memoryRepository) SearchTrips(ctx context.Context, filter domain.TripFilter) ([]*types.Trip, error) {
+	var afterID primitive.ObjectID
+	if filter.AfterID != "" {
+		var err error
+		if afterID, err = parseObjectID(filter.AfterID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	trips := []*types.Trip{}
+	for _, id := range sortedIDs(r.trips) {
+		if filter.Limit > 0 && len(trips) == filter.Limit {
+			break
+		}
+		if filter.AfterID != "" && bytes.Compare(id[:], afterID[:]) <= 0 {
+			continue
+		}
+
+		var t types.Trip
+		if err := fromDocument(r.trips[id], &t); err != nil {
+			return nil, err
+		}
+		if matchesTripFilter(&t, filter) {
+			trips = append(trips, &t)
+		}
+	}
+
+	return trips, nil
+}
+
+func matchesTripFilter(t *types.Trip, filter domain.TripFilter) bool {
+	if len(filter.Statuses) > 0 && !contains(filter.Statuses, t.Status) {
+		return false
+	}
+	if filter.UserID != "" && t.UserID != filter.UserID {
+		return false
+	}
+	if filter.DriverID != "" && t.Driver.GetId() != filter.DriverID {
+		return false
+	}
+	if filter.PackageSlug != "" && (t.RideFare == nil || t.RideFare.PackageSlug != filter.PackageSlug) {
+		return false
+	}
+
+	// ObjectIDs hold their creation time to the second, as Mongo compares
+	// them
+	created := t.ID.Timestamp()
+	if !filter.CreatedAfter.IsZero() && created.Before(filter.CreatedAfter.Truncate(time.Second)) {
+		return false
+	}
+	if !filter.CreatedBefore.IsZero() && !created.Before(filter.CreatedBefore.Truncate(time.Second)) {
+		return false
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryRepository) ExpireQuotes(ctx context.Context, filter domain.QuoteFilter, createdAt time.Time) (int, error) {
+	ids := map[primitive.ObjectID]bool{}
+	for _, id := range filter.IDs {
+		_id, err := parseObjectID(id)
+		if err != nil {
+			return 0, err
+		}
+		ids[_id] = true
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expired := 0
+	for id, doc := range r.quotes {
+		var quote domain.Quote
+		if err := fromDocument(doc, &quote); err != nil {
+			return 0, err
+		}
+		if len(ids) > 0 && !ids[id] {
+			continue
+		}
+		if filter.UserID != "" && quote.UserID != filter.UserID {
+			continue
+		}
+		if !quote.CreatedAt.After(createdAt) {
+			continue
+		}
+
+		for _, fare := range quote.Fares {
+			if fareDoc, ok := r.rideFares[fare.FareID]; ok {
+				r.rideFares[fare.FareID] = setField(bsonpath.Clone(fareDoc), "created_at", createdAt)
+			}
+		}
+		r.quotes[id] = setField(bsonpath.Clone(doc), "created_at", createdAt)
+		expired++
+	}
+
+	return expired, nil
+}
+
+// sortedIDs returns the keys of a collection in insertion order, as Mongo
+// returns documents sorted by ObjectID
+func sortedIDs(docs map[primitive.ObjectID]bson.D) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(docs))
+	for id := range docs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return bytes.Compare(ids[i][:], ids[j][:]) < 0 })
+	return ids
+}
+
+func toDocument(v interface{}) (bson.D, error) {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+func fromDocument(doc bson.D, v interface{}) error {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return bson.Unmarshal(data, v)
+}
+
+func lookupField(doc bson.D, key string) interface{} {
+	for _, e := range doc {
+		if e.Key == key {
+			return e.Value
+		}
+	}
+	return nil
+}
+
+// setField behaves like $set on a top level field: existing fields keep
+// their position and new fields are appended.
+func setField(doc bson.D, key string, value interface{}) bson.D {
+	for i, e := range doc {
+		if e.Key == key {
+			doc[i].Value = value
+			return doc
+		}
+	}
+	return append(doc, bson.E{Key: key, Value: value})
+}
+
+// documentsDiffer reports whether an update changed a document, which is
+// what Mongo reports as the modified count.
+func documentsDiffer(a, b bson.D) (bool, error) {
+	aData, err := bson.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+
+	bData, err := bson.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+
+	return !bytes.Equal(aData, bData), nil
+}
+
+func duplicateKeyError(collection, index string) error {
+	return mongoDriver.WriteException{
+		WriteErrors: mongoDriver.WriteErrors{
+			{
+				Code:    11000,
+				Message: fmt.Sprintf("E11000 duplicate key error collection: %s index: %s", collection, index),
+			},
+		},
+	}
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMemoryRepositoryConformance(t *testing.T) {
+	runRepositoryConformance(t, func(t *testing.T) domain.Repository {
+		return NewMemoryRepository()
+	})
+}
+
+func TestMemoryRepositoryRideFareTTL(t *testing.T) {
+	t.Run("fares expire after the ttl", func(t *testing.T) {
+		// Setup
+		now := time.Now()
+		repo := NewMemoryRepository(
+			WithClock(func() time.Time { return now }),
+			WithRideFareTTL(time.Hour),
+		)
+
+		fare := &types.RideFare{UserID: "user-123", PackageSlug: "suv"}
+		if err := repo.SaveRideFare(context.Background(), fare); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// Execute & Verify
+		now = now.Add(59 * time.Minute)
+		if _, err := repo.GetRideFareByID(context.Background(), fare.ID.Hex()); err != nil {
+			t.Fatalf("expected the fare before the ttl, got %v", err)
+		}
+
+		now = now.Add(time.Minute)
+		if _, err := repo.GetRideFareByID(context.Background(), fare.ID.Hex()); !errors.Is(err, domain.ErrFareNotFound) {
+			t.Errorf("expected ErrFareNotFound after the ttl, got %v", err)
+		}
+	})
+}
+
+func TestMemoryRepositoryIsolation(t *testing.T) {
+	t.Run("stored trips are copies", func(t *testing.T) {
+		// Setup
+		repo := NewMemoryRepository()
+		trip := &types.Trip{ID: primitive.NewObjectID(), Status: domain.TripStatusPending}
+		if _, err := repo.CreateTrip(context.Background(), trip); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// Execute
+		trip.Status = domain.TripStatusPaid
+		got, err := repo.GetTripByID(context.Background(), trip.ID.Hex())
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got.Status != domain.TripStatusPending {
+			t.Errorf("expected the stored trip to be unaffected, got %s", got.Status)
+		}
+	})
+
+	t.Run("concurrent access", func(t *testing.T) {
+		// Setup
+		repo := NewMemoryRepository()
+		ctx := context.Background()
+		tripID := primitive.NewObjectID()
+		if _, err := repo.CreateTrip(ctx, &types.Trip{ID: tripID, Status: domain.TripStatusPending}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// Execute
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				repo.SaveRideFare(ctx, &types.RideFare{UserID: "user-123"})
+				repo.GetTripByID(ctx, tripID.Hex())
+				repo.SaveTripEvent(ctx, &domain.TripEvent{TripID: tripID.Hex(), Type: domain.TripEventStatusChanged})
+			}()
+		}
+		wg.Wait()
+
+		// Verify
+		events, err := repo.GetTripEvents(ctx, tripID.Hex())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(events) != 50 {
+			t.Errorf("expected 50 events, got %d", len(events))
+		}
+	})
+}
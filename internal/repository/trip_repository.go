@@ -2,27 +2,47 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/ride4Low/contracts/proto/driver"
+	"github.com/ride4Low/contracts/proto/trip"
 	"github.com/ride4Low/contracts/types"
 	"github.com/ride4Low/trip-service/internal/adapter/mongo"
 	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/retention"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // repository struct implementing Repository
 type mongoRepository struct {
-	db *mongoDriver.Database
+	db           *mongoDriver.Database
+	routeKeyHash func(string) string
 }
 
-func NewRepository(db *mongoDriver.Database) domain.Repository {
-	return &mongoRepository{
+// Option configures the Mongo repository
+type Option func(*mongoRepository)
+
+// WithRouteKeyHash stores the route keys of quotes hashed, as they hold the
+// rounded endpoints of the route
+func WithRouteKeyHash(hash func(string) string) Option {
+	return func(r *mongoRepository) {
+		r.routeKeyHash = hash
+	}
+}
+
+func NewRepository(db *mongoDriver.Database, opts ...Option) domain.Repository {
+	r := &mongoRepository{
 		db: db,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 func (r *mongoRepository) SaveRideFare(ctx context.Context, rideFare *types.RideFare) error {
@@ -38,25 +58,81 @@ func (r *mongoRepository) SaveRideFare(ctx context.Context, rideFare *types.Ride
 }
 
 func (r *mongoRepository) GetRideFareByID(ctx context.Context, id string) (*types.RideFare, error) {
-	_id, err := primitive.ObjectIDFromHex(id)
+	_id, err := parseObjectID(id)
 	if err != nil {
 		return nil, err
 	}
 
+	// The fares of a preview are stored on its quote
+	var quote domain.Quote
+	err = r.db.Collection(mongo.QuotesCollection).FindOne(ctx, bson.M{"fares.fare_id": _id}).Decode(&quote)
+	if err == nil {
+		return quote.RideFare(_id), nil
+	}
+	if !errors.Is(err, mongoDriver.ErrNoDocuments) {
+		return nil, err
+	}
+
 	result := r.db.Collection(mongo.RideFaresCollection).FindOne(ctx, bson.M{"_id": _id})
-	if result.Err() != nil {
-		return nil, result.Err()
+	if err := result.Err(); err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrFareNotFound, id)
+		}
+		return nil, err
 	}
 
 	var fare types.RideFare
-	err = result.Decode(&fare)
-	if err != nil {
+	if err := result.Decode(&fare); err != nil {
 		return nil, err
 	}
 
 	return &fare, nil
 }
 
+func (r *mongoRepository) SaveQuote(ctx context.Context, quote *domain.Quote) error {
+	quote.CreatedAt = time.Now()
+
+	stored := *quote
+	stored.RouteKey = r.routeKey(quote.RouteKey)
+	result, err := r.db.Collection(mongo.QuotesCollection).InsertOne(ctx, &stored)
+	if err != nil {
+		return err
+	}
+
+	quote.ID = result.InsertedID.(primitive.ObjectID)
+
+	return nil
+}
+
+func (r *mongoRepository) FindRecentQuote(ctx context.Context, userID, routeKey string, since time.Time) (*domain.Quote, error) {
+	filter := bson.M{
+		"userID":     userID,
+		"route_key":  r.routeKey(routeKey),
+		"created_at": bson.M{"$gte": since},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	var quote domain.Quote
+	err := r.db.Collection(mongo.QuotesCollection).FindOne(ctx, filter, opts).Decode(&quote)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	quote.RouteKey = routeKey
+
+	return &quote, nil
+}
+
+func (r *mongoRepository) routeKey(key string) string {
+	if r.routeKeyHash == nil || key == "" {
+		return key
+	}
+	return r.routeKeyHash(key)
+}
+
 func (r *mongoRepository) CreateTrip(ctx context.Context, trip *types.Trip) (*types.Trip, error) {
 	result, err := r.db.Collection(mongo.TripsCollection).InsertOne(ctx, trip)
 	if err != nil {
@@ -69,14 +145,17 @@ func (r *mongoRepository) CreateTrip(ctx context.Context, trip *types.Trip) (*ty
 }
 
 func (r *mongoRepository) GetTripByID(ctx context.Context, id string) (*types.Trip, error) {
-	_id, err := primitive.ObjectIDFromHex(id)
+	_id, err := parseObjectID(id)
 	if err != nil {
 		return nil, err
 	}
 
 	result := r.db.Collection(mongo.TripsCollection).FindOne(ctx, bson.M{"_id": _id})
-	if result.Err() != nil {
-		return nil, result.Err()
+	if err := result.Err(); err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrTripNotFound, id)
+		}
+		return nil, err
 	}
 
 	var trip types.Trip
@@ -89,7 +168,7 @@ func (r *mongoRepository) GetTripByID(ctx context.Context, id string) (*types.Tr
 }
 
 func (r *mongoRepository) UpdateTrip(ctx context.Context, tripID string, status string, driver *driver.Driver) error {
-	_id, err := primitive.ObjectIDFromHex(tripID)
+	_id, err := parseObjectID(tripID)
 	if err != nil {
 		return err
 	}
@@ -105,8 +184,481 @@ func (r *mongoRepository) UpdateTrip(ctx context.Context, tripID string, status
 		return err
 	}
 
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
+	if result.ModifiedCount == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotModified, tripID)
+	}
+	return nil
+}
+
+func (r *mongoRepository) SaveTripEvent(ctx context.Context, event *domain.TripEvent) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	result, err := r.db.Collection(mongo.TripEventsCollection).InsertOne(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	event.ID = result.InsertedID.(primitive.ObjectID)
+
+	return nil
+}
+
+func (r *mongoRepository) GetTripEvents(ctx context.Context, tripID string) ([]*domain.TripEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.db.Collection(mongo.TripEventsCollection).Find(ctx, bson.M{"trip_id": tripID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []*domain.TripEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *mongoRepository) GetEventTripIDs(ctx context.Context) ([]string, error) {
+	values, err := r.db.Collection(mongo.TripEventsCollection).Distinct(ctx, "trip_id", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	tripIDs := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			tripIDs = append(tripIDs, id)
+		}
+	}
+
+	return tripIDs, nil
+}
+
+func (r *mongoRepository) ReplaceTrip(ctx context.Context, trip *types.Trip) error {
+	fields, err := projectedTripFields(trip)
+	if err != nil {
+		return err
+	}
+
+	opts := options.Update().SetUpsert(true)
+	_, err = r.db.Collection(mongo.TripsCollection).UpdateOne(ctx, bson.M{"_id": trip.ID}, bson.M{"$set": fields}, opts)
+	return err
+}
+
+// projectedTripFields are the fields of a trip rebuilt from its events. Tips
+// and ratings are written to the trip document directly, so a projection
+// must leave them alone.
+func projectedTripFields(trip *types.Trip) (bson.D, error) {
+	data, err := bson.Marshal(trip)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	fields := bson.D{}
+	for _, e := range doc {
+		if e.Key != "_id" {
+			fields = append(fields, e)
+		}
+	}
+	return fields, nil
+}
+
+func (r *mongoRepository) SaveRating(ctx context.Context, rating *domain.Rating) error {
+	_id, err := parseObjectID(rating.TripID)
+	if err != nil {
+		return err
+	}
+
+	rating.CreatedAt = time.Now()
+	result, err := r.db.Collection(mongo.RatingsCollection).InsertOne(ctx, rating)
+	if err != nil {
+		if mongoDriver.IsDuplicateKeyError(err) {
+			return domain.ErrAlreadyRated
+		}
+		return err
+	}
+
+	rating.ID = result.InsertedID.(primitive.ObjectID)
+
+	update := bson.M{"$set": bson.M{"ratings." + rating.RaterRole: rating}}
+	updated, err := r.db.Collection(mongo.TripsCollection).UpdateOne(ctx, bson.M{"_id": _id}, update)
+	if err == nil && updated.MatchedCount == 0 {
+		err = fmt.Errorf("%w: %s", domain.ErrTripNotFound, rating.TripID)
+	}
+	if err != nil {
+		// The rating is removed again, so it can be retried instead of being
+		// reported as already rated
+		if _, cleanupErr := r.db.Collection(mongo.RatingsCollection).DeleteOne(context.WithoutCancel(ctx), bson.M{"_id": rating.ID}); cleanupErr != nil {
+			return fmt.Errorf("%w (failed to remove the rating: %v)", err, cleanupErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *mongoRepository) GetRating(ctx context.Context, tripID, raterRole string) (*domain.Rating, error) {
+	var rating domain.Rating
+	err := r.db.Collection(mongo.RatingsCollection).FindOne(ctx, bson.M{"trip_id": tripID, "rater_role": raterRole}).Decode(&rating)
+	if err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &rating, nil
+}
+
+func (r *mongoRepository) SaveTip(ctx context.Context, tripID string, tip *domain.Tip) error {
+	_id, err := parseObjectID(tripID)
+	if err != nil {
+		return err
+	}
+
+	tip.CreatedAt = time.Now()
+
+	// Only one tip per trip: the filter no longer matches once a tip is set
+	filter := bson.M{"_id": _id, "tip": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"tip": tip}}
+
+	result, err := r.db.Collection(mongo.TripsCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		// Either the trip does not exist or it already has a tip
+		count, err := r.db.Collection(mongo.TripsCollection).CountDocuments(ctx, bson.M{"_id": _id})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+		}
+		return domain.ErrTipAlreadyAdded
+	}
+	return nil
+}
+
+func (r *mongoRepository) GetTip(ctx context.Context, tripID string) (*domain.Tip, error) {
+	_id, err := parseObjectID(tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.FindOne().SetProjection(bson.M{"tip": 1})
+	result := r.db.Collection(mongo.TripsCollection).FindOne(ctx, bson.M{"_id": _id}, opts)
+	if err := result.Err(); err != nil {
+		if errors.Is(err, mongoDriver.ErrNoDocuments) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+		}
+		return nil, err
+	}
+
+	var doc struct {
+		Tip *domain.Tip `bson:"tip"`
+	}
+	if err := result.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return doc.Tip, nil
+}
+
+func (r *mongoRepository) SetTripDriver(ctx context.Context, tripID string, driver *trip.TripDriver) error {
+	_id, err := parseObjectID(tripID)
+	if err != nil {
+		return err
+	}
+
+	if driver == nil {
+		driver = &trip.TripDriver{}
+	}
+
+	result, err := r.db.Collection(mongo.TripsCollection).UpdateOne(ctx, bson.M{"_id": _id}, bson.M{"$set": bson.M{"driver": driver}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotFound, tripID)
+	}
+
 	if result.ModifiedCount == 0 {
-		return fmt.Errorf("trip not found: %s", tripID)
+		return fmt.Errorf("%w: %s", domain.ErrTripNotModified, tripID)
 	}
 	return nil
 }
+
+func (r *mongoRepository) SearchTrips(ctx context.Context, filter domain.TripFilter) ([]*types.Trip, error) {
+	query := bson.M{}
+	if len(filter.Statuses) > 0 {
+		query["status"] = bson.M{"$in": filter.Statuses}
+	}
+	if filter.UserID != "" {
+		query["userID"] = filter.UserID
+	}
+	if filter.DriverID != "" {
+		query["driver.id"] = filter.DriverID
+	}
+	if filter.PackageSlug != "" {
+		query["rideFare.packageSlug"] = filter.PackageSlug
+	}
+
+	// Trips are created with their ID, so the ID range is the creation time
+	// range
+	idRange := bson.M{}
+	if filter.AfterID != "" {
+		afterID, err := parseObjectID(filter.AfterID)
+		if err != nil {
+			return nil, err
+		}
+		idRange["$gt"] = afterID
+	}
+	if !filter.CreatedAfter.IsZero() {
+		idRange["$gte"] = primitive.NewObjectIDFromTimestamp(filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		idRange["$lt"] = primitive.NewObjectIDFromTimestamp(filter.CreatedBefore)
+	}
+	if len(idRange) > 0 {
+		query["_id"] = idRange
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+
+	cursor, err := r.db.Collection(mongo.TripsCollection).Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	trips := []*types.Trip{}
+	if err := cursor.All(ctx, &trips); err != nil {
+		return nil, err
+	}
+
+	return trips, nil
+}
+
+func (r *mongoRepository) ExpireQuotes(ctx context.Context, filter domain.QuoteFilter, createdAt time.Time) (int, error) {
+	query := bson.M{"created_at": bson.M{"$gt": createdAt}}
+	if len(filter.IDs) > 0 {
+		ids := make([]primitive.ObjectID, len(filter.IDs))
+		for i, id := range filter.IDs {
+			_id, err := parseObjectID(id)
+			if err != nil {
+				return 0, err
+			}
+			ids[i] = _id
+		}
+		query["_id"] = bson.M{"$in": ids}
+	}
+	if filter.UserID != "" {
+		query["userID"] = filter.UserID
+	}
+
+	var quotes []domain.Quote
+	if err := r.findAll(ctx, mongo.QuotesCollection, query, &quotes); err != nil {
+		return 0, err
+	}
+	if len(quotes) == 0 {
+		return 0, nil
+	}
+
+	quoteIDs := make([]primitive.ObjectID, len(quotes))
+	var fareIDs []primitive.ObjectID
+	for i, quote := range quotes {
+		quoteIDs[i] = quote.ID
+		for _, fare := range quote.Fares {
+			fareIDs = append(fareIDs, fare.FareID)
+		}
+	}
+
+	// Fares first: a retry finds the quotes that were not expired yet
+	update := bson.M{"$set": bson.M{"created_at": createdAt}}
+	if _, err := r.db.Collection(mongo.RideFaresCollection).UpdateMany(ctx, bson.M{"_id": bson.M{"$in": fareIDs}}, update); err != nil {
+		return 0, err
+	}
+	if _, err := r.db.Collection(mongo.QuotesCollection).UpdateMany(ctx, bson.M{"_id": bson.M{"$in": quoteIDs}}, update); err != nil {
+		return 0, err
+	}
+
+	return len(quotes), nil
+}
+
+// userDataCollections name the live collections and the archive collections
+// retention moves old trips into, which both hold personal data
+var userDataCollections = []string{"", retention.ArchiveSuffix}
+
+func (r *mongoRepository) GetUserData(ctx context.Context, userID string) (*domain.UserData, error) {
+	data := &domain.UserData{
+		UserID:     userID,
+		ExportedAt: time.Now(),
+		Trips:      []*domain.UserTrip{},
+		RideFares:  []*types.RideFare{},
+		Quotes:     []*domain.Quote{},
+		Ratings:    []*domain.Rating{},
+	}
+
+	for _, suffix := range userDataCollections {
+		var trips []tripDocument
+		if err := r.findAll(ctx, mongo.TripsCollection+suffix, bson.M{"userID": userID}, &trips); err != nil {
+			return nil, err
+		}
+
+		tripIDs := make([]string, len(trips))
+		byID := map[string]*domain.UserTrip{}
+		for i := range trips {
+			tripIDs[i] = trips[i].ID.Hex()
+			trip := &domain.UserTrip{Trip: &trips[i].Trip, Tip: trips[i].Tip, Events: []*domain.TripEvent{}}
+			byID[tripIDs[i]] = trip
+			data.Trips = append(data.Trips, trip)
+		}
+
+		var events []*domain.TripEvent
+		if err := r.findAll(ctx, mongo.TripEventsCollection+suffix, bson.M{"trip_id": bson.M{"$in": tripIDs}}, &events); err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			byID[event.TripID].Events = append(byID[event.TripID].Events, event)
+		}
+
+		var ratings []*domain.Rating
+		ratingsFilter := bson.M{"$or": bson.A{bson.M{"rater_id": userID}, bson.M{"ratee_id": userID}}}
+		if err := r.findAll(ctx, mongo.RatingsCollection+suffix, ratingsFilter, &ratings); err != nil {
+			return nil, err
+		}
+		data.Ratings = append(data.Ratings, ratings...)
+	}
+
+	if err := r.findAll(ctx, mongo.RideFaresCollection, bson.M{"userID": userID}, &data.RideFares); err != nil {
+		return nil, err
+	}
+
+	if err := r.findAll(ctx, mongo.QuotesCollection, bson.M{"userID": userID}, &data.Quotes); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (r *mongoRepository) EraseUserData(ctx context.Context, userID, pseudonym string) (*domain.ErasureReport, error) {
+	e := &eraser{userID: userID, pseudonym: pseudonym}
+	report := &domain.ErasureReport{}
+
+	fares, err := r.db.Collection(mongo.RideFaresCollection).DeleteMany(ctx, bson.M{"userID": userID})
+	if err != nil {
+		return nil, err
+	}
+	report.RideFares = int(fares.DeletedCount)
+
+	quotes, err := r.db.Collection(mongo.QuotesCollection).DeleteMany(ctx, bson.M{"userID": userID})
+	if err != nil {
+		return nil, err
+	}
+	report.Quotes = int(quotes.DeletedCount)
+
+	for _, suffix := range userDataCollections {
+		if err := r.eraseTrips(ctx, e, suffix, report); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// eraseTrips erases the rider's trips, their events and the ratings of the
+// rider in the collections with the given suffix
+func (r *mongoRepository) eraseTrips(ctx context.Context, e *eraser, suffix string, report *domain.ErasureReport) error {
+	var trips []bson.D
+	if err := r.findAll(ctx, mongo.TripsCollection+suffix, bson.M{"userID": e.userID}, &trips); err != nil {
+		return err
+	}
+
+	tripIDs := make([]string, 0, len(trips))
+	for _, trip := range trips {
+		if id, ok := lookupField(trip, "_id").(primitive.ObjectID); ok {
+			tripIDs = append(tripIDs, id.Hex())
+		}
+	}
+
+	// Trips are erased last: they are how a retry finds the events of the
+	// trips an interrupted erasure did not get to
+	events, err := r.replaceErased(ctx, mongo.TripEventsCollection+suffix, bson.M{"trip_id": bson.M{"$in": tripIDs}}, e.tripEvent)
+	if err != nil {
+		return err
+	}
+	report.TripEvents += events
+
+	ratings, err := r.replaceErased(ctx, mongo.RatingsCollection+suffix,
+		bson.M{"$or": bson.A{bson.M{"rater_id": e.userID}, bson.M{"ratee_id": e.userID}}}, e.rating)
+	if err != nil {
+		return err
+	}
+	report.Ratings += ratings
+
+	for _, trip := range trips {
+		if _, err := r.db.Collection(mongo.TripsCollection+suffix).ReplaceOne(ctx, bson.M{"_id": lookupField(trip, "_id")}, e.trip(trip)); err != nil {
+			return err
+		}
+		report.Trips++
+	}
+
+	return nil
+}
+
+// replaceErased rewrites the documents matching filter without the personal
+// data of a rider
+func (r *mongoRepository) replaceErased(ctx context.Context, collection string, filter bson.M, erase func(bson.D) bson.D) (int, error) {
+	var docs []bson.D
+	if err := r.findAll(ctx, collection, filter, &docs); err != nil {
+		return 0, err
+	}
+
+	for _, doc := range docs {
+		if _, err := r.db.Collection(collection).ReplaceOne(ctx, bson.M{"_id": lookupField(doc, "_id")}, erase(doc)); err != nil {
+			return 0, err
+		}
+	}
+	return len(docs), nil
+}
+
+func (r *mongoRepository) findAll(ctx context.Context, collection string, filter bson.M, results interface{}) error {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	cursor, err := r.db.Collection(collection).Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.All(ctx, results)
+}
+
+// parseObjectID converts a client supplied id, reporting malformed ids as
+// invalid arguments rather than as driver errors
+func parseObjectID(id string) (primitive.ObjectID, error) {
+	_id, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("%w: %q", domain.ErrInvalidID, id)
+	}
+	return _id, nil
+}
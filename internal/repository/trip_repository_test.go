@@ -8,8 +8,11 @@ import (
 
 	"github.com/ride4Low/contracts/types"
 	"github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
 )
 
 // mockCollection is a mock implementation of MongoDB collection for testing
@@ -243,3 +246,359 @@ func TestSaveRideFare(t *testing.T) {
 		}
 	})
 }
+
+func TestMongoRepositoryGetTripByID(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ns := mtest.TestDb + "." + mongo.TripsCollection
+
+	mt.Run("found", func(mt *mtest.T) {
+		// Setup
+		tripID := primitive.NewObjectID()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: tripID},
+			{Key: "status", Value: domain.TripStatusPending},
+		}))
+		repo := NewRepository(mt.DB)
+
+		// Execute
+		trip, err := repo.GetTripByID(context.Background(), tripID.Hex())
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if trip.ID != tripID || trip.Status != domain.TripStatusPending {
+			t.Errorf("unexpected trip: %+v", trip)
+		}
+	})
+
+	mt.Run("not found", func(mt *mtest.T) {
+		// Setup
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch))
+		repo := NewRepository(mt.DB)
+
+		// Execute
+		trip, err := repo.GetTripByID(context.Background(), primitive.NewObjectID().Hex())
+
+		// Verify
+		if !errors.Is(err, domain.ErrTripNotFound) {
+			t.Errorf("expected ErrTripNotFound, got %v", err)
+		}
+		if trip != nil {
+			t.Errorf("expected nil trip, got %+v", trip)
+		}
+	})
+
+	mt.Run("invalid id", func(mt *mtest.T) {
+		// Setup
+		repo := NewRepository(mt.DB)
+
+		// Execute
+		_, err := repo.GetTripByID(context.Background(), "not-an-id")
+
+		// Verify
+		if !errors.Is(err, domain.ErrInvalidID) {
+			t.Errorf("expected ErrInvalidID, got %v", err)
+		}
+		if started := mt.GetStartedEvent(); started != nil {
+			t.Errorf("expected no command to be sent, got %s", started.CommandName)
+		}
+	})
+
+	mt.Run("driver errors pass through", func(mt *mtest.T) {
+		// Setup
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code:    11600,
+			Name:    "InterruptedAtShutdown",
+			Message: "interrupted at shutdown",
+		}))
+		repo := NewRepository(mt.DB)
+
+		// Execute
+		_, err := repo.GetTripByID(context.Background(), primitive.NewObjectID().Hex())
+
+		// Verify
+		var domainErr *domain.Error
+		if err == nil || errors.As(err, &domainErr) {
+			t.Errorf("expected the driver error, got %v", err)
+		}
+	})
+}
+
+func TestMongoRepositoryGetRideFareByID(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ns := mtest.TestDb + "." + mongo.RideFaresCollection
+	quotesNs := mtest.TestDb + "." + mongo.QuotesCollection
+
+	mt.Run("not found", func(mt *mtest.T) {
+		// Setup
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, quotesNs, mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch),
+		)
+		repo := NewRepository(mt.DB)
+
+		// Execute
+		_, err := repo.GetRideFareByID(context.Background(), primitive.NewObjectID().Hex())
+
+		// Verify
+		if !errors.Is(err, domain.ErrFareNotFound) {
+			t.Errorf("expected ErrFareNotFound, got %v", err)
+		}
+	})
+
+	mt.Run("invalid id", func(mt *mtest.T) {
+		// Setup
+		repo := NewRepository(mt.DB)
+
+		// Execute
+		_, err := repo.GetRideFareByID(context.Background(), "zzzzzzzzzzzzzzzzzzzzzzzz")
+
+		// Verify
+		if !errors.Is(err, domain.ErrInvalidID) {
+			t.Errorf("expected ErrInvalidID, got %v", err)
+		}
+	})
+}
+
+func TestMongoRepositorySaveRating(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("unknown trip removes the rating", func(mt *mtest.T) {
+		// Setup
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+		repo := NewRepository(mt.DB)
+
+		// Execute
+		err := repo.SaveRating(context.Background(), &domain.Rating{TripID: primitive.NewObjectID().Hex(), RaterRole: domain.RaterRider})
+
+		// Verify
+		if !errors.Is(err, domain.ErrTripNotFound) {
+			t.Errorf("expected ErrTripNotFound, got %v", err)
+		}
+		if started := mt.GetStartedEvent(); started == nil || started.CommandName != "insert" {
+			t.Fatalf("expected the rating to be inserted, got %v", started)
+		}
+		mt.GetStartedEvent()
+		if started := mt.GetStartedEvent(); started == nil || started.CommandName != "delete" {
+			t.Errorf("expected the rating to be deleted again, got %v", started)
+		}
+	})
+}
+
+func TestMongoRepositoryUpdateTrip(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	tests := []struct {
+		name        string
+		matched     int
+		modified    int
+		expectedErr error
+	}{
+		{
+			name:     "updated",
+			matched:  1,
+			modified: 1,
+		},
+		{
+			name:        "not found",
+			matched:     0,
+			modified:    0,
+			expectedErr: domain.ErrTripNotFound,
+		},
+		{
+			name:        "no-op update",
+			matched:     1,
+			modified:    0,
+			expectedErr: domain.ErrTripNotModified,
+		},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			// Setup
+			mt.AddMockResponses(mtest.CreateSuccessResponse(
+				bson.E{Key: "n", Value: tt.matched},
+				bson.E{Key: "nModified", Value: tt.modified},
+			))
+			repo := NewRepository(mt.DB)
+
+			// Execute
+			err := repo.UpdateTrip(context.Background(), primitive.NewObjectID().Hex(), domain.TripStatusAccepted, nil)
+
+			// Verify
+			if tt.expectedErr == nil {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	mt.Run("invalid id", func(mt *mtest.T) {
+		// Setup
+		repo := NewRepository(mt.DB)
+
+		// Execute
+		err := repo.UpdateTrip(context.Background(), "trip-1", domain.TripStatusAccepted, nil)
+
+		// Verify
+		if !errors.Is(err, domain.ErrInvalidID) {
+			t.Errorf("expected ErrInvalidID, got %v", err)
+		}
+	})
+}
+
+func TestMongoRepositorySaveTip(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ns := mtest.TestDb + "." + mongo.TripsCollection
+
+	mt.Run("trip not found", func(mt *mtest.T) {
+		// Setup
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch),
+		)
+		repo := NewRepository(mt.DB)
+
+		// Execute
+		err := repo.SaveTip(context.Background(), primitive.NewObjectID().Hex(), &domain.Tip{AmountInCents: 300})
+
+		// Verify
+		if !errors.Is(err, domain.ErrTripNotFound) {
+			t.Errorf("expected ErrTripNotFound, got %v", err)
+		}
+	})
+
+	mt.Run("tip already added", func(mt *mtest.T) {
+		// Setup
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+		)
+		repo := NewRepository(mt.DB)
+
+		// Execute
+		err := repo.SaveTip(context.Background(), primitive.NewObjectID().Hex(), &domain.Tip{AmountInCents: 300})
+
+		// Verify
+		if !errors.Is(err, domain.ErrTipAlreadyAdded) {
+			t.Errorf("expected ErrTipAlreadyAdded, got %v", err)
+		}
+	})
+}
+
+func TestMongoRepositorySaveQuote(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("fares are written with their quote", func(mt *mtest.T) {
+		// Setup
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		repo := NewRepository(mt.DB)
+		quote := &domain.Quote{
+			UserID: "user-123",
+			Fares: []domain.QuoteFare{
+				{FareID: primitive.NewObjectID(), PackageSlug: "suv", TotalPriceInCents: 1850},
+				{FareID: primitive.NewObjectID(), PackageSlug: "sedan", TotalPriceInCents: 2000},
+			},
+		}
+
+		// Execute
+		err := repo.SaveQuote(context.Background(), quote)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		started := mt.GetStartedEvent()
+		if started == nil || started.CommandName != "insert" {
+			t.Fatalf("expected an insert, got %v", started)
+		}
+		docs := started.Command.Lookup("documents").Array()
+		if values, _ := docs.Values(); len(values) != 1 {
+			t.Fatalf("expected a single document, got %d", len(values))
+		}
+		fares, _ := docs.Index(0).Value().Document().Lookup("fares").Array().Values()
+		if len(fares) != 2 {
+			t.Errorf("expected both fares on the quote, got %d", len(fares))
+		}
+		if started := mt.GetStartedEvent(); started != nil {
+			t.Errorf("expected a single write, got %s", started.CommandName)
+		}
+	})
+
+	mt.Run("write errors are returned", func(mt *mtest.T) {
+		// Setup
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "E11000 duplicate key error"}))
+		repo := NewRepository(mt.DB)
+
+		// Execute
+		err := repo.SaveQuote(context.Background(), &domain.Quote{UserID: "user-123"})
+
+		// Verify
+		if !mongoDriver.IsDuplicateKeyError(err) {
+			t.Fatalf("expected a duplicate key error, got %v", err)
+		}
+	})
+}
+
+func TestMongoRepositoryRouteKeyHash(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	hash := func(key string) string { return "hashed:" + key }
+
+	mt.Run("save", func(mt *mtest.T) {
+		// Setup
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		repo := NewRepository(mt.DB, WithRouteKeyHash(hash))
+		quote := &domain.Quote{UserID: "user-123", RouteKey: "100.1,13.1;100.2,13.2"}
+
+		// Execute
+		err := repo.SaveQuote(context.Background(), quote)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if quote.RouteKey != "100.1,13.1;100.2,13.2" {
+			t.Errorf("expected the caller's quote to keep its route key, got %s", quote.RouteKey)
+		}
+		doc := mt.GetStartedEvent().Command.Lookup("documents").Array().Index(0).Value().Document()
+		if got := doc.Lookup("route_key").StringValue(); got != "hashed:100.1,13.1;100.2,13.2" {
+			t.Errorf("expected the route key to be stored hashed, got %s", got)
+		}
+	})
+
+	mt.Run("find", func(mt *mtest.T) {
+		// Setup
+		ns := mtest.TestDb + "." + mongo.QuotesCollection
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "userID", Value: "user-123"},
+			{Key: "route_key", Value: "hashed:100.1,13.1;100.2,13.2"},
+		}))
+		repo := NewRepository(mt.DB, WithRouteKeyHash(hash))
+
+		// Execute
+		quote, err := repo.FindRecentQuote(context.Background(), "user-123", "100.1,13.1;100.2,13.2", time.Now().Add(-time.Minute))
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		filter := mt.GetStartedEvent().Command.Lookup("filter").Document()
+		if got := filter.Lookup("route_key").StringValue(); got != "hashed:100.1,13.1;100.2,13.2" {
+			t.Errorf("expected the lookup by hashed route key, got %s", got)
+		}
+		if quote.RouteKey != "100.1,13.1;100.2,13.2" {
+			t.Errorf("expected the plain route key on the quote, got %s", quote.RouteKey)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tripMongo "github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// relatedCollections hold documents keyed by trip_id that leave with their
+// trip. Trip events must go too, or a replay would rebuild archived trips.
+var relatedCollections = []string{tripMongo.TripEventsCollection, tripMongo.RatingsCollection}
+
+type Report struct {
+	Archived int
+	DryRun   bool
+}
+
+// Archiver moves old finished trips out of the live collections
+type Archiver interface {
+	Run(ctx context.Context) (*Report, error)
+}
+
+type archiver struct {
+	db       *mongo.Database
+	sink     Sink
+	cfg      *Config
+	redactor *redactor
+	now      func() time.Time
+}
+
+func NewArchiver(db *mongo.Database, sink Sink, cfg *Config) Archiver {
+	return &archiver{
+		db:       db,
+		sink:     sink,
+		cfg:      cfg,
+		redactor: newRedactor(cfg.Redaction, cfg.RedactionSalt),
+		now:      time.Now,
+	}
+}
+
+// Run archives trips in batches: each batch is written to the sink before it
+// is deleted, so an interrupted run loses nothing and the next run picks up
+// where it stopped.
+func (a *archiver) Run(ctx context.Context) (*Report, error) {
+	report := &Report{DryRun: a.cfg.DryRun}
+
+	// Trips have no creation date of their own, their ObjectID carries it
+	cutoff := primitive.NewObjectIDFromTimestamp(a.now().Add(-a.cfg.MaxAge))
+	last := primitive.NilObjectID
+
+	for {
+		filter := bson.M{
+			"_id":    bson.M{"$lt": cutoff, "$gt": last},
+			"status": bson.M{"$in": a.cfg.Statuses},
+		}
+		opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(a.cfg.BatchSize))
+
+		var trips []bson.D
+		cursor, err := a.db.Collection(tripMongo.TripsCollection).Find(ctx, filter, opts)
+		if err != nil {
+			return report, fmt.Errorf("failed to find trips to archive: %w", err)
+		}
+		if err := cursor.All(ctx, &trips); err != nil {
+			return report, fmt.Errorf("failed to find trips to archive: %w", err)
+		}
+		if len(trips) == 0 {
+			return report, nil
+		}
+
+		ids := make([]primitive.ObjectID, 0, len(trips))
+		for _, trip := range trips {
+			if id, ok := lookup(trip, "_id").(primitive.ObjectID); ok {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) != len(trips) {
+			return report, fmt.Errorf("found trips without an ObjectID")
+		}
+		last = ids[len(ids)-1]
+
+		if !a.cfg.DryRun {
+			if err := a.archive(ctx, trips, ids); err != nil {
+				return report, err
+			}
+		}
+		report.Archived += len(trips)
+	}
+}
+
+func (a *archiver) archive(ctx context.Context, trips []bson.D, ids []primitive.ObjectID) error {
+	tripIDs := make([]string, len(ids))
+	records := make([]Record, len(trips))
+	index := map[string]*Record{}
+	for i, trip := range trips {
+		tripIDs[i] = ids[i].Hex()
+		records[i] = Record{
+			Trip:    a.redactor.redact(tripMongo.TripsCollection, trip),
+			Related: map[string][]bson.D{},
+		}
+		index[tripIDs[i]] = &records[i]
+	}
+
+	for _, collection := range relatedCollections {
+		var docs []bson.D
+		cursor, err := a.db.Collection(collection).Find(ctx, bson.M{"trip_id": bson.M{"$in": tripIDs}})
+		if err != nil {
+			return fmt.Errorf("failed to read %s to archive: %w", collection, err)
+		}
+		if err := cursor.All(ctx, &docs); err != nil {
+			return fmt.Errorf("failed to read %s to archive: %w", collection, err)
+		}
+
+		for _, doc := range docs {
+			tripID, _ := lookup(doc, "trip_id").(string)
+			if record, ok := index[tripID]; ok {
+				record.Related[collection] = append(record.Related[collection], a.redactor.redact(collection, doc))
+			}
+		}
+	}
+
+	if err := a.sink.Write(ctx, records); err != nil {
+		return err
+	}
+
+	// Related documents first: events left behind by an interrupted run would
+	// let a replay rebuild the trip
+	for _, collection := range relatedCollections {
+		if _, err := a.db.Collection(collection).DeleteMany(ctx, bson.M{"trip_id": bson.M{"$in": tripIDs}}); err != nil {
+			return fmt.Errorf("failed to delete archived %s: %w", collection, err)
+		}
+	}
+	if _, err := a.db.Collection(tripMongo.TripsCollection).DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return fmt.Errorf("failed to delete archived trips: %w", err)
+	}
+
+	return nil
+}
+
+func lookup(doc bson.D, key string) interface{} {
+	for _, e := range doc {
+		if e.Key == key {
+			return e.Value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package retention
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tripMongo "github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+type recordingSink struct {
+	records []Record
+}
+
+func (s *recordingSink) Write(ctx context.Context, records []Record) error {
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func testConfig() *Config {
+	return &Config{
+		MaxAge:    90 * 24 * time.Hour,
+		Statuses:  []string{"paid"},
+		Target:    TargetCollection,
+		Redaction: RedactionNone,
+		BatchSize: 100,
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(cfg *Config) {}},
+		{name: "zero max age", modify: func(cfg *Config) { cfg.MaxAge = 0 }, wantErr: true},
+		{name: "unknown target", modify: func(cfg *Config) { cfg.Target = "s3" }, wantErr: true},
+		{name: "file target without directory", modify: func(cfg *Config) { cfg.Target = TargetFile }, wantErr: true},
+		{name: "pseudonymize without salt", modify: func(cfg *Config) { cfg.Redaction = RedactionPseudonymize }, wantErr: true},
+		{name: "unknown redaction", modify: func(cfg *Config) { cfg.Redaction = "hash" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			tt.modify(cfg)
+
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestArchiverRun(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	tripsNS := mtest.TestDb + "." + tripMongo.TripsCollection
+	eventsNS := mtest.TestDb + "." + tripMongo.TripEventsCollection
+	ratingsNS := mtest.TestDb + "." + tripMongo.RatingsCollection
+
+	tripID := primitive.NewObjectIDFromTimestamp(time.Now().Add(-100 * 24 * time.Hour))
+	trip := bson.D{{Key: "_id", Value: tripID}, {Key: "userID", Value: "user-123"}, {Key: "status", Value: "paid"}}
+	event := bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "trip_id", Value: tripID.Hex()}}
+
+	mt.Run("moves trips with their events", func(mt *mtest.T) {
+		// Setup
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, tripsNS, mtest.FirstBatch, trip),
+			mtest.CreateCursorResponse(0, eventsNS, mtest.FirstBatch, event),
+			mtest.CreateCursorResponse(0, ratingsNS, mtest.FirstBatch),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateCursorResponse(0, tripsNS, mtest.FirstBatch),
+		)
+		sink := &recordingSink{}
+		cfg := testConfig()
+		cfg.Redaction = RedactionStrip
+
+		// Execute
+		report, err := NewArchiver(mt.DB, sink, cfg).Run(context.Background())
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if report.Archived != 1 {
+			t.Errorf("expected 1 trip archived, got %d", report.Archived)
+		}
+		if len(sink.records) != 1 || len(sink.records[0].Related[tripMongo.TripEventsCollection]) != 1 {
+			t.Fatalf("expected the trip and its event in the sink, got %+v", sink.records)
+		}
+		if lookup(sink.records[0].Trip, "userID") != nil {
+			t.Error("expected the archived trip to be redacted")
+		}
+
+		deletes := 0
+		for started := mt.GetStartedEvent(); started != nil; started = mt.GetStartedEvent() {
+			if started.CommandName == "delete" {
+				deletes++
+			}
+		}
+		if deletes != 3 {
+			t.Errorf("expected the trip, its events and ratings to be deleted, got %d deletes", deletes)
+		}
+	})
+
+	mt.Run("dry run", func(mt *mtest.T) {
+		// Setup
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, tripsNS, mtest.FirstBatch, trip),
+			mtest.CreateCursorResponse(0, tripsNS, mtest.FirstBatch),
+		)
+		sink := &recordingSink{}
+		cfg := testConfig()
+		cfg.DryRun = true
+
+		// Execute
+		report, err := NewArchiver(mt.DB, sink, cfg).Run(context.Background())
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if report.Archived != 1 || !report.DryRun {
+			t.Errorf("expected 1 trip reported in a dry run, got %+v", report)
+		}
+		if len(sink.records) != 0 {
+			t.Errorf("expected nothing written in a dry run, got %d records", len(sink.records))
+		}
+		for started := mt.GetStartedEvent(); started != nil; started = mt.GetStartedEvent() {
+			if started.CommandName != "find" {
+				t.Errorf("expected only reads in a dry run, got %s", started.CommandName)
+			}
+		}
+	})
+}
+
+func TestFileSink(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	records := []Record{
+		{Trip: bson.D{{Key: "_id", Value: "trip-1"}}, Related: map[string][]bson.D{
+			tripMongo.TripEventsCollection: {{{Key: "trip_id", Value: "trip-1"}}},
+		}},
+		{Trip: bson.D{{Key: "_id", Value: "trip-2"}}},
+	}
+
+	// Execute
+	if err := sink.Write(context.Background(), records); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Verify
+	f, err := os.Open(filepath.Join(dir, "trips-20260102T030405Z-0001.ndjson.gz"))
+	if err != nil {
+		t.Fatalf("expected the archive file, got %v", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("expected a gzip file, got %v", err)
+	}
+
+	var lines []bson.D
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var line bson.D
+		if err := bson.UnmarshalExtJSON(scanner.Bytes(), false, &line); err != nil {
+			t.Fatalf("expected extended JSON, got %v", err)
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	if trip := lookup(lines[0], "trip").(bson.D); lookup(trip, "_id") != "trip-1" {
+		t.Errorf("unexpected first trip: %v", trip)
+	}
+	if events, ok := lookup(lines[0], tripMongo.TripEventsCollection).(bson.A); !ok || len(events) != 1 {
+		t.Errorf("expected the events of the first trip, got %v", lookup(lines[0], tripMongo.TripEventsCollection))
+	}
+
+	if matches, _ := filepath.Glob(filepath.Join(dir, ".trips-*")); len(matches) != 0 {
+		t.Errorf("expected no temporary files left, got %v", matches)
+	}
+}
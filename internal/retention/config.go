@@ -0,0 +1,80 @@
+package retention
+
+import (
+	"fmt"
+	"time"
+)
+
+// Archive targets
+const (
+	TargetCollection = "collection"
+	TargetFile       = "file"
+)
+
+// Redaction modes
+const (
+	// RedactionNone archives trips as they are
+	RedactionNone = "none"
+	// RedactionPseudonymize replaces rider and driver IDs with salted hashes,
+	// so archived trips of the same person can still be grouped, drops names,
+	// pictures, car plates and comments, and keeps only the route endpoints
+	// rounded to about a kilometer
+	RedactionPseudonymize = "pseudonymize"
+	// RedactionStrip removes IDs, driver details, comments and routes
+	RedactionStrip = "strip"
+)
+
+type Config struct {
+	// MaxAge is how long a trip stays in the trips collection after it was
+	// created
+	MaxAge time.Duration
+	// Statuses are the final statuses a trip must be in to be archived
+	Statuses []string
+	// Target is where trips go: the archive collections or NDJSON files.
+	// User data erasure rewrites the archive collections but cannot reach
+	// the files, so files must be written pseudonymized or stripped.
+	Target string
+	// Dir receives the gzipped NDJSON files of the file target
+	Dir       string
+	Redaction string
+	// RedactionSalt keys the hashes of pseudonymized IDs. Without it an ID
+	// could be recovered by hashing candidate IDs.
+	RedactionSalt string
+	BatchSize     int
+	// DryRun reports what would be archived without writing or deleting
+	DryRun bool
+}
+
+func (c *Config) Validate() error {
+	if c.MaxAge <= 0 {
+		return fmt.Errorf("retention max age must be positive")
+	}
+	if len(c.Statuses) == 0 {
+		return fmt.Errorf("at least one trip status to archive is required")
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("retention batch size must be positive")
+	}
+
+	switch c.Target {
+	case TargetCollection:
+	case TargetFile:
+		if c.Dir == "" {
+			return fmt.Errorf("an archive directory is required for the file target")
+		}
+	default:
+		return fmt.Errorf("unknown archive target %q", c.Target)
+	}
+
+	switch c.Redaction {
+	case RedactionNone, RedactionStrip:
+	case RedactionPseudonymize:
+		if c.RedactionSalt == "" {
+			return fmt.Errorf("a redaction salt is required to pseudonymize")
+		}
+	default:
+		return fmt.Errorf("unknown redaction mode %q", c.Redaction)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package retention
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+
+	"github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"github.com/ride4Low/trip-service/internal/bsonpath"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// coarsePrecision rounds coordinates to 2 decimals, about a kilometer
+const coarsePrecision = 2
+
+// fieldRules lists the personal fields of the documents of a collection.
+// Paths are matched case-insensitively, as drivers are stored with the
+// default lowercased BSON keys of the protobuf types.
+type fieldRules struct {
+	ids     []string
+	removed []string
+	routes  []string
+	// embedded maps the paths of embedded documents to the collection whose
+	// rules redact them
+	embedded map[string]string
+}
+
+var driverDetails = []string{"driver.name", "driver.profilepicture", "driver.carplate"}
+
+var collectionRules = map[string]fieldRules{
+	mongo.TripsCollection: {
+		ids:     []string{"userID", "rideFare.userID", "driver.id", "tip.rider_id", "tip.driver_id"},
+		removed: driverDetails,
+		routes:  []string{"rideFare.route"},
+		embedded: map[string]string{
+			"ratings.rider":  mongo.RatingsCollection,
+			"ratings.driver": mongo.RatingsCollection,
+		},
+	},
+	mongo.TripEventsCollection: {
+		ids:     []string{"actor.id", "driver.id"},
+		removed: driverDetails,
+		embedded: map[string]string{
+			"before": mongo.TripsCollection,
+			"after":  mongo.TripsCollection,
+		},
+	},
+	mongo.RatingsCollection: {
+		ids:     []string{"rater_id", "ratee_id"},
+		removed: []string{"comment"},
+	},
+}
+
+type redactor struct {
+	mode string
+	salt []byte
+}
+
+func newRedactor(mode, salt string) *redactor {
+	return &redactor{mode: mode, salt: []byte(salt)}
+}
+
+// redact returns a copy of a document of the collection without its
+// personal data
+func (r *redactor) redact(collection string, doc bson.D) bson.D {
+	if r.mode == RedactionNone {
+		return doc
+	}
+
+	rules := collectionRules[collection]
+	doc = bsonpath.Clone(doc)
+
+	for _, path := range rules.ids {
+		doc = bsonpath.Update(doc, bsonpath.Split(path), r.id)
+	}
+	for _, path := range rules.removed {
+		doc = bsonpath.Update(doc, bsonpath.Split(path), bsonpath.Remove)
+	}
+	for _, path := range rules.routes {
+		doc = bsonpath.Update(doc, bsonpath.Split(path), r.route)
+	}
+	for path, embedded := range rules.embedded {
+		doc = bsonpath.Update(doc, bsonpath.Split(path), bsonpath.Embedded(func(sub bson.D) bson.D {
+			return r.redact(embedded, sub)
+		}))
+	}
+
+	return doc
+}
+
+func (r *redactor) id(v interface{}) (interface{}, bool) {
+	id, ok := v.(string)
+	if !ok || id == "" || r.mode == RedactionStrip {
+		return nil, false
+	}
+
+	mac := hmac.New(sha256.New, r.salt)
+	mac.Write([]byte(id))
+	return "anon-" + hex.EncodeToString(mac.Sum(nil))[:24], true
+}
+
+// route keeps the distance and duration, and under pseudonymization the
+// rounded endpoints of the geometry. Encrypted geometry cannot be rounded and
+// is dropped.
+func (r *redactor) route(v interface{}) (interface{}, bool) {
+	if r.mode == RedactionStrip {
+		return nil, false
+	}
+
+	return bsonpath.UpdateRouteLegs(func(leg bson.D) bson.D {
+		return bsonpath.Update(leg, []string{"geometry", "coordinates"}, coarsenCoordinates)
+	})(v)
+}
+
+func coarsenCoordinates(v interface{}) (interface{}, bool) {
+	coords, ok := v.(bson.A)
+	if !ok || len(coords) == 0 {
+		return v, true
+	}
+
+	endpoints := bson.A{coords[0]}
+	if len(coords) > 1 {
+		endpoints = append(endpoints, coords[len(coords)-1])
+	}
+
+	for i, point := range endpoints {
+		p, ok := point.(bson.A)
+		if !ok {
+			continue
+		}
+		rounded := make(bson.A, len(p))
+		for j, c := range p {
+			rounded[j] = c
+			if f, ok := c.(float64); ok {
+				scale := math.Pow(10, coarsePrecision)
+				rounded[j] = math.Round(f*scale) / scale
+			}
+		}
+		endpoints[i] = rounded
+	}
+
+	return endpoints, true
+}
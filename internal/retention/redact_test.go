@@ -0,0 +1,148 @@
+package retention
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func testTrip() bson.D {
+	return bson.D{
+		{Key: "_id", Value: "trip-1"},
+		{Key: "userID", Value: "user-123"},
+		{Key: "status", Value: "paid"},
+		{Key: "rideFare", Value: bson.D{
+			{Key: "userID", Value: "user-123"},
+			{Key: "totalPriceInCents", Value: 1850.0},
+			{Key: "route", Value: bson.D{
+				{Key: "routes", Value: bson.A{
+					bson.D{
+						{Key: "distance", Value: 1000.0},
+						{Key: "geometry", Value: bson.D{
+							{Key: "coordinates", Value: bson.A{
+								bson.A{100.12345, 13.98765},
+								bson.A{100.2, 13.5},
+								bson.A{100.56789, 13.01234},
+							}},
+						}},
+					},
+				}},
+			}},
+		}},
+		{Key: "driver", Value: bson.D{
+			{Key: "id", Value: "driver-1"},
+			{Key: "name", Value: "Jane"},
+			{Key: "profilepicture", Value: "https://example.com/jane.png"},
+			{Key: "carplate", Value: "AB-123"},
+		}},
+		{Key: "ratings", Value: bson.D{
+			{Key: "rider", Value: bson.D{
+				{Key: "rater_id", Value: "user-123"},
+				{Key: "stars", Value: 5},
+				{Key: "comment", Value: "Great ride with Jane"},
+			}},
+		}},
+	}
+}
+
+func TestRedact(t *testing.T) {
+	t.Run("none keeps the document", func(t *testing.T) {
+		doc := newRedactor(RedactionNone, "").redact(mongo.TripsCollection, testTrip())
+
+		if lookup(doc, "userID") != "user-123" {
+			t.Errorf("expected the user ID to be kept, got %v", lookup(doc, "userID"))
+		}
+	})
+
+	t.Run("pseudonymize", func(t *testing.T) {
+		original := testTrip()
+		doc := newRedactor(RedactionPseudonymize, "salt").redact(mongo.TripsCollection, original)
+
+		userID, _ := lookup(doc, "userID").(string)
+		if !strings.HasPrefix(userID, "anon-") {
+			t.Errorf("expected a pseudonymized user ID, got %q", userID)
+		}
+		fareUserID := lookup(lookup(doc, "rideFare").(bson.D), "userID")
+		if fareUserID != userID {
+			t.Errorf("expected the same pseudonym on the fare, got %v and %v", fareUserID, userID)
+		}
+		if other := newRedactor(RedactionPseudonymize, "other").redact(mongo.TripsCollection, testTrip()); lookup(other, "userID") == userID {
+			t.Error("expected the pseudonym to depend on the salt")
+		}
+
+		driver := lookup(doc, "driver").(bson.D)
+		if len(driver) != 1 || !strings.HasPrefix(lookup(driver, "id").(string), "anon-") {
+			t.Errorf("expected only a pseudonymized driver ID, got %v", driver)
+		}
+
+		route := lookup(lookup(doc, "rideFare").(bson.D), "route").(bson.D)
+		leg := lookup(route, "routes").(bson.A)[0].(bson.D)
+		coords := lookup(lookup(leg, "geometry").(bson.D), "coordinates").(bson.A)
+		if len(coords) != 2 {
+			t.Fatalf("expected only the endpoints, got %v", coords)
+		}
+		if start := coords[0].(bson.A); start[0] != 100.12 || start[1] != 13.99 {
+			t.Errorf("expected the start rounded to 2 decimals, got %v", start)
+		}
+		if lookup(leg, "distance") != 1000.0 {
+			t.Errorf("expected the distance to be kept, got %v", lookup(leg, "distance"))
+		}
+
+		rating := lookup(lookup(doc, "ratings").(bson.D), "rider").(bson.D)
+		if lookup(rating, "rater_id") != userID || lookup(rating, "comment") != nil || lookup(rating, "stars") != 5 {
+			t.Errorf("expected the embedded rating to be redacted, got %v", rating)
+		}
+
+		if lookup(original, "userID") != "user-123" || len(lookup(original, "driver").(bson.D)) != 4 {
+			t.Error("expected the original document to be unchanged")
+		}
+	})
+
+	t.Run("strip", func(t *testing.T) {
+		doc := newRedactor(RedactionStrip, "").redact(mongo.TripsCollection, testTrip())
+
+		if lookup(doc, "userID") != nil {
+			t.Errorf("expected the user ID to be removed, got %v", lookup(doc, "userID"))
+		}
+		if driver := lookup(doc, "driver").(bson.D); len(driver) != 0 {
+			t.Errorf("expected the driver details to be removed, got %v", driver)
+		}
+		fare := lookup(doc, "rideFare").(bson.D)
+		if lookup(fare, "route") != nil || lookup(fare, "totalPriceInCents") != 1850.0 {
+			t.Errorf("expected only the route to be removed from the fare, got %v", fare)
+		}
+	})
+
+	t.Run("encrypted geometry is dropped", func(t *testing.T) {
+		trip := bson.D{{Key: "rideFare", Value: bson.D{{Key: "route", Value: bson.D{
+			{Key: "routes", Value: bson.A{bson.D{{Key: "distance", Value: 1000.0}}}},
+			{Key: "geometry", Value: bson.D{{Key: "key_id", Value: "k1"}, {Key: "data", Value: []byte("sealed")}}},
+		}}}}}
+
+		doc := newRedactor(RedactionPseudonymize, "salt").redact(mongo.TripsCollection, trip)
+
+		route := lookup(lookup(doc, "rideFare").(bson.D), "route").(bson.D)
+		if lookup(route, "geometry") != nil || lookup(route, "routes") == nil {
+			t.Errorf("expected only the encrypted geometry to be removed, got %v", route)
+		}
+	})
+
+	t.Run("embedded trips of events", func(t *testing.T) {
+		event := bson.D{
+			{Key: "trip_id", Value: "trip-1"},
+			{Key: "actor", Value: bson.D{{Key: "type", Value: "rider"}, {Key: "id", Value: "user-123"}}},
+			{Key: "after", Value: testTrip()},
+		}
+
+		doc := newRedactor(RedactionStrip, "").redact(mongo.TripEventsCollection, event)
+
+		if actor := lookup(doc, "actor").(bson.D); lookup(actor, "id") != nil {
+			t.Errorf("expected the actor ID to be removed, got %v", actor)
+		}
+		if after := lookup(doc, "after").(bson.D); lookup(after, "userID") != nil {
+			t.Errorf("expected the trip snapshot to be redacted, got %v", after)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package retention
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tripMongo "github.com/ride4Low/trip-service/internal/adapter/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ArchiveSuffix names the archive collection of a collection, e.g.
+// trips_archive
+const ArchiveSuffix = "_archive"
+
+// Record is an archived trip with the documents of the other collections
+// that belong to it
+type Record struct {
+	Trip    bson.D
+	Related map[string][]bson.D
+}
+
+// Sink stores archived records. Write must only return once the records are
+// durable, as they are deleted from the live collections right after.
+type Sink interface {
+	Write(ctx context.Context, records []Record) error
+}
+
+type collectionSink struct {
+	db *mongo.Database
+}
+
+// NewCollectionSink moves records into the archive collections of the same
+// database
+func NewCollectionSink(db *mongo.Database) Sink {
+	return &collectionSink{db: db}
+}
+
+func (s *collectionSink) Write(ctx context.Context, records []Record) error {
+	docs := map[string][]interface{}{}
+	for _, record := range records {
+		docs[tripMongo.TripsCollection] = append(docs[tripMongo.TripsCollection], record.Trip)
+		for collection, related := range record.Related {
+			for _, doc := range related {
+				docs[collection] = append(docs[collection], doc)
+			}
+		}
+	}
+
+	for collection, batch := range docs {
+		// Unordered, so a batch copied before an interrupted run is skipped
+		// as duplicates instead of stopping the insert
+		_, err := s.db.Collection(collection+ArchiveSuffix).InsertMany(ctx, batch, options.InsertMany().SetOrdered(false))
+		if err != nil && !onlyDuplicateKeys(err) {
+			return fmt.Errorf("failed to archive %s: %w", collection, err)
+		}
+	}
+
+	return nil
+}
+
+func onlyDuplicateKeys(err error) bool {
+	bulkErr, ok := err.(mongo.BulkWriteException)
+	if !ok || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != 11000 {
+			return false
+		}
+	}
+	return true
+}
+
+type fileSink struct {
+	dir    string
+	prefix string
+	seq    int
+}
+
+// NewFileSink writes every batch of records to its own gzipped NDJSON file
+// in dir, one record per line as relaxed extended JSON:
+//
+//	{"trip": {...}, "trip_events": [...], "ratings": [...]}
+func NewFileSink(dir string, now time.Time) (Sink, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create the archive directory: %w", err)
+	}
+
+	return &fileSink{
+		dir:    dir,
+		prefix: "trips-" + now.UTC().Format("20060102T150405Z"),
+	}, nil
+}
+
+func (s *fileSink) Write(ctx context.Context, records []Record) error {
+	s.seq++
+	name := filepath.Join(s.dir, fmt.Sprintf("%s-%04d.ndjson.gz", s.prefix, s.seq))
+
+	// Written under a temporary name, so a file with its final name is
+	// always complete
+	tmp, err := os.CreateTemp(s.dir, ".trips-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := writeRecords(tmp, records); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", name, err)
+	}
+
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+func writeRecords(f *os.File, records []Record) error {
+	gz := gzip.NewWriter(f)
+	w := bufio.NewWriter(gz)
+
+	for _, record := range records {
+		line := bson.D{{Key: "trip", Value: record.Trip}}
+		for _, collection := range relatedCollections {
+			line = append(line, bson.E{Key: collection, Value: record.Related[collection]})
+		}
+
+		data, err := bson.MarshalExtJSON(line, false, false)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,97 @@
+// Package retry retries operations on a dependency with exponential backoff.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy bounds the retries of an operation
+type Policy struct {
+	// InitialInterval is the wait after the first failure, doubled after
+	// each following one
+	InitialInterval time.Duration
+	// MaxInterval caps the wait between two attempts
+	MaxInterval time.Duration
+	// MaxElapsed gives up once that much time has passed since the first
+	// attempt. Zero retries until the context is done.
+	MaxElapsed time.Duration
+}
+
+// DefaultPolicy gives a dependency about two minutes to come up
+func DefaultPolicy() Policy {
+	return Policy{
+		InitialInterval: 500 * time.Millisecond,
+		MaxInterval:     15 * time.Second,
+		MaxElapsed:      2 * time.Minute,
+	}
+}
+
+// Operation is retried until it succeeds
+type Operation func(ctx context.Context) error
+
+// Notify is told about each failed attempt before waiting for the next one
+type Notify func(attempt int, err error, wait time.Duration)
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error that retrying cannot fix, such as an invalid
+// configuration
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Do runs op until it succeeds, it fails with a Permanent error, ctx is done
+// or policy.MaxElapsed has passed. The last error is returned.
+func Do(ctx context.Context, policy Policy, op Operation, notify Notify) error {
+	started := time.Now()
+	wait := policy.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		err := op(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+
+		delay := jitter(wait)
+		if policy.MaxElapsed > 0 && time.Since(started)+delay > policy.MaxElapsed {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+		if notify != nil {
+			notify(attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, errors.Join(ctx.Err(), err))
+		case <-timer.C:
+		}
+
+		wait = min(2*wait, policy.MaxInterval)
+	}
+}
+
+// jitter spreads the wait between half and all of it, so replicas started
+// together do not retry in lockstep
+func jitter(wait time.Duration) time.Duration {
+	if wait <= 1 {
+		return wait
+	}
+	half := wait / 2
+	return half + rand.N(wait-half)
+}
@@ -0,0 +1,106 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testPolicy() Policy {
+	return Policy{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     4 * time.Millisecond,
+		MaxElapsed:      time.Second,
+	}
+}
+
+func TestDo(t *testing.T) {
+	errDown := errors.New("connection refused")
+
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		wantAttempts int
+		wantErr      error
+	}{
+		{name: "first attempt", wantAttempts: 1},
+		{name: "recovers", failures: 3, err: errDown, wantAttempts: 4},
+		{name: "permanent", failures: 3, err: Permanent(errDown), wantAttempts: 1, wantErr: errDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			attempts, notified := 0, 0
+
+			// Execute
+			err := Do(context.Background(), testPolicy(), func(ctx context.Context) error {
+				attempts++
+				if attempts <= tt.failures {
+					return tt.err
+				}
+				return nil
+			}, func(attempt int, err error, wait time.Duration) {
+				notified++
+				if wait > testPolicy().MaxInterval {
+					t.Errorf("expected at most %s between attempts, got %s", testPolicy().MaxInterval, wait)
+				}
+			})
+
+			// Verify
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+			if notified != attempts-1 {
+				t.Errorf("expected %d retries notified, got %d", attempts-1, notified)
+			}
+		})
+	}
+}
+
+func TestDoGivesUp(t *testing.T) {
+	// Setup
+	policy := Policy{InitialInterval: 20 * time.Millisecond, MaxInterval: 20 * time.Millisecond, MaxElapsed: 50 * time.Millisecond}
+	errDown := errors.New("connection refused")
+	attempts := 0
+	started := time.Now()
+
+	// Execute
+	err := Do(context.Background(), policy, func(ctx context.Context) error {
+		attempts++
+		return errDown
+	}, nil)
+
+	// Verify
+	if !errors.Is(err, errDown) {
+		t.Errorf("expected the last error, got %v", err)
+	}
+	if attempts < 2 {
+		t.Errorf("expected retries before giving up, got %d attempts", attempts)
+	}
+	if elapsed := time.Since(started); elapsed > policy.MaxElapsed {
+		t.Errorf("expected to give up within %s, took %s", policy.MaxElapsed, elapsed)
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	// Setup
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := Policy{InitialInterval: time.Hour, MaxInterval: time.Hour}
+
+	// Execute
+	err := Do(ctx, policy, func(ctx context.Context) error {
+		cancel()
+		return errors.New("connection refused")
+	}, nil)
+
+	// Verify
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ride4Low/contracts/proto/trip"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+)
+
+// NewAdminService is the service support repairs trips with. It shares the
+// options of NewService, so it records trip events the same way.
+func NewAdminService(repo domain.Repository, opts ...Option) domain.AdminService {
+	return NewService(nil, repo, opts...).(*service)
+}
+
+func (s *service) ForceTransition(ctx context.Context, tripID, status, reason string) (*types.Trip, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		return nil, domain.ErrReasonRequired
+	}
+	if !domain.KnownTripStatus(status) {
+		return nil, fmt.Errorf("%w: %q", domain.ErrUnknownStatus, status)
+	}
+
+	return s.changeTrip(ctx, tripID, func(before *types.Trip) (*domain.TripEvent, error) {
+		if before.Status == status {
+			return nil, fmt.Errorf("%w: %s", domain.ErrTripNotModified, tripID)
+		}
+
+		event := s.newEvent(ctx, domain.TripEventStatusChanged, tripID)
+		event.FromStatus = before.Status
+		event.ToStatus = status
+		event.Reason = reason
+		return event, nil
+	}, func(ctx context.Context) error {
+		return s.repo.UpdateTrip(ctx, tripID, status, nil)
+	})
+}
+
+func (s *service) AssignDriver(ctx context.Context, tripID string, driver *trip.TripDriver, reason string) (*types.Trip, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		return nil, domain.ErrReasonRequired
+	}
+
+	return s.changeTrip(ctx, tripID, func(before *types.Trip) (*domain.TripEvent, error) {
+		event := s.newEvent(ctx, domain.TripEventDriverChanged, tripID)
+		event.FromStatus = before.Status
+		event.ToStatus = before.Status
+		event.Driver = driver
+		event.Reason = reason
+		return event, nil
+	}, func(ctx context.Context) error {
+		return s.repo.SetTripDriver(ctx, tripID, driver)
+	})
+}
+
+// changeTrip applies the event built by newEvent to a trip. With event
+// sourcing the event is appended to the stream and projected, otherwise the
+// trip is written by update and the event recorded for the audit trail.
+func (s *service) changeTrip(ctx context.Context, tripID string, newEvent func(before *types.Trip) (*domain.TripEvent, error), update func(ctx context.Context) error) (*types.Trip, error) {
+	if s.eventSourced {
+		return s.changeTripFromEvents(ctx, tripID, newEvent)
+	}
+
+	before, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := newEvent(before)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := domain.ApplyTripEvent(before, event)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := update(ctx); err != nil {
+		return nil, err
+	}
+
+	event.Before = before
+	event.After = after
+	s.recordEvent(ctx, event)
+
+	return after, nil
+}
+
+func (s *service) GetTrip(ctx context.Context, tripID string) (*types.Trip, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetTripByID(ctx, tripID)
+}
+
+func (s *service) ExpireQuotes(ctx context.Context, filter domain.QuoteFilter) (int, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return 0, err
+	}
+	if len(filter.IDs) == 0 && filter.UserID == "" {
+		return 0, domain.ErrNoQuoteSelected
+	}
+
+	// A second past the TTL, as fares are bookable up to the TTL itself
+	expired, err := s.repo.ExpireQuotes(ctx, filter, time.Now().Add(-domain.RideFareTTL-time.Second))
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire quotes: %w", err)
+	}
+
+	meta := domain.EventMetaFromContext(ctx)
+	s.logger.InfoContext(ctx, "Expired quotes", "actor_id", meta.Actor.ID, "quote_ids", filter.IDs, "user_id", filter.UserID, "expired", expired)
+
+	return expired, nil
+}
+
+func (s *service) SearchTrips(ctx context.Context, filter domain.TripFilter) ([]*types.Trip, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	filter.Limit = filter.PageSize()
+
+	trips, err := s.repo.SearchTrips(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search trips: %w", err)
+	}
+
+	return trips, nil
+}
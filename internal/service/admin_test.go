@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ride4Low/contracts/proto/driver"
+	"github.com/ride4Low/contracts/proto/trip"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAdminOperationsRequireAdmin(t *testing.T) {
+	tripID := primitive.NewObjectID()
+	mockRepo := &mockRepository{
+		getTripFunc: func(ctx context.Context, id string) (*types.Trip, error) {
+			return &types.Trip{ID: tripID, UserID: "user-123", Status: domain.TripStatusAccepted, Driver: &trip.TripDriver{Id: "driver-1"}}, nil
+		},
+		updateTripFunc: func(ctx context.Context, id string, status string, d *driver.Driver) error {
+			return nil
+		},
+	}
+	svc := NewAdminService(mockRepo, WithLogger(logging.Discard()))
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		allowed bool
+	}{
+		{name: "admin", ctx: withPrincipal("ops-1", domain.RoleAdmin), allowed: true},
+		{name: "internal caller", ctx: context.Background(), allowed: true},
+		{name: "rider", ctx: withPrincipal("user-123", domain.RoleRider), allowed: false},
+		{name: "driver", ctx: withPrincipal("driver-1", domain.RoleDriver), allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			errs := []error{}
+			_, err := svc.ForceTransition(tt.ctx, tripID.Hex(), domain.TripStatusPaid, "stuck payment")
+			errs = append(errs, err)
+			_, err = svc.AssignDriver(tt.ctx, tripID.Hex(), nil, "driver left")
+			errs = append(errs, err)
+			_, err = svc.GetTrip(tt.ctx, tripID.Hex())
+			errs = append(errs, err)
+			_, err = svc.ExpireQuotes(tt.ctx, domain.QuoteFilter{UserID: "user-123"})
+			errs = append(errs, err)
+			_, err = svc.SearchTrips(tt.ctx, domain.TripFilter{})
+			errs = append(errs, err)
+
+			// Verify
+			for i, err := range errs {
+				if tt.allowed && err != nil {
+					t.Errorf("operation %d: expected no error, got %v", i, err)
+				}
+				if !tt.allowed && !errors.Is(err, domain.ErrForbidden) {
+					t.Errorf("operation %d: expected ErrForbidden, got %v", i, err)
+				}
+			}
+		})
+	}
+}
+
+func TestForceTransition(t *testing.T) {
+	tripID := primitive.NewObjectID()
+	newRepo := func(events *[]*domain.TripEvent) *mockRepository {
+		status := domain.TripStatusAccepted
+		return &mockRepository{
+			getTripFunc: func(ctx context.Context, id string) (*types.Trip, error) {
+				return &types.Trip{ID: tripID, UserID: "user-123", Status: status}, nil
+			},
+			updateTripFunc: func(ctx context.Context, id string, s string, d *driver.Driver) error {
+				status = s
+				return nil
+			},
+			saveEventFunc: func(ctx context.Context, event *domain.TripEvent) error {
+				*events = append(*events, event)
+				return nil
+			},
+		}
+	}
+	ctx := domain.WithEventMeta(withPrincipal("ops-1", domain.RoleAdmin), domain.EventMeta{
+		Actor:  domain.Actor{Type: domain.ActorAdmin, ID: "ops-1"},
+		Source: trip.TripAdminService_ForceTransition_FullMethodName,
+	})
+
+	t.Run("bypasses the transition rules and records the reason", func(t *testing.T) {
+		// Setup
+		var events []*domain.TripEvent
+		svc := NewAdminService(newRepo(&events))
+
+		// Execute
+		got, err := svc.ForceTransition(ctx, tripID.Hex(), domain.TripStatusPending, "driver never arrived")
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got.Status != domain.TripStatusPending {
+			t.Errorf("expected status pending, got %s", got.Status)
+		}
+		if len(events) != 1 {
+			t.Fatalf("expected 1 event, got %d", len(events))
+		}
+		event := events[0]
+		if event.Reason != "driver never arrived" || event.Actor.ID != "ops-1" || event.FromStatus != domain.TripStatusAccepted || event.ToStatus != domain.TripStatusPending {
+			t.Errorf("unexpected event: %+v", event)
+		}
+		if event.Before.Status != domain.TripStatusAccepted || event.After.Status != domain.TripStatusPending {
+			t.Errorf("expected before and after snapshots, got %s and %s", event.Before.Status, event.After.Status)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		status  string
+		reason  string
+		wantErr error
+	}{
+		{name: "missing reason", status: domain.TripStatusPaid, wantErr: domain.ErrReasonRequired},
+		{name: "unknown status", status: "teleported", reason: "test", wantErr: domain.ErrUnknownStatus},
+		{name: "same status", status: domain.TripStatusAccepted, reason: "test", wantErr: domain.ErrTripNotModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			var events []*domain.TripEvent
+			svc := NewAdminService(newRepo(&events))
+
+			// Execute
+			_, err := svc.ForceTransition(ctx, tripID.Hex(), tt.status, tt.reason)
+
+			// Verify
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+			if len(events) != 0 {
+				t.Errorf("expected no event, got %d", len(events))
+			}
+		})
+	}
+}
+
+func TestAssignDriver(t *testing.T) {
+	t.Run("unassigns the driver", func(t *testing.T) {
+		// Setup
+		tripID := primitive.NewObjectID()
+		var driverSet *trip.TripDriver
+		var events []*domain.TripEvent
+		mockRepo := &mockRepository{
+			getTripFunc: func(ctx context.Context, id string) (*types.Trip, error) {
+				return &types.Trip{ID: tripID, Status: domain.TripStatusAccepted, Driver: &trip.TripDriver{Id: "driver-1"}}, nil
+			},
+			setDriverFunc: func(ctx context.Context, id string, d *trip.TripDriver) error {
+				driverSet = d
+				return nil
+			},
+			saveEventFunc: func(ctx context.Context, event *domain.TripEvent) error {
+				events = append(events, event)
+				return nil
+			},
+		}
+		svc := NewAdminService(mockRepo)
+
+		// Execute
+		got, err := svc.AssignDriver(context.Background(), tripID.Hex(), nil, "driver left")
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if driverSet != nil || got.Driver.GetId() != "" {
+			t.Errorf("expected the driver to be cleared, got %v", got.Driver)
+		}
+		if len(events) != 1 || events[0].Type != domain.TripEventDriverChanged || events[0].Before.Driver.GetId() != "driver-1" {
+			t.Errorf("expected a driver changed event, got %+v", events)
+		}
+		if got.Status != domain.TripStatusAccepted {
+			t.Errorf("expected the status to be kept, got %s", got.Status)
+		}
+	})
+
+	t.Run("appends to the event stream", func(t *testing.T) {
+		// Setup
+		store, repo := newEventStoreRepository()
+		svc := NewService(nil, repo, WithEventSourcing())
+		created, err := svc.CreateTrip(context.Background(), &types.RideFare{UserID: "user-123"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		admin := NewAdminService(repo, WithEventSourcing())
+
+		// Execute
+		got, err := admin.AssignDriver(context.Background(), created.ID.Hex(), &trip.TripDriver{Id: "driver-2"}, "reassigned by support")
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(store.events) != 2 || store.events[1].Version != 2 || store.events[1].Reason != "reassigned by support" {
+			t.Fatalf("expected a second event carrying the reason, got %+v", store.events)
+		}
+		if got.Driver.GetId() != "driver-2" || store.projections[created.ID.Hex()].Driver.GetId() != "driver-2" {
+			t.Errorf("expected driver-2 to be projected, got %v", store.projections[created.ID.Hex()].Driver)
+		}
+	})
+}
+
+func TestExpireQuotes(t *testing.T) {
+	t.Run("backdates past the fare ttl", func(t *testing.T) {
+		// Setup
+		var backdatedTo time.Time
+		mockRepo := &mockRepository{
+			expireQuotesFunc: func(ctx context.Context, filter domain.QuoteFilter, createdAt time.Time) (int, error) {
+				backdatedTo = createdAt
+				return 2, nil
+			},
+		}
+		svc := NewAdminService(mockRepo, WithLogger(logging.Discard()))
+
+		// Execute
+		expired, err := svc.ExpireQuotes(context.Background(), domain.QuoteFilter{UserID: "user-123"})
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if expired != 2 {
+			t.Errorf("expected 2 expired quotes, got %d", expired)
+		}
+		if time.Since(backdatedTo) <= domain.RideFareTTL {
+			t.Errorf("expected quotes to be backdated past the ttl, got %s", backdatedTo)
+		}
+	})
+
+	t.Run("requires a selection", func(t *testing.T) {
+		// Setup
+		mockRepo := &mockRepository{
+			expireQuotesFunc: func(ctx context.Context, filter domain.QuoteFilter, createdAt time.Time) (int, error) {
+				t.Error("expected no quote to be expired")
+				return 0, nil
+			},
+		}
+		svc := NewAdminService(mockRepo)
+
+		// Execute
+		_, err := svc.ExpireQuotes(context.Background(), domain.QuoteFilter{})
+
+		// Verify
+		if !errors.Is(err, domain.ErrNoQuoteSelected) {
+			t.Errorf("expected ErrNoQuoteSelected, got %v", err)
+		}
+	})
+}
+
+func TestSearchTripsLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		want  int
+	}{
+		{name: "default", limit: 0, want: domain.DefaultSearchLimit},
+		{name: "requested", limit: 10, want: 10},
+		{name: "capped", limit: 10000, want: domain.MaxSearchLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			var got int
+			mockRepo := &mockRepository{
+				searchTripsFunc: func(ctx context.Context, filter domain.TripFilter) ([]*types.Trip, error) {
+					got = filter.Limit
+					return nil, nil
+				},
+			}
+			svc := NewAdminService(mockRepo)
+
+			// Execute
+			_, err := svc.SearchTrips(context.Background(), domain.TripFilter{Limit: tt.limit})
+
+			// Verify
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected limit %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+)
+
+// authorizeUser checks that the caller acts on its own behalf. Calls without
+// a principal come from inside the service, such as event consumers and CLI
+// commands, and are trusted.
+func authorizeUser(ctx context.Context, userID string) error {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	if principal.ID != userID {
+		return fmt.Errorf("%w: cannot act on behalf of another user", domain.ErrForbidden)
+	}
+	return nil
+}
+
+// authorizeTripAccess lets the rider, the assigned driver and admins read a
+// trip
+func authorizeTripAccess(ctx context.Context, t *types.Trip) error {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok || principal.HasRole(domain.RoleAdmin) {
+		return nil
+	}
+
+	if principal.ID == t.UserID {
+		return nil
+	}
+	if driverID := t.Driver.GetId(); driverID != "" && principal.ID == driverID {
+		return nil
+	}
+	return fmt.Errorf("%w: trip belongs to another user", domain.ErrForbidden)
+}
+
+// authorizeReceiptAccess lets the rider and admins read a receipt
+func authorizeReceiptAccess(ctx context.Context, t *types.Trip) error {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok || principal.HasRole(domain.RoleAdmin) {
+		return nil
+	}
+
+	return authorizeUser(ctx, t.UserID)
+}
+
+// requireAdmin restricts an operation to admins and internal callers
+func requireAdmin(ctx context.Context) error {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok || principal.HasRole(domain.RoleAdmin) {
+		return nil
+	}
+	return fmt.Errorf("%w: admin role required", domain.ErrForbidden)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ride4Low/contracts/proto/trip"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func withPrincipal(id string, roles ...string) context.Context {
+	return domain.WithPrincipal(context.Background(), &domain.Principal{ID: id, Roles: roles})
+}
+
+func TestRiderOperationsUsePrincipal(t *testing.T) {
+	mockRepo := &mockRepository{
+		getRideFareFunc: func(ctx context.Context, id string) (*types.RideFare, error) {
+			return &types.RideFare{UserID: "user-123"}, nil
+		},
+	}
+	svc := NewService(nil, mockRepo)
+	fares := []*types.RideFare{{PackageSlug: "suv", TotalPriceInCents: 1850}}
+
+	t.Run("own user id", func(t *testing.T) {
+		ctx := withPrincipal("user-123", domain.RoleRider)
+		if _, err := svc.CreateTripFares(ctx, fares, "user-123", nil); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if _, err := svc.GetAndValidateFare(ctx, primitive.NewObjectID().Hex(), "user-123"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("another user id", func(t *testing.T) {
+		ctx := withPrincipal("user-456", domain.RoleRider)
+		if _, err := svc.CreateTripFares(ctx, fares, "user-123", nil); !errors.Is(err, domain.ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+		if _, err := svc.GetAndValidateFare(ctx, primitive.NewObjectID().Hex(), "user-123"); !errors.Is(err, domain.ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+		if _, err := svc.AddTip(ctx, primitive.NewObjectID().Hex(), "user-123", 100); !errors.Is(err, domain.ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+	})
+}
+
+func TestGetTripTimelineAuthorization(t *testing.T) {
+	mockRepo := &mockRepository{
+		getTripFunc: func(ctx context.Context, id string) (*types.Trip, error) {
+			return &types.Trip{UserID: "user-123", Driver: &trip.TripDriver{Id: "driver-1"}}, nil
+		},
+		getEventsFunc: func(ctx context.Context, tripID string) ([]*domain.TripEvent, error) {
+			return []*domain.TripEvent{}, nil
+		},
+	}
+	svc := NewService(nil, mockRepo)
+	tripID := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		allowed bool
+	}{
+		{name: "rider", ctx: withPrincipal("user-123", domain.RoleRider), allowed: true},
+		{name: "assigned driver", ctx: withPrincipal("driver-1", domain.RoleDriver), allowed: true},
+		{name: "admin", ctx: withPrincipal("ops-1", domain.RoleAdmin), allowed: true},
+		{name: "internal caller", ctx: context.Background(), allowed: true},
+		{name: "another rider", ctx: withPrincipal("user-456", domain.RoleRider), allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			_, err := svc.GetTripTimeline(tt.ctx, tripID)
+
+			// Verify
+			if tt.allowed && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if !tt.allowed && !errors.Is(err, domain.ErrForbidden) {
+				t.Errorf("expected ErrForbidden, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ride4Low/contracts/proto/driver"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+)
+
+func (s *service) createTripFromEvents(ctx context.Context, t *types.Trip) (*types.Trip, error) {
+	event := s.newEvent(ctx, domain.TripEventCreated, t.ID.Hex())
+	event.ToStatus = t.Status
+	event.After = t
+	event.Version = 1
+
+	created, err := domain.ApplyTripEvent(nil, event)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SaveTripEvent(ctx, event); err != nil {
+		return nil, fmt.Errorf("failed to append trip event: %w", err)
+	}
+
+	if err := s.repo.ReplaceTrip(ctx, created); err != nil {
+		return nil, fmt.Errorf("failed to project trip: %w", err)
+	}
+	s.metrics.TripEventRecorded(event)
+
+	return created, nil
+}
+
+func (s *service) updateTripFromEvents(ctx context.Context, tripID string, status string, driver *driver.Driver) error {
+	events, err := s.loadTripStream(ctx, tripID)
+	if err != nil {
+		return err
+	}
+
+	before, err := domain.FoldTripEvents(events)
+	if err != nil {
+		return err
+	}
+
+	if err := validateTransition(before, status, driver); err != nil {
+		return err
+	}
+
+	event := s.newEvent(ctx, domain.TripEventStatusChanged, tripID)
+	event.FromStatus = before.Status
+	event.ToStatus = status
+	event.Driver = toTripDriver(driver)
+	event.Version = domain.NextTripEventVersion(events)
+
+	after, err := domain.ApplyTripEvent(before, event)
+	if err != nil {
+		return err
+	}
+	event.Before = before
+	event.After = after
+
+	// A concurrent command appending the same version fails on the unique
+	// (trip_id, version) index, so the projection is never written twice.
+	if err := s.repo.SaveTripEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to append trip event: %w", err)
+	}
+
+	if err := s.repo.ReplaceTrip(ctx, after); err != nil {
+		return fmt.Errorf("failed to project trip: %w", err)
+	}
+	s.metrics.TripEventRecorded(event)
+
+	return nil
+}
+
+func (s *service) changeTripFromEvents(ctx context.Context, tripID string, newEvent func(before *types.Trip) (*domain.TripEvent, error)) (*types.Trip, error) {
+	events, err := s.loadTripStream(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := domain.FoldTripEvents(events)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := newEvent(before)
+	if err != nil {
+		return nil, err
+	}
+	event.Version = domain.NextTripEventVersion(events)
+
+	after, err := domain.ApplyTripEvent(before, event)
+	if err != nil {
+		return nil, err
+	}
+	event.Before = before
+	event.After = after
+
+	if err := s.repo.SaveTripEvent(ctx, event); err != nil {
+		return nil, fmt.Errorf("failed to append trip event: %w", err)
+	}
+
+	if err := s.repo.ReplaceTrip(ctx, after); err != nil {
+		return nil, fmt.Errorf("failed to project trip: %w", err)
+	}
+	s.metrics.TripEventRecorded(event)
+
+	return after, nil
+}
+
+// loadTripStream returns the event stream of a trip. Trips created before
+// event sourcing was enabled have no stream yet, so one is seeded from the
+// current projection.
+func (s *service) loadTripStream(ctx context.Context, tripID string) ([]*domain.TripEvent, error) {
+	events, err := s.repo.GetTripEvents(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		if event.Type == domain.TripEventCreated {
+			return events, nil
+		}
+	}
+
+	current, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The seed is dated when the trip was created, so it is read back ahead of
+	// the audit events already recorded for the trip
+	seed := s.newEvent(ctx, domain.TripEventCreated, tripID)
+	seed.ToStatus = current.Status
+	seed.After = current
+	seed.Version = domain.NextTripEventVersion(events)
+	seed.CreatedAt = current.ID.Timestamp()
+
+	if err := s.repo.SaveTripEvent(ctx, seed); err != nil {
+		return nil, fmt.Errorf("failed to seed trip event stream: %w", err)
+	}
+
+	return append([]*domain.TripEvent{seed}, events...), nil
+}
+
+// ProjectionRebuilder replays trip event streams into the trips collection
+type ProjectionRebuilder struct {
+	repo      domain.Repository
+	upcasters []domain.TripEventUpcaster
+}
+
+// RebuildReport summarises a projection rebuild
+type RebuildReport struct {
+	Rebuilt int
+	Failed  map[string]error
+}
+
+func NewProjectionRebuilder(repo domain.Repository, upcasters ...domain.TripEventUpcaster) *ProjectionRebuilder {
+	return &ProjectionRebuilder{
+		repo:      repo,
+		upcasters: upcasters,
+	}
+}
+
+// Rebuild replays the given trips, or every trip with an event stream when
+// tripIDs is empty. With dryRun the projections are rebuilt but not written.
+func (r *ProjectionRebuilder) Rebuild(ctx context.Context, tripIDs []string, dryRun bool) (*RebuildReport, error) {
+	if len(tripIDs) == 0 {
+		ids, err := r.repo.GetEventTripIDs(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list trip event streams: %w", err)
+		}
+		tripIDs = ids
+	}
+
+	report := &RebuildReport{Failed: map[string]error{}}
+	for _, tripID := range tripIDs {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		if err := r.rebuildTrip(ctx, tripID, dryRun); err != nil {
+			report.Failed[tripID] = err
+			continue
+		}
+		report.Rebuilt++
+	}
+
+	return report, nil
+}
+
+func (r *ProjectionRebuilder) rebuildTrip(ctx context.Context, tripID string, dryRun bool) error {
+	events, err := r.repo.GetTripEvents(ctx, tripID)
+	if err != nil {
+		return err
+	}
+
+	t, err := domain.FoldTripEvents(events, r.upcasters...)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	return r.repo.ReplaceTrip(ctx, t)
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ride4Low/contracts/proto/driver"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// eventStore is an in-memory event stream backing mockRepository in these tests
+type eventStore struct {
+	events      []*domain.TripEvent
+	projections map[string]*types.Trip
+}
+
+func newEventStoreRepository() (*eventStore, *mockRepository) {
+	store := &eventStore{projections: map[string]*types.Trip{}}
+	repo := &mockRepository{
+		saveEventFunc: func(ctx context.Context, event *domain.TripEvent) error {
+			for _, e := range store.events {
+				if e.TripID == event.TripID && e.Version == event.Version {
+					return errors.New("duplicate key")
+				}
+			}
+			if event.CreatedAt.IsZero() {
+				event.CreatedAt = time.Now()
+			}
+			store.events = append(store.events, event)
+			return nil
+		},
+		getEventsFunc: func(ctx context.Context, tripID string) ([]*domain.TripEvent, error) {
+			var events []*domain.TripEvent
+			for _, e := range store.events {
+				if e.TripID == tripID {
+					events = append(events, e)
+				}
+			}
+			// Read back in the order of the repositories
+			sort.SliceStable(events, func(i, j int) bool {
+				return events[i].CreatedAt.Before(events[j].CreatedAt)
+			})
+			return events, nil
+		},
+		getEventTripIDs: func(ctx context.Context) ([]string, error) {
+			var ids []string
+			for id := range store.projections {
+				ids = append(ids, id)
+			}
+			return ids, nil
+		},
+		replaceTripFunc: func(ctx context.Context, trip *types.Trip) error {
+			store.projections[trip.ID.Hex()] = trip
+			return nil
+		},
+		createTripFunc: func(ctx context.Context, trip *types.Trip) (*types.Trip, error) {
+			return nil, errors.New("trips must not be written directly")
+		},
+		updateTripFunc: func(ctx context.Context, tripID string, status string, driver *driver.Driver) error {
+			return errors.New("trips must not be written directly")
+		},
+	}
+	return store, repo
+}
+
+func testRoute(distance, duration float64) *types.OsrmApiResponse {
+	return &types.OsrmApiResponse{
+		Routes: []struct {
+			Distance float64 `json:"distance"`
+			Duration float64 `json:"duration"`
+			Geometry struct {
+				Coordinates [][]float64 `json:"coordinates"`
+			} `json:"geometry"`
+		}{
+			{Distance: distance, Duration: duration},
+		},
+	}
+}
+
+func TestEventSourcedTrip(t *testing.T) {
+	t.Run("commands append events and project the trip", func(t *testing.T) {
+		// Setup
+		store, repo := newEventStoreRepository()
+		svc := NewService(nil, repo, WithEventSourcing())
+
+		fare := &types.RideFare{UserID: "user-123", PackageSlug: "suv", TotalPriceInCents: 1850}
+
+		// Execute
+		created, err := svc.CreateTrip(context.Background(), fare)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		tripID := created.ID.Hex()
+
+		err = svc.UpdateTrip(context.Background(), tripID, "accepted", &driver.Driver{Id: "driver-1", Name: "Jane"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// Verify
+		if len(store.events) != 2 {
+			t.Fatalf("expected 2 events, got %d", len(store.events))
+		}
+		if store.events[0].Version != 1 || store.events[1].Version != 2 {
+			t.Errorf("expected versions 1 and 2, got %d and %d", store.events[0].Version, store.events[1].Version)
+		}
+
+		projected := store.projections[tripID]
+		if projected == nil {
+			t.Fatal("expected the trip to be projected")
+		}
+		if projected.Status != "accepted" {
+			t.Errorf("expected status accepted, got %s", projected.Status)
+		}
+		if projected.Driver.GetId() != "driver-1" {
+			t.Errorf("expected driver-1 to be assigned, got %v", projected.Driver)
+		}
+		if store.events[1].Before.Status != "pending" {
+			t.Errorf("expected before snapshot to stay pending, got %s", store.events[1].Before.Status)
+		}
+	})
+
+	t.Run("seeds a stream for trips created before event sourcing", func(t *testing.T) {
+		// Setup
+		store, repo := newEventStoreRepository()
+		tripID := primitive.NewObjectID()
+		repo.getTripFunc = func(ctx context.Context, id string) (*types.Trip, error) {
+			return &types.Trip{ID: tripID, UserID: "user-123", Status: "accepted"}, nil
+		}
+		svc := NewService(nil, repo, WithEventSourcing())
+
+		// Execute
+		err := svc.UpdateTrip(context.Background(), tripID.Hex(), "paid", nil)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(store.events) != 2 {
+			t.Fatalf("expected a seed and a status event, got %d events", len(store.events))
+		}
+		if store.events[0].Type != domain.TripEventCreated {
+			t.Errorf("expected the stream to be seeded with %s, got %s", domain.TripEventCreated, store.events[0].Type)
+		}
+		if store.projections[tripID.Hex()].Status != "paid" {
+			t.Errorf("expected status paid, got %s", store.projections[tripID.Hex()].Status)
+		}
+	})
+
+	t.Run("seeded stream loads again after earlier audit events", func(t *testing.T) {
+		// Setup
+		store, repo := newEventStoreRepository()
+		tripID := primitive.NewObjectIDFromTimestamp(time.Now().Add(-time.Hour))
+		repo.getTripFunc = func(ctx context.Context, id string) (*types.Trip, error) {
+			return &types.Trip{ID: tripID, UserID: "user-123", Status: "accepted"}, nil
+		}
+		store.events = append(store.events, &domain.TripEvent{
+			TripID:     tripID.Hex(),
+			Type:       domain.TripEventStatusChanged,
+			FromStatus: "pending",
+			ToStatus:   "accepted",
+			CreatedAt:  time.Now().Add(-time.Minute),
+		})
+		svc := NewService(nil, repo, WithEventSourcing())
+
+		// Execute
+		if err := svc.UpdateTrip(context.Background(), tripID.Hex(), "paid", nil); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		report, err := NewProjectionRebuilder(repo).Rebuild(context.Background(), []string{tripID.Hex()}, false)
+
+		// Verify
+		if err != nil || report.Rebuilt != 1 {
+			t.Fatalf("expected the stream to load again, got %+v, %v", report, err)
+		}
+		events, _ := repo.GetTripEvents(context.Background(), tripID.Hex())
+		if events[0].Type != domain.TripEventCreated {
+			t.Errorf("expected the seed to be read first, got %s", events[0].Type)
+		}
+		if store.projections[tripID.Hex()].Status != "paid" {
+			t.Errorf("expected status paid, got %s", store.projections[tripID.Hex()].Status)
+		}
+	})
+
+	t.Run("invalid transition appends no event", func(t *testing.T) {
+		// Setup
+		store, repo := newEventStoreRepository()
+		svc := NewService(nil, repo, WithEventSourcing())
+		created, err := svc.CreateTrip(context.Background(), &types.RideFare{UserID: "user-123", PackageSlug: "suv"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// Execute
+		err = svc.UpdateTrip(context.Background(), created.ID.Hex(), "paid", nil)
+
+		// Verify
+		if !errors.Is(err, domain.ErrInvalidTransition) {
+			t.Errorf("expected ErrInvalidTransition, got %v", err)
+		}
+		if len(store.events) != 1 {
+			t.Errorf("expected only the created event, got %d events", len(store.events))
+		}
+	})
+}
+
+func TestProjectionRebuilder(t *testing.T) {
+	t.Run("replays streams with the repricing upcaster", func(t *testing.T) {
+		// Setup
+		store, repo := newEventStoreRepository()
+		svc := NewService(nil, repo, WithEventSourcing())
+
+		fare := &types.RideFare{UserID: "user-123", PackageSlug: "suv", TotalPriceInCents: 1, Route: testRoute(1000, 600)}
+		created, err := svc.CreateTrip(context.Background(), fare)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		tripID := created.ID.Hex()
+
+		// Execute
+		rebuilder := NewProjectionRebuilder(repo, RepriceFareUpcaster(domain.DefaultPricingConfig()))
+		report, err := rebuilder.Rebuild(context.Background(), nil, false)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if report.Rebuilt != 1 || len(report.Failed) != 0 {
+			t.Errorf("expected 1 rebuilt trip, got %+v", report)
+		}
+		if price := store.projections[tripID].RideFare.TotalPriceInCents; price != 1850 {
+			t.Errorf("expected repriced fare 1850, got %f", price)
+		}
+		if store.events[0].After.RideFare.TotalPriceInCents != 1 {
+			t.Error("expected the stored event to be left untouched")
+		}
+	})
+
+	t.Run("dry run does not write projections", func(t *testing.T) {
+		// Setup
+		_, repo := newEventStoreRepository()
+		tripID := primitive.NewObjectID().Hex()
+		repo.getEventsFunc = func(ctx context.Context, id string) ([]*domain.TripEvent, error) {
+			return []*domain.TripEvent{
+				{TripID: tripID, Type: domain.TripEventCreated, After: &types.Trip{Status: "pending"}},
+				{TripID: tripID, Type: domain.TripEventStatusChanged, ToStatus: "paid"},
+			}, nil
+		}
+		repo.replaceTripFunc = func(ctx context.Context, trip *types.Trip) error {
+			t.Error("expected no projection to be written")
+			return nil
+		}
+
+		// Execute
+		report, err := NewProjectionRebuilder(repo).Rebuild(context.Background(), []string{tripID}, true)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if report.Rebuilt != 1 {
+			t.Errorf("expected 1 rebuilt trip, got %+v", report)
+		}
+	})
+
+	t.Run("streams without a created event fail", func(t *testing.T) {
+		// Setup
+		_, repo := newEventStoreRepository()
+		tripID := primitive.NewObjectID().Hex()
+		repo.getEventsFunc = func(ctx context.Context, id string) ([]*domain.TripEvent, error) {
+			return []*domain.TripEvent{{TripID: tripID, Type: domain.TripEventStatusChanged, ToStatus: "paid"}}, nil
+		}
+
+		// Execute
+		report, err := NewProjectionRebuilder(repo).Rebuild(context.Background(), []string{tripID}, false)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if report.Failed[tripID] == nil {
+			t.Error("expected the trip to be reported as failed")
+		}
+	})
+}
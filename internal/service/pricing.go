@@ -1,7 +1,7 @@
 package service
 
 import (
-	"log"
+	"math"
 
 	"github.com/ride4Low/contracts/types"
 	"github.com/ride4Low/trip-service/internal/domain"
@@ -12,7 +12,16 @@ func (s *service) EstimatePackagesPriceWithRoute(route *types.OsrmApiResponse) [
 	estimatedFares := make([]*types.RideFare, len(baseFares))
 
 	for i, f := range baseFares {
-		estimatedFares[i] = estimateFareRoute(f, route)
+		estimatedFares[i] = estimateFareRoute(f, route, s.pricing)
+		s.logger.Debug("Estimated fare", "package", f.PackageSlug, "total_in_cents", estimatedFares[i].TotalPriceInCents)
+	}
+
+	// Exact distances and durations can single out a trip, the log keeps
+	// them to 100 meters and a minute
+	if len(route.Routes) > 0 {
+		s.logger.Debug("Estimated route",
+			"distance_m", math.Round(route.Routes[0].Distance/100)*100,
+			"duration_s", math.Round(route.Routes[0].Duration/60)*60)
 	}
 
 	return estimatedFares
@@ -39,23 +48,78 @@ func getBaseFares() []*types.RideFare {
 	}
 }
 
-func estimateFareRoute(f *types.RideFare, route *types.OsrmApiResponse) *types.RideFare {
-	pricingCfg := domain.DefaultPricingConfig()
+func estimateFareRoute(f *types.RideFare, route *types.OsrmApiResponse, pricingCfg *domain.PricingConfig) *types.RideFare {
+	components := computeFareComponents(f, route, pricingCfg)
+
+	return &types.RideFare{
+		TotalPriceInCents: components.total(),
+		PackageSlug:       f.PackageSlug,
+	}
+}
+
+// fareComponents are the parts a ride fare is made of
+type fareComponents struct {
+	packagePrice float64
+	distanceFare float64
+	timeFare     float64
+}
+
+func (c fareComponents) total() float64 {
+	return c.packagePrice + c.distanceFare + c.timeFare
+}
+
+func computeFareComponents(f *types.RideFare, route *types.OsrmApiResponse, pricingCfg *domain.PricingConfig) fareComponents {
 	carPackagePrice := f.TotalPriceInCents
 
 	distanceM := route.Routes[0].Distance
 	durationInSeconds := route.Routes[0].Duration
 
-	log.Printf("Distance in meters: %f, Duration in Seconds: %f\n", distanceM, durationInSeconds)
-
 	distanceFare := distanceM * pricingCfg.PricePerUnitOfDistance
 	timeFare := durationInSeconds * pricingCfg.PricingPerMinute
-	log.Printf("CarPackagePrice: %f, DistanceFare: %f, TimeFare: %f\n", carPackagePrice, distanceFare, timeFare)
-	totalPrice := carPackagePrice + distanceFare + timeFare
-	log.Printf("Total: %f\n", totalPrice)
 
-	return &types.RideFare{
-		TotalPriceInCents: totalPrice,
-		PackageSlug:       f.PackageSlug,
+	return fareComponents{
+		packagePrice: carPackagePrice,
+		distanceFare: distanceFare,
+		timeFare:     timeFare,
+	}
+}
+
+func getBaseFare(packageSlug string) (*types.RideFare, bool) {
+	for _, f := range getBaseFares() {
+		if f.PackageSlug == packageSlug {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// RepriceFareUpcaster recomputes the fare stored on trip.created events with
+// the current pricing rules, so replaying the event stream corrects fares of
+// historical trips.
+func RepriceFareUpcaster(pricingCfg *domain.PricingConfig) domain.TripEventUpcaster {
+	return func(event *domain.TripEvent) *domain.TripEvent {
+		if event.Type != domain.TripEventCreated || event.After == nil {
+			return event
+		}
+
+		fare := event.After.RideFare
+		if fare == nil || fare.Route == nil || len(fare.Route.Routes) == 0 {
+			return event
+		}
+
+		base, ok := getBaseFare(fare.PackageSlug)
+		if !ok {
+			return event
+		}
+
+		repricedFare := *fare
+		repricedFare.TotalPriceInCents = estimateFareRoute(base, fare.Route, pricingCfg).TotalPriceInCents
+
+		repricedTrip := *event.After
+		repricedTrip.RideFare = &repricedFare
+
+		repriced := *event
+		repriced.After = &repricedTrip
+		return &repriced
 	}
 }
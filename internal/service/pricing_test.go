@@ -4,11 +4,13 @@ import (
 	"testing"
 
 	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"github.com/ride4Low/trip-service/internal/logging"
 )
 
 func TestEstimatePackagesPriceWithRoute(t *testing.T) {
 	// Setup
-	svc := &service{}
+	svc := &service{logger: logging.Discard(), pricing: domain.DefaultPricingConfig()}
 
 	// Mock route
 	// Distance: 1000 meters
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ride4Low/trip-service/internal/domain"
+)
+
+func (s *service) RateTrip(ctx context.Context, rating *domain.Rating) (*domain.Rating, error) {
+	cfg := s.ratings
+
+	if err := validateRating(cfg, rating); err != nil {
+		return nil, err
+	}
+
+	if err := authorizeUser(ctx, rating.RaterID); err != nil {
+		return nil, err
+	}
+
+	t, err := s.repo.GetTripByID(ctx, rating.TripID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trip: %w", err)
+	}
+
+	if t.Status != domain.TripStatusPaid {
+		return nil, fmt.Errorf("%w: trip is %s", domain.ErrTripNotCompleted, t.Status)
+	}
+
+	// The rider rates the driver and the driver rates the rider
+	switch rating.RaterRole {
+	case domain.RaterRider:
+		if rating.RaterID != t.UserID {
+			return nil, fmt.Errorf("%w: user did not take part in the trip", domain.ErrForbidden)
+		}
+		rating.RateeID = t.Driver.GetId()
+	case domain.RaterDriver:
+		if rating.RaterID != t.Driver.GetId() {
+			return nil, fmt.Errorf("%w: user did not take part in the trip", domain.ErrForbidden)
+		}
+		rating.RateeID = t.UserID
+	}
+
+	completedAt, err := s.tripCompletedAt(ctx, rating.TripID)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Since(completedAt) > cfg.Window {
+		return nil, domain.ErrRatingWindowClosed
+	}
+
+	if err := s.repo.SaveRating(ctx, rating); err != nil {
+		if errors.Is(err, domain.ErrAlreadyRated) {
+			return s.retriedRating(ctx, rating)
+		}
+		return nil, fmt.Errorf("failed to save rating: %w", err)
+	}
+
+	return rating, nil
+}
+
+// retriedRating returns the stored rating when the rater retries the same
+// rating, so the trip rated event a failed publish lost is sent again. Any
+// other rating is refused.
+func (s *service) retriedRating(ctx context.Context, rating *domain.Rating) (*domain.Rating, error) {
+	stored, err := s.repo.GetRating(ctx, rating.TripID, rating.RaterRole)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating: %w", err)
+	}
+
+	if stored == nil || stored.RaterID != rating.RaterID || stored.Stars != rating.Stars ||
+		!slices.Equal(stored.Tags, rating.Tags) || stored.Comment != rating.Comment {
+		return nil, domain.ErrAlreadyRated
+	}
+	return stored, nil
+}
+
+func validateRating(cfg *domain.RatingConfig, rating *domain.Rating) error {
+	if rating.RaterRole != domain.RaterRider && rating.RaterRole != domain.RaterDriver {
+		return fmt.Errorf("%w: unknown rater role %q", domain.ErrInvalidRating, rating.RaterRole)
+	}
+
+	if rating.RaterID == "" {
+		return fmt.Errorf("%w: rater id is required", domain.ErrInvalidRating)
+	}
+
+	if rating.Stars < cfg.MinStars || rating.Stars > cfg.MaxStars {
+		return fmt.Errorf("%w: stars must be between %d and %d", domain.ErrInvalidRating, cfg.MinStars, cfg.MaxStars)
+	}
+
+	if len(rating.Tags) > cfg.MaxTags {
+		return fmt.Errorf("%w: at most %d tags are allowed", domain.ErrInvalidRating, cfg.MaxTags)
+	}
+
+	for _, tag := range rating.Tags {
+		if strings.TrimSpace(tag) == "" {
+			return fmt.Errorf("%w: tags must not be empty", domain.ErrInvalidRating)
+		}
+	}
+
+	if utf8.RuneCountInString(rating.Comment) > cfg.MaxCommentLength {
+		return fmt.Errorf("%w: comment is longer than %d characters", domain.ErrInvalidRating, cfg.MaxCommentLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ride4Low/contracts/proto/trip"
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func completedTripRepository(completedAt time.Time) *mockRepository {
+	tripID := primitive.NewObjectID()
+	return &mockRepository{
+		getTripFunc: func(ctx context.Context, id string) (*types.Trip, error) {
+			return &types.Trip{
+				ID:       tripID,
+				UserID:   "rider-1",
+				Status:   domain.TripStatusPaid,
+				Driver:   &trip.TripDriver{Id: "driver-1"},
+				RideFare: &types.RideFare{TotalPriceInCents: 2000},
+			}, nil
+		},
+		getEventsFunc: func(ctx context.Context, id string) ([]*domain.TripEvent, error) {
+			return []*domain.TripEvent{
+				{Type: domain.TripEventCreated, ToStatus: domain.TripStatusPending, CreatedAt: completedAt.Add(-time.Hour)},
+				{Type: domain.TripEventStatusChanged, ToStatus: domain.TripStatusPaid, CreatedAt: completedAt},
+			}, nil
+		},
+	}
+}
+
+func TestRateTrip(t *testing.T) {
+	t.Run("rider rates the driver", func(t *testing.T) {
+		// Setup
+		var saved *domain.Rating
+		mockRepo := completedTripRepository(time.Now().Add(-time.Hour))
+		mockRepo.saveRatingFunc = func(ctx context.Context, rating *domain.Rating) error {
+			saved = rating
+			return nil
+		}
+		svc := NewService(nil, mockRepo)
+
+		// Execute
+		rating, err := svc.RateTrip(context.Background(), &domain.Rating{
+			TripID:    primitive.NewObjectID().Hex(),
+			RaterRole: domain.RaterRider,
+			RaterID:   "rider-1",
+			Stars:     5,
+			Tags:      []string{"friendly"},
+		})
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if saved != rating {
+			t.Error("expected the rating to be saved")
+		}
+		if rating.RateeID != "driver-1" {
+			t.Errorf("expected ratee driver-1, got %s", rating.RateeID)
+		}
+	})
+
+	t.Run("driver rates the rider", func(t *testing.T) {
+		// Setup
+		svc := NewService(nil, completedTripRepository(time.Now()))
+
+		// Execute
+		rating, err := svc.RateTrip(context.Background(), &domain.Rating{
+			TripID:    primitive.NewObjectID().Hex(),
+			RaterRole: domain.RaterDriver,
+			RaterID:   "driver-1",
+			Stars:     4,
+		})
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if rating.RateeID != "rider-1" {
+			t.Errorf("expected ratee rider-1, got %s", rating.RateeID)
+		}
+	})
+
+	t.Run("retried rating returns the stored rating", func(t *testing.T) {
+		// Setup
+		stored := &domain.Rating{ID: primitive.NewObjectID(), RaterRole: domain.RaterRider, RaterID: "rider-1", RateeID: "driver-1", Stars: 5, Tags: []string{"friendly"}}
+		mockRepo := completedTripRepository(time.Now().Add(-time.Hour))
+		mockRepo.saveRatingFunc = func(ctx context.Context, rating *domain.Rating) error {
+			return domain.ErrAlreadyRated
+		}
+		mockRepo.getRatingFunc = func(ctx context.Context, tripID, raterRole string) (*domain.Rating, error) {
+			return stored, nil
+		}
+		svc := NewService(nil, mockRepo)
+
+		// Execute
+		rating, err := svc.RateTrip(context.Background(), &domain.Rating{
+			TripID:    primitive.NewObjectID().Hex(),
+			RaterRole: domain.RaterRider,
+			RaterID:   "rider-1",
+			Stars:     5,
+			Tags:      []string{"friendly"},
+		})
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if rating != stored {
+			t.Errorf("expected the stored rating, got %+v", rating)
+		}
+	})
+
+	tests := []struct {
+		name        string
+		rating      *domain.Rating
+		completedAt time.Time
+		status      string
+		saveErr     error
+		stored      *domain.Rating
+		ratings     *domain.RatingConfig
+		expectedErr error
+	}{
+		{
+			name:        "stars out of range",
+			rating:      &domain.Rating{RaterRole: domain.RaterRider, RaterID: "rider-1", Stars: 6},
+			expectedErr: domain.ErrInvalidRating,
+		},
+		{
+			name:        "unknown role",
+			rating:      &domain.Rating{RaterRole: "admin", RaterID: "rider-1", Stars: 3},
+			expectedErr: domain.ErrInvalidRating,
+		},
+		{
+			name:        "comment too long",
+			rating:      &domain.Rating{RaterRole: domain.RaterRider, RaterID: "rider-1", Stars: 3, Comment: strings.Repeat("a", 501)},
+			expectedErr: domain.ErrInvalidRating,
+		},
+		{
+			name:        "rider of another trip",
+			rating:      &domain.Rating{RaterRole: domain.RaterRider, RaterID: "rider-2", Stars: 3},
+			expectedErr: domain.ErrForbidden,
+		},
+		{
+			name:        "trip not completed",
+			rating:      &domain.Rating{RaterRole: domain.RaterRider, RaterID: "rider-1", Stars: 3},
+			status:      domain.TripStatusAccepted,
+			expectedErr: domain.ErrTripNotCompleted,
+		},
+		{
+			name:        "rating window closed",
+			rating:      &domain.Rating{RaterRole: domain.RaterRider, RaterID: "rider-1", Stars: 3},
+			completedAt: time.Now().Add(-73 * time.Hour),
+			expectedErr: domain.ErrRatingWindowClosed,
+		},
+		{
+			name:        "configured rating window closed",
+			rating:      &domain.Rating{RaterRole: domain.RaterRider, RaterID: "rider-1", Stars: 3},
+			completedAt: time.Now().Add(-2 * time.Hour),
+			ratings:     &domain.RatingConfig{Window: time.Hour, MinStars: 1, MaxStars: 5, MaxTags: 5, MaxCommentLength: 500},
+			expectedErr: domain.ErrRatingWindowClosed,
+		},
+		{
+			name:        "already rated",
+			rating:      &domain.Rating{RaterRole: domain.RaterRider, RaterID: "rider-1", Stars: 3},
+			saveErr:     domain.ErrAlreadyRated,
+			expectedErr: domain.ErrAlreadyRated,
+		},
+		{
+			name:        "already rated with other stars",
+			rating:      &domain.Rating{RaterRole: domain.RaterRider, RaterID: "rider-1", Stars: 3},
+			saveErr:     domain.ErrAlreadyRated,
+			stored:      &domain.Rating{RaterRole: domain.RaterRider, RaterID: "rider-1", Stars: 5},
+			expectedErr: domain.ErrAlreadyRated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			completedAt := tt.completedAt
+			if completedAt.IsZero() {
+				completedAt = time.Now()
+			}
+			mockRepo := completedTripRepository(completedAt)
+			if tt.status != "" {
+				getTrip := mockRepo.getTripFunc
+				mockRepo.getTripFunc = func(ctx context.Context, id string) (*types.Trip, error) {
+					t, err := getTrip(ctx, id)
+					t.Status = tt.status
+					return t, err
+				}
+			}
+			mockRepo.saveRatingFunc = func(ctx context.Context, rating *domain.Rating) error {
+				return tt.saveErr
+			}
+			mockRepo.getRatingFunc = func(ctx context.Context, tripID, raterRole string) (*domain.Rating, error) {
+				return tt.stored, nil
+			}
+			var opts []Option
+			if tt.ratings != nil {
+				opts = append(opts, WithRatings(tt.ratings))
+			}
+			svc := NewService(nil, mockRepo, opts...)
+
+			tt.rating.TripID = primitive.NewObjectID().Hex()
+
+			// Execute
+			_, err := svc.RateTrip(context.Background(), tt.rating)
+
+			// Verify
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+)
+
+func (s *service) GetReceipt(ctx context.Context, tripID string) (*domain.Receipt, error) {
+	t, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trip: %w", err)
+	}
+
+	if err := authorizeReceiptAccess(ctx, t); err != nil {
+		return nil, err
+	}
+
+	if t.Status != domain.TripStatusPaid {
+		return nil, fmt.Errorf("%w: trip is %s", domain.ErrTripNotCompleted, t.Status)
+	}
+
+	completedAt, err := s.tripCompletedAt(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	tip, err := s.repo.GetTip(ctx, tripID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tip: %w", err)
+	}
+
+	return buildReceipt(s.receipts, s.pricing, t, tip, completedAt), nil
+}
+
+func buildReceipt(cfg *domain.ReceiptConfig, pricingCfg *domain.PricingConfig, t *types.Trip, tip *domain.Tip, completedAt time.Time) *domain.Receipt {
+	receipt := &domain.Receipt{
+		TripID:      t.ID.Hex(),
+		RiderID:     t.UserID,
+		Currency:    cfg.Currency,
+		CompletedAt: completedAt,
+		IssuedAt:    time.Now(),
+	}
+
+	if t.Driver.GetId() != "" {
+		receipt.Driver = &domain.ReceiptDriver{
+			Name:     t.Driver.GetName(),
+			CarPlate: t.Driver.GetCarPlate(),
+		}
+	}
+
+	if fare := t.RideFare; fare != nil {
+		receipt.PackageSlug = fare.PackageSlug
+		receipt.FareInCents = fare.TotalPriceInCents
+		receipt.Fare = fareLineItems(fare, pricingCfg)
+
+		if fare.Route != nil && len(fare.Route.Routes) > 0 {
+			receipt.Route = receiptRoute(fare.Route)
+		}
+	}
+
+	// Taxes are included in the fare, the receipt only shows their share
+	receipt.TaxInCents = receipt.FareInCents * cfg.TaxRatePercent / (100 + cfg.TaxRatePercent)
+
+	if tip != nil {
+		receipt.TipInCents = tip.AmountInCents
+	}
+
+	receipt.TotalInCents = receipt.FareInCents - receipt.DiscountInCents + receipt.TipInCents
+
+	return receipt
+}
+
+// fareLineItems itemizes a fare into its package, distance and time parts.
+// Fares that no longer match the current pricing rules are shown as a single
+// line, so the receipt always adds up to what the rider was charged.
+func fareLineItems(fare *types.RideFare, pricingCfg *domain.PricingConfig) []domain.ReceiptLineItem {
+	base, ok := getBaseFare(fare.PackageSlug)
+	if ok && fare.Route != nil && len(fare.Route.Routes) > 0 {
+		components := computeFareComponents(base, fare.Route, pricingCfg)
+		if math.Abs(components.total()-fare.TotalPriceInCents) < 0.5 {
+			return []domain.ReceiptLineItem{
+				{Label: "Base fare", AmountInCents: components.packagePrice},
+				{Label: "Distance", AmountInCents: components.distanceFare},
+				{Label: "Time", AmountInCents: components.timeFare},
+			}
+		}
+	}
+
+	return []domain.ReceiptLineItem{
+		{Label: "Ride fare", AmountInCents: fare.TotalPriceInCents},
+	}
+}
+
+func receiptRoute(route *types.OsrmApiResponse) domain.ReceiptRoute {
+	r := route.Routes[0]
+	summary := domain.ReceiptRoute{
+		DistanceMeters:  r.Distance,
+		DurationSeconds: r.Duration,
+	}
+
+	// OSRM geometries are [longitude, latitude] pairs
+	coords := r.Geometry.Coordinates
+	if len(coords) > 0 && len(coords[0]) == 2 && len(coords[len(coords)-1]) == 2 {
+		first, last := coords[0], coords[len(coords)-1]
+		summary.Pickup = &domain.Coordinate{Latitude: first[1], Longitude: first[0]}
+		summary.Dropoff = &domain.Coordinate{Latitude: last[1], Longitude: last[0]}
+	}
+
+	return summary
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ride4Low/contracts/types"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGetReceipt(t *testing.T) {
+	t.Run("itemizes the fare and adds the tip", func(t *testing.T) {
+		// Setup
+		completedAt := time.Now().Add(-time.Hour)
+		mockRepo := completedTripRepository(completedAt)
+		getTrip := mockRepo.getTripFunc
+		mockRepo.getTripFunc = func(ctx context.Context, id string) (*types.Trip, error) {
+			t, err := getTrip(ctx, id)
+			t.RideFare = &types.RideFare{PackageSlug: "suv", TotalPriceInCents: 1850, Route: testRoute(1000, 600)}
+			return t, err
+		}
+		mockRepo.getTipFunc = func(ctx context.Context, tripID string) (*domain.Tip, error) {
+			return &domain.Tip{AmountInCents: 300}, nil
+		}
+		svc := NewService(nil, mockRepo)
+
+		// Execute
+		receipt, err := svc.GetReceipt(context.Background(), primitive.NewObjectID().Hex())
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(receipt.Fare) != 3 {
+			t.Fatalf("expected 3 fare lines, got %+v", receipt.Fare)
+		}
+		if receipt.Fare[1].AmountInCents != 1500 {
+			t.Errorf("expected distance line of 1500, got %f", receipt.Fare[1].AmountInCents)
+		}
+		if receipt.TipInCents != 300 || receipt.TotalInCents != 2150 {
+			t.Errorf("expected tip 300 and total 2150, got %f and %f", receipt.TipInCents, receipt.TotalInCents)
+		}
+		if !receipt.CompletedAt.Equal(completedAt) {
+			t.Errorf("expected completion time %v, got %v", completedAt, receipt.CompletedAt)
+		}
+	})
+
+	t.Run("fares not matching current pricing are a single line", func(t *testing.T) {
+		// Setup
+		mockRepo := completedTripRepository(time.Now())
+		svc := NewService(nil, mockRepo)
+
+		// Execute
+		receipt, err := svc.GetReceipt(context.Background(), primitive.NewObjectID().Hex())
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(receipt.Fare) != 1 || receipt.Fare[0].AmountInCents != 2000 {
+			t.Errorf("expected a single fare line of 2000, got %+v", receipt.Fare)
+		}
+	})
+
+	t.Run("trip not completed", func(t *testing.T) {
+		// Setup
+		mockRepo := &mockRepository{
+			getTripFunc: func(ctx context.Context, id string) (*types.Trip, error) {
+				return &types.Trip{Status: domain.TripStatusAccepted}, nil
+			},
+		}
+		svc := NewService(nil, mockRepo)
+
+		// Execute
+		_, err := svc.GetReceipt(context.Background(), primitive.NewObjectID().Hex())
+
+		// Verify
+		if !errors.Is(err, domain.ErrTripNotCompleted) {
+			t.Errorf("expected ErrTripNotCompleted, got %v", err)
+		}
+	})
+
+	t.Run("admins and the rider read the receipt", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			ctx     context.Context
+			allowed bool
+		}{
+			{name: "rider", ctx: withPrincipal("rider-1", domain.RoleRider), allowed: true},
+			{name: "admin", ctx: withPrincipal("ops-1", domain.RoleAdmin), allowed: true},
+			{name: "another rider", ctx: withPrincipal("rider-2", domain.RoleRider), allowed: false},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Setup
+				svc := NewService(nil, completedTripRepository(time.Now()))
+
+				// Execute
+				_, err := svc.GetReceipt(tt.ctx, primitive.NewObjectID().Hex())
+
+				// Verify
+				if tt.allowed && err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				if !tt.allowed && !errors.Is(err, domain.ErrForbidden) {
+					t.Errorf("expected ErrForbidden, got %v", err)
+				}
+			})
+		}
+	})
+}
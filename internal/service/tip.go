@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ride4Low/trip-service/internal/domain"
+)
+
+func (s *service) AddTip(ctx context.Context, tripID, userID string, amountInCents float64) (*domain.Tip, error) {
+	cfg := s.tips
+
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if amountInCents <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", domain.ErrInvalidTip)
+	}
+
+	t, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trip: %w", err)
+	}
+
+	if t.UserID != userID {
+		return nil, fmt.Errorf("%w: only the rider can tip the trip", domain.ErrForbidden)
+	}
+
+	if t.Status != domain.TripStatusPaid {
+		return nil, fmt.Errorf("%w: trip is %s", domain.ErrTripNotCompleted, t.Status)
+	}
+
+	var fareInCents float64
+	if t.RideFare != nil {
+		fareInCents = t.RideFare.TotalPriceInCents
+	}
+
+	if maxTip := cfg.MaxTipInCents(fareInCents); amountInCents > maxTip {
+		return nil, fmt.Errorf("%w: tip cannot exceed %.0f cents", domain.ErrInvalidTip, maxTip)
+	}
+
+	completedAt, err := s.tripCompletedAt(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Since(completedAt) > cfg.Window {
+		return nil, domain.ErrTipWindowClosed
+	}
+
+	tip := &domain.Tip{
+		AmountInCents: amountInCents,
+		RiderID:       userID,
+		DriverID:      t.Driver.GetId(),
+	}
+
+	if err := s.repo.SaveTip(ctx, tripID, tip); err != nil {
+		if errors.Is(err, domain.ErrTipAlreadyAdded) {
+			return s.retriedTip(ctx, tripID, tip)
+		}
+		return nil, fmt.Errorf("failed to save tip: %w", err)
+	}
+
+	return tip, nil
+}
+
+// retriedTip returns the stored tip when the rider retries the same tip, so
+// the tip added event a failed publish lost is sent again. Any other tip is
+// refused.
+func (s *service) retriedTip(ctx context.Context, tripID string, tip *domain.Tip) (*domain.Tip, error) {
+	stored, err := s.repo.GetTip(ctx, tripID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tip: %w", err)
+	}
+
+	if stored == nil || stored.RiderID != tip.RiderID || stored.AmountInCents != tip.AmountInCents {
+		return nil, domain.ErrTipAlreadyAdded
+	}
+	return stored, nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAddTip(t *testing.T) {
+	t.Run("rider tips a completed trip", func(t *testing.T) {
+		// Setup
+		var savedTripID string
+		mockRepo := completedTripRepository(time.Now().Add(-time.Hour))
+		mockRepo.saveTipFunc = func(ctx context.Context, tripID string, tip *domain.Tip) error {
+			savedTripID = tripID
+			return nil
+		}
+		svc := NewService(nil, mockRepo)
+		tripID := primitive.NewObjectID().Hex()
+
+		// Execute
+		tip, err := svc.AddTip(context.Background(), tripID, "rider-1", 300)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if savedTripID != tripID {
+			t.Errorf("expected the tip to be saved on trip %s, got %s", tripID, savedTripID)
+		}
+		if tip.AmountInCents != 300 {
+			t.Errorf("expected tip of 300 cents, got %f", tip.AmountInCents)
+		}
+		if tip.DriverID != "driver-1" {
+			t.Errorf("expected tip for driver-1, got %s", tip.DriverID)
+		}
+	})
+
+	t.Run("retried tip returns the stored tip", func(t *testing.T) {
+		// Setup
+		stored := &domain.Tip{AmountInCents: 300, RiderID: "rider-1", DriverID: "driver-1", CreatedAt: time.Now().Add(-time.Minute)}
+		mockRepo := completedTripRepository(time.Now().Add(-time.Hour))
+		mockRepo.saveTipFunc = func(ctx context.Context, tripID string, tip *domain.Tip) error {
+			return domain.ErrTipAlreadyAdded
+		}
+		mockRepo.getTipFunc = func(ctx context.Context, tripID string) (*domain.Tip, error) {
+			return stored, nil
+		}
+		svc := NewService(nil, mockRepo)
+
+		// Execute
+		tip, err := svc.AddTip(context.Background(), primitive.NewObjectID().Hex(), "rider-1", 300)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if tip != stored {
+			t.Errorf("expected the stored tip, got %+v", tip)
+		}
+	})
+
+	tests := []struct {
+		name        string
+		userID      string
+		amount      float64
+		completedAt time.Time
+		saveErr     error
+		storedTip   *domain.Tip
+		tips        *domain.TipConfig
+		expectedErr error
+	}{
+		{
+			name:        "non positive amount",
+			userID:      "rider-1",
+			amount:      0,
+			expectedErr: domain.ErrInvalidTip,
+		},
+		{
+			name:        "above fare percentage",
+			userID:      "rider-1",
+			amount:      1001,
+			expectedErr: domain.ErrInvalidTip,
+		},
+		{
+			name:        "not the rider",
+			userID:      "driver-1",
+			amount:      100,
+			expectedErr: domain.ErrForbidden,
+		},
+		{
+			name:        "tip window closed",
+			userID:      "rider-1",
+			amount:      100,
+			completedAt: time.Now().Add(-49 * time.Hour),
+			expectedErr: domain.ErrTipWindowClosed,
+		},
+		{
+			name:        "above configured maximum",
+			userID:      "rider-1",
+			amount:      300,
+			tips:        &domain.TipConfig{Window: 48 * time.Hour, MaxFarePercent: 50, MaxAmountInCents: 200},
+			expectedErr: domain.ErrInvalidTip,
+		},
+		{
+			name:        "configured tip window closed",
+			userID:      "rider-1",
+			amount:      100,
+			completedAt: time.Now().Add(-2 * time.Hour),
+			tips:        &domain.TipConfig{Window: time.Hour, MaxFarePercent: 50, MaxAmountInCents: 10000},
+			expectedErr: domain.ErrTipWindowClosed,
+		},
+		{
+			name:        "already tipped",
+			userID:      "rider-1",
+			amount:      100,
+			saveErr:     domain.ErrTipAlreadyAdded,
+			expectedErr: domain.ErrTipAlreadyAdded,
+		},
+		{
+			name:        "already tipped another amount",
+			userID:      "rider-1",
+			amount:      100,
+			saveErr:     domain.ErrTipAlreadyAdded,
+			storedTip:   &domain.Tip{AmountInCents: 300, RiderID: "rider-1"},
+			expectedErr: domain.ErrTipAlreadyAdded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			completedAt := tt.completedAt
+			if completedAt.IsZero() {
+				completedAt = time.Now()
+			}
+			mockRepo := completedTripRepository(completedAt)
+			mockRepo.saveTipFunc = func(ctx context.Context, tripID string, tip *domain.Tip) error {
+				return tt.saveErr
+			}
+			mockRepo.getTipFunc = func(ctx context.Context, tripID string) (*domain.Tip, error) {
+				return tt.storedTip, nil
+			}
+			var opts []Option
+			if tt.tips != nil {
+				opts = append(opts, WithTips(tt.tips))
+			}
+			svc := NewService(nil, mockRepo, opts...)
+
+			// Execute
+			_, err := svc.AddTip(context.Background(), primitive.NewObjectID().Hex(), tt.userID, tt.amount)
+
+			// Verify
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/ride4Low/contracts/proto/driver"
 	"github.com/ride4Low/contracts/proto/trip"
@@ -15,68 +17,201 @@ import (
 type service struct {
 	routeProvider domain.RouteProvider
 	repo          domain.Repository
+	eventSourced  bool
+	metrics       domain.Metrics
+	logger        *slog.Logger
+	pricing       *domain.PricingConfig
+	quotes        *domain.QuoteConfig
+	receipts      *domain.ReceiptConfig
+	tips          *domain.TipConfig
+	ratings       *domain.RatingConfig
 }
 
-func NewService(routeProvider domain.RouteProvider, repo domain.Repository) domain.Service {
-	return &service{
+// Option configures optional service behaviour
+type Option func(*service)
+
+// WithEventSourcing makes trip_events the source of truth: commands append
+// events and the trips collection becomes a projection rebuilt from them.
+func WithEventSourcing() Option {
+	return func(s *service) {
+		s.eventSourced = true
+	}
+}
+
+// WithMetrics records quotes and trip events in m
+func WithMetrics(m domain.Metrics) Option {
+	return func(s *service) {
+		s.metrics = m
+	}
+}
+
+// WithPricing sets the pricing rules, domain.DefaultPricingConfig()
+// otherwise
+func WithPricing(cfg *domain.PricingConfig) Option {
+	return func(s *service) {
+		s.pricing = cfg
+	}
+}
+
+// WithQuotes sets how previews are reused, domain.DefaultQuoteConfig()
+// otherwise
+func WithQuotes(cfg *domain.QuoteConfig) Option {
+	return func(s *service) {
+		s.quotes = cfg
+	}
+}
+
+// WithReceipts sets the currency and tax of receipts,
+// domain.DefaultReceiptConfig() otherwise
+func WithReceipts(cfg *domain.ReceiptConfig) Option {
+	return func(s *service) {
+		s.receipts = cfg
+	}
+}
+
+// WithTips sets the tip limits and window, domain.DefaultTipConfig()
+// otherwise
+func WithTips(cfg *domain.TipConfig) Option {
+	return func(s *service) {
+		s.tips = cfg
+	}
+}
+
+// WithRatings sets the rating rules and window,
+// domain.DefaultRatingConfig() otherwise
+func WithRatings(cfg *domain.RatingConfig) Option {
+	return func(s *service) {
+		s.ratings = cfg
+	}
+}
+
+// WithLogger sets the logger, slog.Default() otherwise
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *service) {
+		s.logger = logger
+	}
+}
+
+func NewService(routeProvider domain.RouteProvider, repo domain.Repository, opts ...Option) domain.Service {
+	s := &service{
 		routeProvider: routeProvider,
 		repo:          repo,
+		metrics:       nopMetrics{},
+		logger:        slog.Default(),
+		pricing:       domain.DefaultPricingConfig(),
+		quotes:        domain.DefaultQuoteConfig(),
+		receipts:      domain.DefaultReceiptConfig(),
+		tips:          domain.DefaultTipConfig(),
+		ratings:       domain.DefaultRatingConfig(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *service) CreateTrip(ctx context.Context, fare *types.RideFare) (*types.Trip, error) {
 	t := &types.Trip{
 		ID:       primitive.NewObjectID(),
 		UserID:   fare.UserID,
-		Status:   "pending",
+		Status:   domain.TripStatusPending,
 		RideFare: fare,
 		Driver:   &trip.TripDriver{},
 	}
 
-	return s.repo.CreateTrip(ctx, t)
+	if s.eventSourced {
+		return s.createTripFromEvents(ctx, t)
+	}
+
+	created, err := s.repo.CreateTrip(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	event := s.newEvent(ctx, domain.TripEventCreated, created.ID.Hex())
+	event.ToStatus = created.Status
+	event.After = created
+	s.recordEvent(ctx, event)
+
+	return created, nil
 }
 
 func (s *service) GetRoute(ctx context.Context, pickup, dropoff types.Coordinate) (*types.OsrmApiResponse, error) {
-	return s.routeProvider.GetRoute(ctx, pickup, dropoff)
+	route, err := s.routeProvider.GetRoute(ctx, pickup, dropoff)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrUpstreamUnavailable, err)
+	}
+
+	return route, nil
 }
 
 func (s *service) CreateTripFares(ctx context.Context, rideFares []*types.RideFare, userID string, route *types.OsrmApiResponse) ([]*types.RideFare, error) {
-	fares := make([]*types.RideFare, len(rideFares))
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
 
-	for i, f := range rideFares {
-		id := primitive.NewObjectID()
+	cfg := s.quotes
+	routeKey := domain.QuoteRouteKey(route, cfg.RoutePrecision)
 
-		fare := &types.RideFare{
-			UserID:            userID,
-			ID:                id,
-			TotalPriceInCents: f.TotalPriceInCents,
-			PackageSlug:       f.PackageSlug,
-			Route:             route,
+	// Repeated previews of the same route reuse the quote instead of writing
+	// a new set of fares
+	if routeKey != "" {
+		quote, err := s.repo.FindRecentQuote(ctx, userID, routeKey, time.Now().Add(-cfg.ReuseWindow))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find a recent quote: %w", err)
+		}
+		if quote != nil && len(quote.Fares) == len(rideFares) {
+			fares := quote.RideFares()
+			s.metrics.QuoteCreated(fares, true)
+			return fares, nil
 		}
+	}
 
-		if err := s.repo.SaveRideFare(ctx, fare); err != nil {
-			return nil, fmt.Errorf("failed to save trip fare: %w", err)
+	// The fares are stored on the quote, next to the route they share, so a
+	// preview is a single write
+	quote := &domain.Quote{
+		ID:       primitive.NewObjectID(),
+		UserID:   userID,
+		RouteKey: routeKey,
+		Route:    route,
+		Fares:    make([]domain.QuoteFare, len(rideFares)),
+	}
+	for i, f := range rideFares {
+		quote.Fares[i] = domain.QuoteFare{
+			FareID:            primitive.NewObjectID(),
+			PackageSlug:       f.PackageSlug,
+			TotalPriceInCents: f.TotalPriceInCents,
 		}
+	}
 
-		fares[i] = fare
+	if err := s.repo.SaveQuote(ctx, quote); err != nil {
+		return nil, fmt.Errorf("failed to save trip fares: %w", err)
 	}
+	fares := quote.RideFares()
+	s.metrics.QuoteCreated(fares, false)
 
 	return fares, nil
 }
 
 func (s *service) GetAndValidateFare(ctx context.Context, fareID, userID string) (*types.RideFare, error) {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
 	fare, err := s.repo.GetRideFareByID(ctx, fareID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get trip fare: %w", err)
 	}
 
-	if fare == nil {
-		return nil, fmt.Errorf("fare does not exist")
-	}
-
 	// User fare validation (user is owner of this fare?)
 	if userID != fare.UserID {
-		return nil, fmt.Errorf("fare does not belong to the user")
+		return nil, fmt.Errorf("%w: fare does not belong to the user", domain.ErrForbidden)
+	}
+
+	// Mongo purges expired fares in the background, so they can still be read
+	// for a while after the TTL has passed
+	if !fare.CreatedAt.IsZero() && time.Since(fare.CreatedAt) > domain.RideFareTTL {
+		return nil, domain.ErrFareExpired
 	}
 
 	return fare, nil
@@ -87,5 +222,125 @@ func (s *service) GetTripByID(ctx context.Context, id string) (*types.Trip, erro
 }
 
 func (s *service) UpdateTrip(ctx context.Context, tripID string, status string, driver *driver.Driver) error {
-	return s.repo.UpdateTrip(ctx, tripID, status, driver)
+	if s.eventSourced {
+		return s.updateTripFromEvents(ctx, tripID, status, driver)
+	}
+
+	before, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		return err
+	}
+
+	if err := validateTransition(before, status, driver); err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateTrip(ctx, tripID, status, driver); err != nil {
+		return err
+	}
+
+	after, err := s.repo.GetTripByID(ctx, tripID)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to load the trip after update", "trip_id", tripID, "error", err)
+		snapshot := *before
+		snapshot.Status = status
+		after = &snapshot
+	}
+
+	event := s.newEvent(ctx, domain.TripEventStatusChanged, tripID)
+	event.FromStatus = before.Status
+	event.ToStatus = status
+	event.Driver = toTripDriver(driver)
+	event.Before = before
+	event.After = after
+	s.recordEvent(ctx, event)
+
+	return nil
+}
+
+func (s *service) GetTripTimeline(ctx context.Context, tripID string) ([]*domain.TripEvent, error) {
+	if principal, ok := domain.PrincipalFromContext(ctx); ok && !principal.HasRole(domain.RoleAdmin) {
+		t, err := s.repo.GetTripByID(ctx, tripID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get trip: %w", err)
+		}
+		if err := authorizeTripAccess(ctx, t); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.GetTripEvents(ctx, tripID)
+}
+
+// tripCompletedAt returns when the trip reached its final status, as recorded
+// in the trip event history.
+func (s *service) tripCompletedAt(ctx context.Context, tripID string) (time.Time, error) {
+	events, err := s.repo.GetTripEvents(ctx, tripID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get trip events: %w", err)
+	}
+
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].ToStatus == domain.TripStatusPaid {
+			return events[i].CreatedAt, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: completion time is unknown", domain.ErrTripNotCompleted)
+}
+
+// validateTransition refuses the status changes the trip lifecycle does not
+// allow. Repeating the change the trip already went through, as a redelivered
+// message does, is reported as ErrTripNotModified instead.
+func validateTransition(before *types.Trip, status string, driver *driver.Driver) error {
+	if before.Status == status && (driver == nil || driver.GetId() == before.Driver.GetId()) {
+		return fmt.Errorf("%w: %s", domain.ErrTripNotModified, before.ID.Hex())
+	}
+
+	if !domain.CanTransition(before.Status, status) {
+		return fmt.Errorf("%w: %s to %s", domain.ErrInvalidTransition, before.Status, status)
+	}
+	return nil
+}
+
+// newEvent builds a trip event carrying the actor and source of the request
+func (s *service) newEvent(ctx context.Context, eventType, tripID string) *domain.TripEvent {
+	meta := domain.EventMetaFromContext(ctx)
+
+	return &domain.TripEvent{
+		TripID: tripID,
+		Type:   eventType,
+		Actor:  meta.Actor,
+		Source: meta.Source,
+	}
+}
+
+// recordEvent appends a trip event to the audit trail. The trip itself has
+// already been written at this point, so a failure is logged rather than
+// reported back to the caller.
+func (s *service) recordEvent(ctx context.Context, event *domain.TripEvent) {
+	if err := s.repo.SaveTripEvent(ctx, event); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to record the trip event", "trip_id", event.TripID, "event_type", event.Type, "error", err)
+		return
+	}
+	s.metrics.TripEventRecorded(event)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) QuoteCreated([]*types.RideFare, bool) {}
+
+func (nopMetrics) TripEventRecorded(*domain.TripEvent) {}
+
+func toTripDriver(d *driver.Driver) *trip.TripDriver {
+	if d == nil {
+		return nil
+	}
+
+	return &trip.TripDriver{
+		Id:             d.Id,
+		Name:           d.Name,
+		ProfilePicture: d.ProfilePicture,
+		CarPlate:       d.CarPlate,
+	}
 }
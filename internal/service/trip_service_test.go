@@ -7,10 +7,14 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ride4Low/contracts/proto/driver"
+	"github.com/ride4Low/contracts/proto/trip"
 	"github.com/ride4Low/contracts/types"
 	"github.com/ride4Low/trip-service/internal/adapter/osrm"
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // mockRepository is a mock implementation of types.Repository for testing
@@ -21,6 +25,21 @@ type mockRepository struct {
 	createTripFunc   func(ctx context.Context, fare *types.Trip) (*types.Trip, error)
 	getTripFunc      func(ctx context.Context, id string) (*types.Trip, error)
 	updateTripFunc   func(ctx context.Context, tripID string, status string, driver *driver.Driver) error
+	saveEventFunc    func(ctx context.Context, event *domain.TripEvent) error
+	getEventsFunc    func(ctx context.Context, tripID string) ([]*domain.TripEvent, error)
+	getEventTripIDs  func(ctx context.Context) ([]string, error)
+	replaceTripFunc  func(ctx context.Context, trip *types.Trip) error
+	saveRatingFunc   func(ctx context.Context, rating *domain.Rating) error
+	getRatingFunc    func(ctx context.Context, tripID, raterRole string) (*domain.Rating, error)
+	saveTipFunc      func(ctx context.Context, tripID string, tip *domain.Tip) error
+	getTipFunc       func(ctx context.Context, tripID string) (*domain.Tip, error)
+	saveQuoteFunc    func(ctx context.Context, quote *domain.Quote) error
+	findQuoteFunc    func(ctx context.Context, userID, routeKey string, since time.Time) (*domain.Quote, error)
+	getUserDataFunc  func(ctx context.Context, userID string) (*domain.UserData, error)
+	eraseUserFunc    func(ctx context.Context, userID, pseudonym string) (*domain.ErasureReport, error)
+	setDriverFunc    func(ctx context.Context, tripID string, driver *trip.TripDriver) error
+	searchTripsFunc  func(ctx context.Context, filter domain.TripFilter) ([]*types.Trip, error)
+	expireQuotesFunc func(ctx context.Context, filter domain.QuoteFilter, createdAt time.Time) (int, error)
 }
 
 func (m *mockRepository) SaveTrip(ctx context.Context) error {
@@ -65,6 +84,111 @@ func (m *mockRepository) UpdateTrip(ctx context.Context, tripID string, status s
 	return errors.New("not implemented")
 }
 
+func (m *mockRepository) SaveTripEvent(ctx context.Context, event *domain.TripEvent) error {
+	if m.saveEventFunc != nil {
+		return m.saveEventFunc(ctx, event)
+	}
+	return nil
+}
+
+func (m *mockRepository) GetTripEvents(ctx context.Context, tripID string) ([]*domain.TripEvent, error) {
+	if m.getEventsFunc != nil {
+		return m.getEventsFunc(ctx, tripID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRepository) GetEventTripIDs(ctx context.Context) ([]string, error) {
+	if m.getEventTripIDs != nil {
+		return m.getEventTripIDs(ctx)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRepository) ReplaceTrip(ctx context.Context, trip *types.Trip) error {
+	if m.replaceTripFunc != nil {
+		return m.replaceTripFunc(ctx, trip)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *mockRepository) SaveRating(ctx context.Context, rating *domain.Rating) error {
+	if m.saveRatingFunc != nil {
+		return m.saveRatingFunc(ctx, rating)
+	}
+	return nil
+}
+
+func (m *mockRepository) GetRating(ctx context.Context, tripID, raterRole string) (*domain.Rating, error) {
+	if m.getRatingFunc != nil {
+		return m.getRatingFunc(ctx, tripID, raterRole)
+	}
+	return nil, nil
+}
+
+func (m *mockRepository) SaveTip(ctx context.Context, tripID string, tip *domain.Tip) error {
+	if m.saveTipFunc != nil {
+		return m.saveTipFunc(ctx, tripID, tip)
+	}
+	return nil
+}
+
+func (m *mockRepository) GetTip(ctx context.Context, tripID string) (*domain.Tip, error) {
+	if m.getTipFunc != nil {
+		return m.getTipFunc(ctx, tripID)
+	}
+	return nil, nil
+}
+
+func (m *mockRepository) SaveQuote(ctx context.Context, quote *domain.Quote) error {
+	if m.saveQuoteFunc != nil {
+		return m.saveQuoteFunc(ctx, quote)
+	}
+	return nil
+}
+
+func (m *mockRepository) FindRecentQuote(ctx context.Context, userID, routeKey string, since time.Time) (*domain.Quote, error) {
+	if m.findQuoteFunc != nil {
+		return m.findQuoteFunc(ctx, userID, routeKey, since)
+	}
+	return nil, nil
+}
+
+func (m *mockRepository) GetUserData(ctx context.Context, userID string) (*domain.UserData, error) {
+	if m.getUserDataFunc != nil {
+		return m.getUserDataFunc(ctx, userID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRepository) EraseUserData(ctx context.Context, userID, pseudonym string) (*domain.ErasureReport, error) {
+	if m.eraseUserFunc != nil {
+		return m.eraseUserFunc(ctx, userID, pseudonym)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockRepository) SetTripDriver(ctx context.Context, tripID string, driver *trip.TripDriver) error {
+	if m.setDriverFunc != nil {
+		return m.setDriverFunc(ctx, tripID, driver)
+	}
+	return nil
+}
+
+func (m *mockRepository) SearchTrips(ctx context.Context, filter domain.TripFilter) ([]*types.Trip, error) {
+	if m.searchTripsFunc != nil {
+		return m.searchTripsFunc(ctx, filter)
+	}
+	return nil, nil
+}
+
+func (m *mockRepository) ExpireQuotes(ctx context.Context, filter domain.QuoteFilter, createdAt time.Time) (int, error) {
+	if m.expireQuotesFunc != nil {
+		return m.expireQuotesFunc(ctx, filter, createdAt)
+	}
+	return 0, nil
+}
+
 func TestCreateTrip(t *testing.T) {
 	t.Run("successful trip creation", func(t *testing.T) {
 		// Setup
@@ -76,7 +200,7 @@ func TestCreateTrip(t *testing.T) {
 		svc := NewService(nil, mockRepo)
 
 		// Execute
-		_, err := svc.CreateTrip(context.Background(), nil)
+		_, err := svc.CreateTrip(context.Background(), &types.RideFare{UserID: "user-123"})
 
 		// Verify
 		if err != nil {
@@ -85,6 +209,176 @@ func TestCreateTrip(t *testing.T) {
 	})
 }
 
+func TestUpdateTrip(t *testing.T) {
+	t.Run("records a status change event", func(t *testing.T) {
+		// Setup
+		tripID := primitive.NewObjectID()
+		current := &types.Trip{ID: tripID, UserID: "user-123", Status: "pending"}
+
+		var recorded *domain.TripEvent
+		mockRepo := &mockRepository{
+			getTripFunc: func(ctx context.Context, id string) (*types.Trip, error) {
+				snapshot := *current
+				return &snapshot, nil
+			},
+			updateTripFunc: func(ctx context.Context, id string, status string, driver *driver.Driver) error {
+				current.Status = status
+				return nil
+			},
+			saveEventFunc: func(ctx context.Context, event *domain.TripEvent) error {
+				recorded = event
+				return nil
+			},
+		}
+		svc := NewService(nil, mockRepo)
+
+		ctx := domain.WithEventMeta(context.Background(), domain.EventMeta{
+			Actor:  domain.Actor{Type: domain.ActorDriver, ID: "driver-1"},
+			Source: "driver.cmd.trip_accept",
+		})
+
+		// Execute
+		err := svc.UpdateTrip(ctx, tripID.Hex(), "accepted", nil)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if recorded == nil {
+			t.Fatal("expected a trip event to be recorded")
+		}
+		if recorded.Type != domain.TripEventStatusChanged {
+			t.Errorf("expected event type %s, got %s", domain.TripEventStatusChanged, recorded.Type)
+		}
+		if recorded.FromStatus != "pending" || recorded.ToStatus != "accepted" {
+			t.Errorf("expected pending -> accepted, got %s -> %s", recorded.FromStatus, recorded.ToStatus)
+		}
+		if recorded.Actor.Type != domain.ActorDriver || recorded.Actor.ID != "driver-1" {
+			t.Errorf("unexpected actor: %+v", recorded.Actor)
+		}
+		if recorded.Source != "driver.cmd.trip_accept" {
+			t.Errorf("unexpected source: %s", recorded.Source)
+		}
+	})
+
+	t.Run("repository error skips the event", func(t *testing.T) {
+		// Setup
+		expectedErr := errors.New("database connection failed")
+		mockRepo := &mockRepository{
+			getTripFunc: func(ctx context.Context, id string) (*types.Trip, error) {
+				return &types.Trip{Status: "pending"}, nil
+			},
+			updateTripFunc: func(ctx context.Context, id string, status string, driver *driver.Driver) error {
+				return expectedErr
+			},
+			saveEventFunc: func(ctx context.Context, event *domain.TripEvent) error {
+				t.Error("expected no event to be recorded")
+				return nil
+			},
+		}
+		svc := NewService(nil, mockRepo)
+
+		// Execute
+		err := svc.UpdateTrip(context.Background(), primitive.NewObjectID().Hex(), "accepted", nil)
+
+		// Verify
+		if !errors.Is(err, expectedErr) {
+			t.Errorf("expected error to be %v, got %v", expectedErr, err)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		current string
+		status  string
+		wantErr error
+	}{
+		{name: "invalid transition", current: domain.TripStatusPaid, status: domain.TripStatusAccepted, wantErr: domain.ErrInvalidTransition},
+		{name: "skipped status", current: domain.TripStatusPending, status: domain.TripStatusPaid, wantErr: domain.ErrInvalidTransition},
+		{name: "same status", current: domain.TripStatusAccepted, status: domain.TripStatusAccepted, wantErr: domain.ErrTripNotModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockRepo := &mockRepository{
+				getTripFunc: func(ctx context.Context, id string) (*types.Trip, error) {
+					return &types.Trip{Status: tt.current}, nil
+				},
+				updateTripFunc: func(ctx context.Context, id string, status string, driver *driver.Driver) error {
+					t.Error("expected the trip not to be updated")
+					return nil
+				},
+			}
+			svc := NewService(nil, mockRepo)
+
+			// Execute
+			err := svc.UpdateTrip(context.Background(), primitive.NewObjectID().Hex(), tt.status, nil)
+
+			// Verify
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected error to be %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGetAndValidateFare(t *testing.T) {
+	tests := []struct {
+		name        string
+		fare        *types.RideFare
+		expectedErr error
+	}{
+		{
+			name: "valid fare",
+			fare: &types.RideFare{UserID: "user-123", CreatedAt: time.Now()},
+		},
+		{
+			name:        "missing fare",
+			expectedErr: domain.ErrFareNotFound,
+		},
+		{
+			name:        "fare of another user",
+			fare:        &types.RideFare{UserID: "user-456", CreatedAt: time.Now()},
+			expectedErr: domain.ErrForbidden,
+		},
+		{
+			name:        "expired fare",
+			fare:        &types.RideFare{UserID: "user-123", CreatedAt: time.Now().Add(-domain.RideFareTTL - time.Minute)},
+			expectedErr: domain.ErrFareExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockRepo := &mockRepository{
+				getRideFareFunc: func(ctx context.Context, id string) (*types.RideFare, error) {
+					if tt.fare == nil {
+						return nil, domain.ErrFareNotFound
+					}
+					return tt.fare, nil
+				},
+			}
+			svc := NewService(nil, mockRepo)
+
+			// Execute
+			fare, err := svc.GetAndValidateFare(context.Background(), primitive.NewObjectID().Hex(), "user-123")
+
+			// Verify
+			if tt.expectedErr == nil {
+				if err != nil || fare == nil {
+					t.Errorf("expected the fare, got %v, %v", fare, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
 func TestGetRoute(t *testing.T) {
 	t.Run("successful route retrieval", func(t *testing.T) {
 		// Create a mock OSRM server
@@ -184,6 +478,9 @@ func TestGetRoute(t *testing.T) {
 		if !strings.Contains(err.Error(), "500") {
 			t.Errorf("expected error to mention status code 500, got: %v", err)
 		}
+		if !errors.Is(err, domain.ErrUpstreamUnavailable) {
+			t.Errorf("expected ErrUpstreamUnavailable, got: %v", err)
+		}
 		if result != nil {
 			t.Errorf("expected nil result, got %v", result)
 		}
@@ -193,10 +490,10 @@ func TestGetRoute(t *testing.T) {
 func TestCreateTripFares(t *testing.T) {
 	t.Run("successful creation of multiple fares", func(t *testing.T) {
 		// Setup
-		savedFares := []*types.RideFare{}
+		var savedQuotes []*domain.Quote
 		mockRepo := &mockRepository{
-			saveRideFareFunc: func(ctx context.Context, rideFare *types.RideFare) error {
-				savedFares = append(savedFares, rideFare)
+			saveQuoteFunc: func(ctx context.Context, quote *domain.Quote) error {
+				savedQuotes = append(savedQuotes, quote)
 				return nil
 			},
 		}
@@ -244,8 +541,8 @@ func TestCreateTripFares(t *testing.T) {
 		if len(result) != 3 {
 			t.Fatalf("expected 3 fares, got %d", len(result))
 		}
-		if len(savedFares) != 3 {
-			t.Fatalf("expected 3 fares saved to repository, got %d", len(savedFares))
+		if len(savedQuotes) != 1 || len(savedQuotes[0].Fares) != 3 {
+			t.Fatalf("expected one quote with 3 fares, got %+v", savedQuotes)
 		}
 
 		// Verify each fare
@@ -265,6 +562,73 @@ func TestCreateTripFares(t *testing.T) {
 			if fare.ID.IsZero() {
 				t.Errorf("fare %d: ID should be generated", i)
 			}
+			if savedQuotes[0].Fares[i].FareID != fare.ID {
+				t.Errorf("fare %d: expected the fare to be stored on the quote", i)
+			}
+		}
+	})
+
+	t.Run("reuses a recent quote of the same route", func(t *testing.T) {
+		// Setup
+		var savedQuote *domain.Quote
+		mockRepo := &mockRepository{
+			saveQuoteFunc: func(ctx context.Context, quote *domain.Quote) error {
+				quote.CreatedAt = time.Now()
+				savedQuote = quote
+				return nil
+			},
+			findQuoteFunc: func(ctx context.Context, userID, routeKey string, since time.Time) (*domain.Quote, error) {
+				if savedQuote == nil || savedQuote.UserID != userID || savedQuote.RouteKey != routeKey {
+					return nil, nil
+				}
+				return savedQuote, nil
+			},
+		}
+		svc := NewService(nil, mockRepo)
+
+		route := &types.OsrmApiResponse{
+			Routes: []struct {
+				Distance float64 `json:"distance"`
+				Duration float64 `json:"duration"`
+				Geometry struct {
+					Coordinates [][]float64 `json:"coordinates"`
+				} `json:"geometry"`
+			}{
+				{Distance: 1000.0, Duration: 600.0},
+			},
+		}
+		route.Routes[0].Geometry.Coordinates = [][]float64{{100.0, 13.0}, {100.1, 13.1}}
+
+		inputFares := []*types.RideFare{
+			{PackageSlug: "suv", TotalPriceInCents: 1850.0},
+			{PackageSlug: "sedan", TotalPriceInCents: 2000.0},
+		}
+
+		// Execute
+		first, err := svc.CreateTripFares(context.Background(), inputFares, "test-user", route)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		mockRepo.saveQuoteFunc = func(ctx context.Context, quote *domain.Quote) error {
+			t.Error("expected no quote to be saved for a repeated preview")
+			return nil
+		}
+		second, err := svc.CreateTripFares(context.Background(), inputFares, "test-user", route)
+
+		// Verify
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(second) != len(first) {
+			t.Fatalf("expected %d fares, got %d", len(first), len(second))
+		}
+		for i := range first {
+			if second[i].ID != first[i].ID {
+				t.Errorf("fare %d: expected ID %s, got %s", i, first[i].ID.Hex(), second[i].ID.Hex())
+			}
+			if second[i].Route != route {
+				t.Errorf("fare %d: route not set correctly", i)
+			}
 		}
 	})
 
@@ -272,7 +636,7 @@ func TestCreateTripFares(t *testing.T) {
 		// Setup
 		expectedErr := errors.New("database connection failed")
 		mockRepo := &mockRepository{
-			saveRideFareFunc: func(ctx context.Context, rideFare *types.RideFare) error {
+			saveQuoteFunc: func(ctx context.Context, quote *domain.Quote) error {
 				return expectedErr
 			},
 		}
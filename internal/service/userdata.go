@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ride4Low/trip-service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *service) ExportUserData(ctx context.Context, userID string) (*domain.UserData, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	data, err := s.repo.GetUserData(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user data: %w", err)
+	}
+
+	return data, nil
+}
+
+func (s *service) EraseUserData(ctx context.Context, userID, mode string) (*domain.ErasureReport, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var pseudonym string
+	switch mode {
+	case domain.ErasurePseudonymize:
+		// Random rather than derived from the user ID, so it cannot be
+		// linked back to the user
+		pseudonym = "erased-" + primitive.NewObjectID().Hex()
+	case domain.ErasureDelete:
+	default:
+		return nil, domain.ErrInvalidErasureMode
+	}
+
+	report, err := s.repo.EraseUserData(ctx, userID, pseudonym)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase user data: %w", err)
+	}
+
+	requestedBy := "internal"
+	if principal, ok := domain.PrincipalFromContext(ctx); ok {
+		requestedBy = principal.ID
+	}
+	s.logger.InfoContext(ctx, "Erased the data of a user",
+		"user_id", userID, "mode", mode, "requested_by", requestedBy,
+		"trips", report.Trips, "trip_events", report.TripEvents, "ratings", report.Ratings,
+		"ride_fares", report.RideFares, "quotes", report.Quotes)
+
+	return report, nil
+}
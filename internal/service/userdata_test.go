@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ride4Low/trip-service/internal/domain"
+)
+
+func TestExportUserData(t *testing.T) {
+	mockRepo := &mockRepository{
+		getUserDataFunc: func(ctx context.Context, userID string) (*domain.UserData, error) {
+			return &domain.UserData{UserID: userID}, nil
+		},
+	}
+	svc := NewService(nil, mockRepo)
+
+	t.Run("admin", func(t *testing.T) {
+		data, err := svc.ExportUserData(withPrincipal("admin-1", domain.RoleAdmin), "user-123")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if data.UserID != "user-123" {
+			t.Errorf("expected the data of user-123, got %s", data.UserID)
+		}
+	})
+
+	t.Run("not an admin", func(t *testing.T) {
+		_, err := svc.ExportUserData(withPrincipal("user-123", domain.RoleRider), "user-123")
+		if !errors.Is(err, domain.ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+	})
+}
+
+func TestEraseUserData(t *testing.T) {
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		mode          string
+		wantErr       error
+		wantPseudonym bool
+	}{
+		{name: "pseudonymize", ctx: withPrincipal("admin-1", domain.RoleAdmin), mode: domain.ErasurePseudonymize, wantPseudonym: true},
+		{name: "delete", ctx: withPrincipal("admin-1", domain.RoleAdmin), mode: domain.ErasureDelete},
+		{name: "internal caller", ctx: context.Background(), mode: domain.ErasureDelete},
+		{name: "unknown mode", ctx: withPrincipal("admin-1", domain.RoleAdmin), mode: "anonymize", wantErr: domain.ErrInvalidErasureMode},
+		{name: "not an admin", ctx: withPrincipal("user-123", domain.RoleRider), mode: domain.ErasureDelete, wantErr: domain.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			var gotPseudonym string
+			called := false
+			mockRepo := &mockRepository{
+				eraseUserFunc: func(ctx context.Context, userID, pseudonym string) (*domain.ErasureReport, error) {
+					called = true
+					gotPseudonym = pseudonym
+					return &domain.ErasureReport{Trips: 2}, nil
+				},
+			}
+			svc := NewService(nil, mockRepo)
+
+			// Execute
+			report, err := svc.EraseUserData(tt.ctx, "user-123", tt.mode)
+
+			// Verify
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				if called {
+					t.Error("expected nothing to be erased")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if report.Trips != 2 {
+				t.Errorf("expected the repository report, got %+v", report)
+			}
+			if tt.wantPseudonym != strings.HasPrefix(gotPseudonym, "erased-") {
+				t.Errorf("expected pseudonym: %t, got %q", tt.wantPseudonym, gotPseudonym)
+			}
+		})
+	}
+}